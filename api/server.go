@@ -0,0 +1,174 @@
+// Package api exposes a small authenticated HTTP API for creating reminders
+// from scripts and home automation.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"reminders21/core"
+	"reminders21/logging"
+	"reminders21/storage"
+)
+
+// maxRequestBody limits the size of request bodies
+const maxRequestBody = 64 * 1024
+
+// Server is the HTTP API server
+type Server struct {
+	reminders core.Reminders
+	token     string
+	logger    logging.Logger
+	srv       *http.Server
+}
+
+// NewServer creates a new Server listening on addr. Requests must carry
+// "Authorization: Bearer <token>".
+func NewServer(addr, token string, reminders core.Reminders, logger logging.Logger) *Server {
+	s := &Server{
+		reminders: reminders,
+		token:     token,
+		logger:    logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reminders", s.authenticate(s.handleReminders))
+
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	return s
+}
+
+// Start serves requests until Shutdown is called
+func (s *Server) Start() error {
+	s.logger.Printf("Starting API server on %s", s.srv.Addr)
+	if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// authenticate rejects requests without the configured bearer token
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// createReminderRequest is the body of POST /reminders
+type createReminderRequest struct {
+	ChatID int64  `json:"chat_id"`
+	UserID int64  `json:"user_id"` // Defaults to chat_id, which is the user ID in private chats
+	Label  string `json:"label"`
+	Time   string `json:"time"` // RFC 3339, or "2006-01-02 15:04" in the user's timezone
+}
+
+// createReminderResponse is returned after a reminder is created
+type createReminderResponse struct {
+	ID   int64  `json:"id"`
+	Time string `json:"time"`
+}
+
+// handleReminders handles /reminders
+func (s *Server) handleReminders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req createReminderRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if req.ChatID == 0 {
+		writeError(w, http.StatusBadRequest, "chat_id is required")
+		return
+	}
+	if req.UserID == 0 {
+		req.UserID = req.ChatID
+	}
+
+	reminderTime, err := s.parseTime(r.Context(), req.UserID, req.Time)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid time: use RFC 3339 or \"2006-01-02 15:04\"")
+		return
+	}
+	if reminderTime.Before(time.Now()) {
+		writeError(w, http.StatusBadRequest, "time is in the past")
+		return
+	}
+
+	id, err := s.reminders.Create(r.Context(), storage.ReminderItem{
+		ChatID:       req.ChatID,
+		UserID:       req.UserID,
+		ReminderTime: reminderTime,
+		Label:        req.Label,
+	})
+	if errors.Is(err, core.ErrEmptyLabel) {
+		writeError(w, http.StatusBadRequest, "label is required")
+		return
+	}
+	if errors.Is(err, storage.ErrLimitReached) {
+		writeError(w, http.StatusForbidden, "reminder limit reached")
+		return
+	}
+	if err != nil {
+		s.logger.Printf("API: error creating reminder: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to create reminder")
+		return
+	}
+
+	s.logger.Printf("API: created reminder ID=%d, '%s' at %s (chat %d)",
+		id, req.Label, reminderTime.UTC().Format("2006-01-02 15:04:05"), req.ChatID)
+
+	writeJSON(w, http.StatusCreated, createReminderResponse{
+		ID:   id,
+		Time: reminderTime.Format(time.RFC3339),
+	})
+}
+
+// parseTime parses an RFC 3339 time, or a local time in the user's timezone
+func (s *Server) parseTime(ctx context.Context, userID int64, value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return s.reminders.ParseLocalTime(ctx, userID, "2006-01-02 15:04", value)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
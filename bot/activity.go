@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"context"
+	"time"
+)
+
+// lastActiveInterval is how often a user's activity is written at most
+const lastActiveInterval = time.Minute
+
+// updateLastActive records that the user sent a message, skipping the write if it was
+// recorded less than lastActiveInterval ago
+func (b *ReminderBot) updateLastActive(userID int64) {
+	now := time.Now()
+	if last, ok := b.lastActive.Load(userID); ok && now.Sub(last.(time.Time)) < lastActiveInterval {
+		return
+	}
+
+	if err := b.repo.UpdateLastActiveContext(context.Background(), userID, now); err != nil {
+		b.logger.Printf("Error recording activity of user %d: %v", userID, err)
+		return
+	}
+	b.lastActive.Store(userID, now)
+}
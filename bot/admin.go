@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// broadcastProgressStep is how often (in sent messages) broadcast progress is reported
+	broadcastProgressStep = 25
+	// maxUsersListed limits the /users output to fit in a single message
+	maxUsersListed = 50
+	// overdueThreshold is how late a reminder must be to count as overdue in /stats_global
+	overdueThreshold = 5 * time.Minute
+)
+
+// handleAdminCommand handles admin-only commands. The caller must check b.config.IsAdmin first.
+func (b *ReminderBot) handleAdminCommand(ctx context.Context, msg *tgbotapi.Message) {
+	b.logger.Printf("Admin command %s from %d", msg.Command(), msg.From.ID)
+
+	switch msg.Command() {
+	case "broadcast":
+		b.handleBroadcastCommand(ctx, msg)
+	case "users":
+		b.handleUsersCommand(ctx, msg)
+	case "stats_global":
+		b.handleGlobalStatsCommand(ctx, msg)
+	case "backup":
+		b.handleBackupCommand(msg)
+	case "reply":
+		b.handleReplyCommand(ctx, msg)
+	}
+}
+
+// handleBroadcastCommand sends the command's text to every active chat
+func (b *ReminderBot) handleBroadcastCommand(ctx context.Context, msg *tgbotapi.Message) {
+	text := strings.TrimSpace(msg.CommandArguments())
+	if text == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /broadcast <текст сообщения>")
+		b.bot.Send(reply)
+		return
+	}
+
+	chatIDs, err := b.repo.GetAllActiveChatIDsContext(ctx)
+	if err != nil {
+		b.logger.Printf("Error getting chat IDs for broadcast: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка чатов.")
+		b.bot.Send(reply)
+		return
+	}
+
+	status, err := b.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Рассылка: 0/%d", len(chatIDs))))
+	if err != nil {
+		b.logger.Printf("Error sending broadcast status: %v", err)
+		return
+	}
+
+	// Sending can take a while, so don't hold up the update handler
+	go b.broadcast(chatIDs, text, status)
+}
+
+// broadcast sends text to the chats with throttling, editing the status message as it goes
+func (b *ReminderBot) broadcast(chatIDs []int64, text string, status tgbotapi.Message) {
+	sent, failed := 0, 0
+
+	reportProgress := func(text string) {
+		edit := tgbotapi.NewEditMessageText(status.Chat.ID, status.MessageID, text)
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error updating broadcast status: %v", err)
+		}
+	}
+
+send:
+	for i, chatID := range chatIDs {
+		if i > 0 && b.config.SendInterval > 0 {
+			select {
+			case <-b.stopChan:
+				break send
+			case <-time.After(b.config.SendInterval):
+			}
+		}
+
+		if _, err := b.bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			b.logger.Printf("Failed to send broadcast to chat %d: %v", chatID, err)
+			failed++
+		} else {
+			sent++
+		}
+
+		if (i+1)%broadcastProgressStep == 0 && i+1 < len(chatIDs) {
+			reportProgress(fmt.Sprintf("Рассылка: %d/%d", i+1, len(chatIDs)))
+		}
+	}
+
+	b.logger.Printf("Broadcast complete: %d messages sent successfully, %d failed", sent, failed)
+	reportProgress(fmt.Sprintf("Рассылка завершена: отправлено %d, ошибок %d из %d", sent, failed, len(chatIDs)))
+}
+
+// handleUsersCommand lists known users with their reminder counts
+func (b *ReminderBot) handleUsersCommand(ctx context.Context, msg *tgbotapi.Message) {
+	// The list shows every user's ID and activity, so it isn't posted to groups
+	if !msg.Chat.IsPrivate() {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Список пользователей можно получить только в личном чате с ботом.")
+		b.bot.Send(reply)
+		return
+	}
+
+	users, err := b.repo.GetUserSummariesContext(ctx)
+	if err != nil {
+		b.logger.Printf("Error getting user summaries: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка пользователей.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(users) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Пользователей пока нет.")
+		b.bot.Send(reply)
+		return
+	}
+
+	var lines []string
+	for i, u := range users {
+		if i == maxUsersListed {
+			lines = append(lines, fmt.Sprintf("… и ещё %d", len(users)-maxUsersListed))
+			break
+		}
+
+		line := fmt.Sprintf("%d – %s, напоминаний: %d, регулярных: %d",
+			u.UserID, u.Timezone, u.ActiveReminders, u.RecurringReminders)
+		if !u.LastActive.IsZero() {
+			line += ", активность: " + formatAge(time.Since(u.LastActive))
+		}
+		if !u.PausedUntil.IsZero() {
+			line += " (на паузе)"
+		}
+		lines = append(lines, line)
+	}
+
+	text := fmt.Sprintf("Пользователей: %d\n\n", len(users)) + strings.Join(lines, "\n")
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// handleGlobalStatsCommand shows aggregate statistics across all users
+func (b *ReminderBot) handleGlobalStatsCommand(ctx context.Context, msg *tgbotapi.Message) {
+	now := time.Now()
+	stats, err := b.repo.GetGlobalStatsContext(ctx, now, now.Add(-overdueThreshold))
+	if err != nil {
+		b.logger.Printf("Error getting global stats: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении статистики.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf(`Статистика бота:
+
+Пользователей: %d (на паузе: %d)
+Активных за 24 часа: %d
+Активных напоминаний: %d
+Регулярных напоминаний: %d
+
+Отправлено за 24 часа: %d
+Регулярных отправлено за 24 часа: %d
+Просрочено (не отправлено вовремя): %d`,
+		stats.TotalUsers, stats.PausedUsers, stats.ActiveLast24h,
+		stats.ActiveReminders, stats.RecurringReminders,
+		stats.SentLast24h, stats.RecurringLast24h, stats.Overdue)
+
+	if stats.Overdue > 0 {
+		text += "\n\n⚠️ Есть просроченные напоминания – проверьте цикл отправки."
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
+	"reminders21/storage"
+	"reminders21/utils"
+)
+
+// monthDayCollapseLimit is the number of items above which a day is collapsed in /month
+const monthDayCollapseLimit = 3
+
+// dayAgenda holds the reminders and recurring occurrences of a single day
+type dayAgenda struct {
+	Date      time.Time
+	Reminders []storage.ReminderItem
+	Events    []RecurringEvent
+}
+
+// count returns the number of items scheduled for the day
+func (d dayAgenda) count() int {
+	return len(d.Reminders) + len(d.Events)
+}
+
+// title returns the day header, e.g. "На среду, 16.10"
+func (d dayAgenda) title() string {
+	return fmt.Sprintf("На %s, %s", utils.WeekdayToRussian(d.Date.Weekday()), d.Date.Format("02.01"))
+}
+
+// agendaBetween groups the user's reminders and recurring occurrences by day.
+// start must be the beginning of a day in the user's timezone; empty days are skipped.
+func (b *ReminderBot) agendaBetween(ctx context.Context, userID int64, start, end time.Time) ([]dayAgenda, error) {
+	reminders, err := b.reminders.ListBetween(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	recurringEvents, err := b.getApplicableRecurringReminders(userID, start, end)
+	if err != nil {
+		b.logger.Printf("Error getting recurring reminders: %v", err)
+		// Continue with the regular reminders we already have
+	}
+
+	var days []dayAgenda
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		agenda := dayAgenda{Date: day}
+
+		for _, r := range reminders {
+			if !r.ReminderTime.Before(day) && r.ReminderTime.Before(next) {
+				agenda.Reminders = append(agenda.Reminders, r)
+			}
+		}
+
+		for _, e := range recurringEvents {
+			if e.Date.Equal(day) {
+				agenda.Events = append(agenda.Events, e)
+			}
+		}
+
+		if agenda.count() > 0 {
+			days = append(days, agenda)
+		}
+	}
+
+	return days, nil
+}
+
+// handleDayCallback expands a day collapsed in the /month overview
+func (b *ReminderBot) handleDayCallback(query *tgbotapi.CallbackQuery) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	location := b.reminders.Location(ctx, query.From.ID)
+	day, err := time.ParseInLocation("20060102", strings.TrimPrefix(query.Data, "day_"), location)
+	if err != nil {
+		b.logger.Printf("Error parsing day from callback: %v", err)
+		return
+	}
+
+	days, err := b.agendaBetween(ctx, query.From.ID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		b.logger.Printf("Error getting day's reminders: %v", err)
+		reply := tgbotapi.NewMessage(query.Message.Chat.ID, "Ошибка при получении напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(days) == 0 {
+		reply := tgbotapi.NewMessage(query.Message.Chat.ID, "На этот день напоминаний больше нет.")
+		b.bot.Send(reply)
+		return
+	}
+
+	lines := formatDayLines(days[0].Reminders, days[0].Events)
+	reply := tgbotapi.NewMessage(query.Message.Chat.ID, days[0].title()+":\n"+strings.Join(lines, "\n"))
+	b.bot.Send(reply)
+}
+
+// todayLines formats the user's reminders and recurring occurrences for today, as in /today
+func (b *ReminderBot) todayLines(ctx context.Context, userID int64) ([]string, error) {
+	start := core.StartOfDay(b.reminders.Now(ctx, userID))
+
+	days, err := b.agendaBetween(ctx, userID, start, start.AddDate(0, 0, 1))
+	if err != nil || len(days) == 0 {
+		return nil, err
+	}
+	return formatDayLines(days[0].Reminders, days[0].Events), nil
+}
+
+// handleAgendaCommand shows or sets the time of the user's daily agenda:
+// "/agenda 08:00", "/agenda 08:00 всегда" to send it on empty days too, "/agenda off".
+// In group chats it sets the chat's agenda instead.
+func (b *ReminderBot) handleAgendaCommand(ctx context.Context, msg *tgbotapi.Message) {
+	args := strings.Fields(strings.ToLower(msg.CommandArguments()))
+
+	if !msg.Chat.IsPrivate() {
+		b.handleChatAgendaCommand(ctx, msg, args)
+		return
+	}
+
+	if len(args) == 0 {
+		subscription, err := b.repo.GetUserAgendaContext(ctx, msg.From.ID)
+		if err != nil {
+			b.logger.Printf("Error getting agenda of user %d: %v", msg.From.ID, err)
+		}
+
+		text := `План на день сейчас не присылается.
+
+Чтобы получать его каждое утро, укажите время, например:
+/agenda 08:00`
+		if subscription.Time != "" {
+			text = fmt.Sprintf("План на день приходит каждый день в %s", subscription.Time)
+			if subscription.SkipEmpty {
+				text += " (кроме дней без напоминаний)"
+			}
+			text += ".\n\nИзменить время: /agenda 09:00\nВыключить: /agenda off"
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	if args[0] == "off" || args[0] == "выкл" {
+		if err := b.repo.SetUserAgendaContext(ctx, msg.From.ID, "", true); err != nil {
+			b.logger.Printf("Error turning off agenda: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+			b.bot.Send(reply)
+			return
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "План на день больше не будет приходить.")
+		b.bot.Send(reply)
+		return
+	}
+
+	at, err := time.Parse("15:04", args[0])
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Укажите время в формате ЧЧ:ММ, например: /agenda 08:00")
+		b.bot.Send(reply)
+		return
+	}
+	skipEmpty := !(len(args) > 1 && (args[1] == "всегда" || args[1] == "always"))
+
+	if err := b.repo.SetUserAgendaContext(ctx, msg.From.ID, at.Format("15:04"), skipEmpty); err != nil {
+		b.logger.Printf("Error setting agenda: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("Буду присылать план на день каждый день в %s.", at.Format("15:04"))
+	if skipEmpty {
+		text += "\nВ дни без напоминаний ничего не пришлю. Чтобы получать план всегда: /agenda " + at.Format("15:04") + " всегда"
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// processDailyAgendas sends users their plan for the day at their chosen local time.
+// Agendas go to the user's private chat.
+func (b *ReminderBot) processDailyAgendas() {
+	ctx := context.Background()
+	subscriptions, err := b.repo.GetDueAgendasContext(ctx, time.Now())
+	if err != nil {
+		b.logger.Printf("Error getting due agendas: %v", err)
+		return
+	}
+
+	for _, s := range subscriptions {
+		lines, err := b.todayLines(ctx, s.UserID)
+		if err != nil {
+			b.logger.Printf("Error getting agenda of user %d: %v", s.UserID, err)
+			continue
+		}
+		if len(lines) == 0 && s.SkipEmpty {
+			continue
+		}
+
+		claimed, err := b.repo.ClaimAgendaContext(ctx, s.UserID, s.Date)
+		if err != nil {
+			b.logger.Printf("Error claiming agenda of user %d: %v", s.UserID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		text := "☀️ Вот твой день:\n" + strings.Join(lines, "\n")
+		if len(lines) == 0 {
+			text = "☀️ На сегодня напоминаний нет – свободный день!"
+		}
+
+		// In private chats the chat ID equals the user ID
+		if _, err := b.bot.Send(tgbotapi.NewMessage(s.UserID, text)); err != nil {
+			b.logger.Printf("Error sending agenda to user %d: %v", s.UserID, err)
+			continue
+		}
+
+		b.logger.Printf("Sent daily agenda to user %d (%d items)", s.UserID, len(lines))
+	}
+}
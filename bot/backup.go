@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// backupPrefix and backupSuffix surround the timestamp in backup file names
+	backupPrefix = "reminders-"
+	backupSuffix = ".db"
+	// backupTimeout bounds a single backup, which copies the whole database
+	backupTimeout = 5 * time.Minute
+	// maxBackupDocumentSize is Telegram's upload limit for bots
+	maxBackupDocumentSize = 50 * 1024 * 1024
+)
+
+// createBackup writes a timestamped copy of the database into dir and returns its path
+func (b *ReminderBot) createBackup(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, backupPrefix+time.Now().UTC().Format("20060102-150405")+backupSuffix)
+
+	ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+	defer cancel()
+
+	if err := b.repo.BackupContext(ctx, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// handleBackupCommand sends a fresh copy of the database to the admin. It only works in
+// private chats, since the copy holds every user's reminders.
+func (b *ReminderBot) handleBackupCommand(msg *tgbotapi.Message) {
+	if !msg.Chat.IsPrivate() {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Резервную копию можно получить только в личном чате с ботом.")
+		b.bot.Send(reply)
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "reminders-backup-")
+	if err != nil {
+		b.logger.Printf("Error creating backup directory: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось создать резервную копию.")
+		b.bot.Send(reply)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := b.createBackup(dir)
+	if err != nil {
+		b.logger.Printf("Error creating backup: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось создать резервную копию.")
+		b.bot.Send(reply)
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		b.logger.Printf("Error reading backup: %v", err)
+		return
+	}
+	if info.Size() > maxBackupDocumentSize {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Резервная копия слишком большая для отправки (%d МБ). Используйте BACKUP_DIR для резервных копий на сервере.",
+			info.Size()/(1024*1024)))
+		b.bot.Send(reply)
+		return
+	}
+
+	document := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FilePath(path))
+	document.Caption = fmt.Sprintf("Резервная копия базы от %s UTC", time.Now().UTC().Format("02.01.2006 15:04"))
+	if _, err := b.bot.Send(document); err != nil {
+		b.logger.Printf("Error sending backup: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось отправить резервную копию.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Printf("Sent database backup (%d bytes) to %d", info.Size(), msg.From.ID)
+}
+
+// scheduleBackups periodically backs up the database to BackupDir
+func (b *ReminderBot) scheduleBackups() {
+	b.logger.Printf("Starting scheduled backups to %s every %s", b.config.BackupDir, b.config.BackupInterval)
+	ticker := time.NewTicker(b.config.BackupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		case <-ticker.C:
+			path, err := b.createBackup(b.config.BackupDir)
+			if err != nil {
+				b.logger.Printf("Error creating scheduled backup: %v", err)
+				continue
+			}
+			b.logger.Printf("Created scheduled backup %s", path)
+			b.pruneBackups()
+		}
+	}
+}
+
+// pruneBackups deletes the oldest backups in BackupDir beyond BackupKeep
+func (b *ReminderBot) pruneBackups() {
+	if b.config.BackupKeep <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(b.config.BackupDir)
+	if err != nil {
+		b.logger.Printf("Error listing backups: %v", err)
+		return
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= b.config.BackupKeep {
+		return
+	}
+
+	// Timestamps in the names sort chronologically
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-b.config.BackupKeep] {
+		if err := os.Remove(filepath.Join(b.config.BackupDir, name)); err != nil {
+			b.logger.Printf("Error removing old backup %s: %v", name, err)
+		}
+	}
+}
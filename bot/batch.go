@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Kinds of confirmations counted in a batch summary
+const (
+	confirmCreated = iota
+	confirmUpdated
+	confirmDeleted
+)
+
+// batchButtonLabelLength is how much of a label is shown on a button in a batch summary
+const batchButtonLabelLength = 25
+
+// confirmation is the reply to a successful operation
+type confirmation struct {
+	kind  int
+	label string // Tells apart the buttons of several confirmations in a summary
+	reply tgbotapi.MessageConfig
+}
+
+// operationBatch collects the confirmations of the operations parsed from one message,
+// so they are sent as a single summary instead of flooding the chat
+type operationBatch struct {
+	confirmations []confirmation
+}
+
+// confirm sends the reply to a successful operation, or adds it to batch if it isn't nil
+func (b *ReminderBot) confirm(batch *operationBatch, kind int, label string, reply tgbotapi.MessageConfig) {
+	if batch == nil {
+		b.bot.Send(reply)
+		return
+	}
+	batch.confirmations = append(batch.confirmations, confirmation{kind: kind, label: label, reply: reply})
+}
+
+// sendBatch sends the collected confirmations: a single one as is, several as a summary
+// like "Готово: создано 2, удалено 1" with the first paragraph of each reply and all
+// of their buttons
+func (b *ReminderBot) sendBatch(chatID int64, batch *operationBatch) {
+	switch len(batch.confirmations) {
+	case 0:
+		return
+	case 1:
+		b.bot.Send(batch.confirmations[0].reply)
+		return
+	}
+
+	var counts [3]int
+	var details []string
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, c := range batch.confirmations {
+		counts[c.kind]++
+
+		detail, _, _ := strings.Cut(c.reply.Text, "\n\n")
+		details = append(details, "• "+detail)
+
+		keyboard, ok := c.reply.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+		if !ok {
+			continue
+		}
+		for _, row := range keyboard.InlineKeyboard {
+			for i := range row {
+				if c.label != "" {
+					row[i].Text += " «" + truncateText(c.label, batchButtonLabelLength) + "»"
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	var parts []string
+	for kind, word := range []string{"создано", "изменено", "удалено"} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", word, counts[kind]))
+		}
+	}
+
+	text := "Готово: " + strings.Join(parts, ", ") + "\n\n" + strings.Join(details, "\n")
+	reply := tgbotapi.NewMessage(chatID, text)
+	if len(rows) > 0 {
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	b.bot.Send(reply)
+}
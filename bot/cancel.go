@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// transcriptionTimeout limits transcribing a voice, audio or video message and parsing the text
+const transcriptionTimeout = 60 * time.Second
+
+// activeTranscription is a user's voice, audio or video message being processed
+type activeTranscription struct {
+	token  int64
+	cancel context.CancelFunc
+}
+
+// transcriptions tracks the media message each user is waiting on, so a new message
+// or /cancel can stop it
+type transcriptions struct {
+	mu     sync.Mutex
+	next   int64
+	active map[int64]activeTranscription
+}
+
+// newTranscriptions creates an empty tracker
+func newTranscriptions() *transcriptions {
+	return &transcriptions{active: make(map[int64]activeTranscription)}
+}
+
+// begin returns the context to process a user's media message with, cancelling the
+// one already in progress. done must be called when processing finishes.
+func (t *transcriptions) begin(userID int64) (ctx context.Context, done func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), transcriptionTimeout)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if previous, ok := t.active[userID]; ok {
+		previous.cancel()
+	}
+	t.next++
+	token := t.next
+	t.active[userID] = activeTranscription{token: token, cancel: cancel}
+
+	return ctx, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if current, ok := t.active[userID]; ok && current.token == token {
+			delete(t.active, userID)
+		}
+		cancel()
+	}
+}
+
+// cancel stops the media message the user is waiting on and reports whether there was one
+func (t *transcriptions) cancel(userID int64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.active[userID]
+	if !ok {
+		return false
+	}
+	current.cancel()
+	delete(t.active, userID)
+	return true
+}
+
+// cancelTranscription stops the user's voice, audio or video message still being processed
+// and tells them. It reports whether there was one.
+func (b *ReminderBot) cancelTranscription(msg *tgbotapi.Message) bool {
+	if !b.transcriptions.cancel(msg.From.ID) {
+		return false
+	}
+
+	b.logger.Printf("Cancelled transcription for user %d", msg.From.ID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Отменил обработку предыдущего голосового сообщения.")
+	b.bot.Send(reply)
+	return true
+}
+
+// handleCancelCommand stops the media message being processed or the question the bot
+// is waiting to be answered
+func (b *ReminderBot) handleCancelCommand(msg *tgbotapi.Message) {
+	if b.cancelTranscription(msg) {
+		return
+	}
+
+	if b.dialogs.clear(msg.Chat.ID, msg.From.ID) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Хорошо, отменил.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Сейчас нечего отменять.")
+	b.bot.Send(reply)
+}
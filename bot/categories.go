@@ -0,0 +1,37 @@
+package bot
+
+import "strings"
+
+// categoryIcons are the icons shown in lists before reminders of each category the LLM
+// detects; the category names are also listed in llm/functions.go
+var categoryIcons = map[string]string{
+	"work":     "💼",
+	"health":   "💊",
+	"shopping": "🛒",
+	"home":     "🏠",
+	"family":   "👨‍👩‍👧",
+	"finance":  "💰",
+	"study":    "📚",
+	"sport":    "🏃",
+	"travel":   "✈️",
+}
+
+// defaultCategoryIcon is shown before reminders without a known category
+const defaultCategoryIcon = "🔔"
+
+// normalizeCategory returns the category name the LLM gave if it's a known one, "" otherwise
+func normalizeCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if _, ok := categoryIcons[category]; !ok {
+		return ""
+	}
+	return category
+}
+
+// categoryIcon returns the list icon of a category, defaultCategoryIcon if it has none
+func categoryIcon(category string) string {
+	if icon, ok := categoryIcons[category]; ok {
+		return icon
+	}
+	return defaultCategoryIcon
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"reminders21/storage"
+)
+
+// chatMemberKey identifies a user in a chat in ReminderBot.chatMembers
+type chatMemberKey struct {
+	chatID, userID int64
+}
+
+// rememberChatMember records the name of the author of a group message so reminders
+// in the chat can be listed by creator. Unchanged names aren't written again.
+func (b *ReminderBot) rememberChatMember(msg *tgbotapi.Message) {
+	if msg.From == nil || msg.Chat == nil || msg.Chat.IsPrivate() {
+		return
+	}
+
+	member := storage.ChatMember{
+		UserID:   msg.From.ID,
+		Name:     strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName),
+		Username: msg.From.UserName,
+	}
+	key := chatMemberKey{msg.Chat.ID, msg.From.ID}
+	if saved, ok := b.chatMembers.Load(key); ok && saved.(storage.ChatMember) == member {
+		return
+	}
+
+	if err := b.repo.SaveChatMemberContext(context.Background(), msg.Chat.ID, member); err != nil {
+		b.logger.Printf("Error saving member %d of chat %d: %v", member.UserID, msg.Chat.ID, err)
+		return
+	}
+	b.chatMembers.Store(key, member)
+}
+
+// memberMatches reports whether a name like "Иван" or "@ivan" refers to the member
+func memberMatches(member storage.ChatMember, name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if username, ok := strings.CutPrefix(name, "@"); ok {
+		return strings.EqualFold(member.Username, username)
+	}
+	for _, part := range strings.Fields(strings.ToLower(member.Name)) {
+		if name == part {
+			return true
+		}
+		// Prefix match tolerates Russian case endings: "Ивана" matches "Иван"
+		if len([]rune(part)) >= 3 && strings.HasPrefix(name, part) {
+			return true
+		}
+	}
+	return strings.EqualFold(member.Username, name)
+}
+
+// sendChatList lists the reminders delivered to a group chat with their creators,
+// only those created by members matching creatorName if it isn't empty
+func (b *ReminderBot) sendChatList(ctx context.Context, msg *tgbotapi.Message, creatorName string) {
+	if msg.Chat.IsPrivate() {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Напоминания участников можно посмотреть в группе. Здесь все напоминания ваши: /list")
+		b.bot.Send(reply)
+		return
+	}
+
+	members, err := b.repo.GetChatMembersContext(ctx, msg.Chat.ID)
+	if err != nil {
+		b.logger.Printf("Error getting members of chat %d: %v", msg.Chat.ID, err)
+	}
+	names := make(map[int64]string, len(members))
+	var creatorIDs []int64
+	for _, m := range members {
+		names[m.UserID] = m.Name
+		if creatorName != "" && memberMatches(m, creatorName) {
+			creatorIDs = append(creatorIDs, m.UserID)
+		}
+	}
+
+	if creatorName != "" && len(creatorIDs) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Не знаю участника «%s». Я запоминаю участников, когда они пишут в этом чате.", creatorName))
+		b.bot.Send(reply)
+		return
+	}
+
+	reminders, err := b.repo.GetChatRemindersContext(ctx, msg.Chat.ID, creatorIDs)
+	if err != nil {
+		b.logger.Printf("Error getting reminders of chat %d: %v", msg.Chat.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(reminders) == 0 {
+		text := "В этом чате нет активных напоминаний."
+		if creatorName != "" {
+			text = fmt.Sprintf("У «%s» нет активных напоминаний в этом чате.", creatorName)
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	// Times are shown in the timezone of whoever asked
+	location := b.reminders.Location(ctx, msg.From.ID)
+
+	var lines []string
+	for _, r := range reminders {
+		r.ReminderTime = r.ReminderTime.In(location)
+		if !r.EndTime.IsZero() {
+			r.EndTime = r.EndTime.In(location)
+		}
+
+		creator := names[r.UserID]
+		if creator == "" {
+			creator = "неизвестный участник"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s – %s (%s)",
+			categoryIcon(r.Category), formatReminderTime(r, "02.01.2006 15:04"), b.listLabel(r.Label), creator))
+	}
+
+	title := "Напоминания в этом чате"
+	if creatorName != "" {
+		title = fmt.Sprintf("Напоминания от «%s»", creatorName)
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, title+":\n"+strings.Join(lines, "\n"))
+	b.bot.Send(reply)
+}
+
+// handleChatListCommand lists the reminders in a group chat: "/chat_list" for all of
+// them, "/chat_list Иван" or "/chat_list @ivan" for one member's
+func (b *ReminderBot) handleChatListCommand(ctx context.Context, msg *tgbotapi.Message) {
+	b.sendChatList(ctx, msg, strings.TrimSpace(msg.CommandArguments()))
+}
@@ -0,0 +1,232 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"reminders21/core"
+	"reminders21/storage"
+)
+
+// isChatAdmin reports whether the user administers the group chat. Only admins change
+// chat settings.
+func (b *ReminderBot) isChatAdmin(chatID, userID int64) bool {
+	member, err := b.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		b.logger.Printf("Error getting member %d of chat %d: %v", userID, chatID, err)
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// chatDeletesByAnyone reports whether any member of the chat may delete its one-time reminders
+func (b *ReminderBot) chatDeletesByAnyone(ctx context.Context, chat *tgbotapi.Chat) bool {
+	if chat.IsPrivate() {
+		return false
+	}
+
+	prefs, err := b.repo.GetChatPreferencesContext(ctx, chat.ID)
+	if err != nil {
+		b.logger.Printf("Error getting settings of chat %d: %v", chat.ID, err)
+		return false
+	}
+	return prefs.DeletePolicy == storage.DeleteByAnyone
+}
+
+// deleteChatReminder deletes a one-time reminder delivered to the chat on behalf of its
+// creator, if the chat lets any member delete reminders
+func (b *ReminderBot) deleteChatReminder(ctx context.Context, chat *tgbotapi.Chat, reminderID int64) (bool, error) {
+	reminder, err := b.reminders.Get(ctx, reminderID)
+	if err != nil {
+		return false, err
+	}
+	if reminder.ChatID != chat.ID || !b.chatDeletesByAnyone(ctx, chat) {
+		return false, storage.ErrNotOwned
+	}
+	return b.reminders.Delete(ctx, reminderID, reminder.UserID)
+}
+
+// handleChatSettingsCommand shows or changes the settings of a group chat:
+// "/chat_settings delete anyone" lets any member delete the chat's reminders,
+// "/chat_settings delete creator" leaves it to their creators
+func (b *ReminderBot) handleChatSettingsCommand(ctx context.Context, msg *tgbotapi.Message) {
+	if msg.Chat.IsPrivate() {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Настройки чата меняются в группе. Личные настройки: /timezone, /agenda, /snooze_default.")
+		b.bot.Send(reply)
+		return
+	}
+
+	args := strings.Fields(strings.ToLower(msg.CommandArguments()))
+	if len(args) == 0 {
+		prefs, err := b.repo.GetChatPreferencesContext(ctx, msg.Chat.ID)
+		if err != nil {
+			b.logger.Printf("Error getting settings of chat %d: %v", msg.Chat.ID, err)
+		}
+
+		deletes := "только автор"
+		if prefs.DeletePolicy == storage.DeleteByAnyone {
+			deletes = "любой участник"
+		}
+		agenda := "не присылается"
+		if prefs.AgendaTime != "" {
+			agenda = fmt.Sprintf("каждый день в %s (%s)", prefs.AgendaTime, prefs.Timezone)
+		}
+
+		text := fmt.Sprintf(`Настройки чата:
+• Удалить напоминание может: %s
+• План на день: %s
+
+Изменить (только админы):
+/chat_settings delete anyone – удалять может любой участник
+/chat_settings delete creator – только автор
+/agenda 09:00 – присылать в чат план на день, /agenda off – выключить`, deletes, agenda)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(args) != 2 || args[0] != "delete" || (args[1] != storage.DeleteByAnyone && args[1] != storage.DeleteByCreator) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /chat_settings delete anyone или /chat_settings delete creator")
+		b.bot.Send(reply)
+		return
+	}
+
+	if !b.isChatAdmin(msg.Chat.ID, msg.From.ID) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Настройки чата могут менять только его администраторы.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if err := b.repo.SetChatDeletePolicyContext(ctx, msg.Chat.ID, args[1]); err != nil {
+		b.logger.Printf("Error setting delete policy of chat %d: %v", msg.Chat.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := "Готово! Теперь напоминание в этом чате может удалить любой участник."
+	if args[1] == storage.DeleteByCreator {
+		text = "Готово! Теперь напоминание в этом чате может удалить только его автор."
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// handleChatAgendaCommand sets the daily agenda of a group chat, which lists the
+// reminders delivered to the chat that day: "/agenda 09:00", "/agenda 09:00 всегда",
+// "/agenda off". The agenda follows the timezone of the admin who sets it.
+func (b *ReminderBot) handleChatAgendaCommand(ctx context.Context, msg *tgbotapi.Message, args []string) {
+	if len(args) == 0 {
+		prefs, err := b.repo.GetChatPreferencesContext(ctx, msg.Chat.ID)
+		if err != nil {
+			b.logger.Printf("Error getting settings of chat %d: %v", msg.Chat.ID, err)
+		}
+
+		text := "План на день в этот чат не присылается.\n\nЧтобы получать его каждое утро, администратор может указать время, например:\n/agenda 08:00"
+		if prefs.AgendaTime != "" {
+			text = fmt.Sprintf("План на день приходит в этот чат каждый день в %s (%s).\n\nВыключить: /agenda off",
+				prefs.AgendaTime, prefs.Timezone)
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	if !b.isChatAdmin(msg.Chat.ID, msg.From.ID) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "План на день для чата могут настроить только его администраторы.")
+		b.bot.Send(reply)
+		return
+	}
+
+	var agendaTime string
+	skipEmpty := true
+	if args[0] != "off" && args[0] != "выкл" {
+		at, err := time.Parse("15:04", args[0])
+		if err != nil {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Укажите время в формате ЧЧ:ММ, например: /agenda 08:00")
+			b.bot.Send(reply)
+			return
+		}
+		agendaTime = at.Format("15:04")
+		skipEmpty = !(len(args) > 1 && (args[1] == "всегда" || args[1] == "always"))
+	}
+
+	timezone := b.reminders.Location(ctx, msg.From.ID).String()
+	if err := b.repo.SetChatAgendaContext(ctx, msg.Chat.ID, agendaTime, skipEmpty, timezone); err != nil {
+		b.logger.Printf("Error setting agenda of chat %d: %v", msg.Chat.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := "План на день больше не будет приходить в этот чат."
+	if agendaTime != "" {
+		text = fmt.Sprintf("Буду присылать в этот чат план на день каждый день в %s (%s).", agendaTime, timezone)
+		if skipEmpty {
+			text += "\nВ дни без напоминаний ничего не пришлю."
+		}
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// processChatAgendas sends group chats the reminders delivered to them that day
+func (b *ReminderBot) processChatAgendas() {
+	ctx := context.Background()
+	agendas, err := b.repo.GetDueChatAgendasContext(ctx, time.Now())
+	if err != nil {
+		b.logger.Printf("Error getting due chat agendas: %v", err)
+		return
+	}
+
+	for _, a := range agendas {
+		reminders, err := b.repo.GetChatRemindersContext(ctx, a.ChatID, nil)
+		if err != nil {
+			b.logger.Printf("Error getting agenda of chat %d: %v", a.ChatID, err)
+			continue
+		}
+
+		start := core.StartOfDay(time.Now().In(a.Location))
+		end := start.AddDate(0, 0, 1)
+		var today []storage.ReminderItem
+		for _, r := range reminders {
+			r.ReminderTime = r.ReminderTime.In(a.Location)
+			if r.ReminderTime.Before(start) || !r.ReminderTime.Before(end) {
+				continue
+			}
+			if !r.EndTime.IsZero() {
+				r.EndTime = r.EndTime.In(a.Location)
+			}
+			today = append(today, r)
+		}
+		if len(today) == 0 && a.SkipEmpty {
+			continue
+		}
+
+		claimed, err := b.repo.ClaimChatAgendaContext(ctx, a.ChatID, a.Date)
+		if err != nil {
+			b.logger.Printf("Error claiming agenda of chat %d: %v", a.ChatID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		text := "☀️ Сегодня в чате:\n" + strings.Join(formatDayLines(today, nil), "\n")
+		if len(today) == 0 {
+			text = "☀️ На сегодня напоминаний в чате нет."
+		}
+		if _, err := b.bot.Send(tgbotapi.NewMessage(a.ChatID, text)); err != nil {
+			b.logger.Printf("Error sending agenda to chat %d: %v", a.ChatID, err)
+			continue
+		}
+
+		b.logger.Printf("Sent daily agenda to chat %d (%d items)", a.ChatID, len(today))
+	}
+}
@@ -3,37 +3,216 @@ package bot
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
+	"reminders21/llm"
+	"reminders21/utils"
 )
 
-// handleCommand handles bot commands
-func (b *ReminderBot) handleCommand(msg *tgbotapi.Message) {
-	b.logger.Printf("Received command: %s from %d", msg.Command(), msg.From.ID)
-
-	switch msg.Command() {
-	case "start":
-		welcome := `Привет! 👋 Я твой бот-напоминалка. С моей помощью ты никогда не пропустишь важные дедлайны. 🧾
+// defaultWelcomeText is the /start message unless WELCOME_TEXT overrides it
+const defaultWelcomeText = `Привет! 👋 Я твой бот-напоминалка. С моей помощью ты никогда не пропустишь важные дедлайны. 🧾
 Отправь мне текстовое или голосовое сообщение с самим напоминанием, датой и временем! 😜 И я напомню тебе про твоё важное дело в нужное время 🤟
 Если захочешь изменить или удалить дело, или узнать список дел на день – просто скажи мне об этом 😁
 Доступные команды:
 • /start – Приветственное сообщение
 • /list – Показать список будущих напоминаний
 • /recurring – Показать список регулярных напоминаний
+• /next – Ближайшие даты регулярного напоминания
 • /today – Показать напоминания на сегодня
+• /soon – Показать напоминания на ближайшие часы
 • /tomorrow – Показать напоминания на завтра
+• /week – Показать напоминания на неделю
+• /month – Обзор напоминаний на месяц
+• /past – Показать последние сработавшие напоминания
+• /chat_list – Напоминания всех участников группы
+• /chat_settings – Настройки группы: кто удаляет напоминания, план на день
+• /agenda – Присылать план на день по утрам
+• /pause – Приостановить все напоминания
+• /resume – Возобновить напоминания
+• /snooze_default – Настроить, на сколько откладывать напоминания
+• /daytime – Настроить, во сколько «утром», «днём» и «вечером»
+• /default_hour – Настроить, во сколько напоминать, если указана только дата
+• /origin – Показывать в напоминаниях, когда и как они созданы
+• /delivery – Настроить, как начинаются пришедшие напоминания
+• /todos – Список задач с кнопками «выполнено»
+• /clear_todos – Отметить все задачи выполненными
+• /move – Перенести напоминание в другой чат
+• /transfer – Передать все напоминания другому пользователю
+• /templates – Шаблоны напоминаний
+• /cancel – Отменить обработку голосового сообщения
+• /feedback – Сообщить о проблеме или предложить идею
 • /help – Показать помощь`
 
+// defaultHelpText is the /help message unless HELP_TEXT overrides it
+const defaultHelpText = `Как пользоваться ботом:
+
+1. Создать напоминание:
+   Просто напишите, что и когда вам напомнить, например:
+   "Напомни купить молоко завтра в 18:00"
+   "Напомни позвонить маме через 2 часа"
+   "Совещание в понедельник в 10:00"
+   "Позвонить в банк завтра утром" – без точного времени «утром» это 09:00, «днём» – 13:00, «вечером» – 19:00
+   • /daytime утром 08:00 - изменить, во сколько напоминать «утром»
+   "Напомни 5 марта купить подарок" – без времени напомню в 10:00
+   • /default_hour 9 - напоминать в 09:00, если указана только дата
+
+2. Создать регулярное напоминание:
+   "Напоминай выпить таблетки каждый день в 10:00"
+   "Напоминай про йогу каждый вторник в 19:00"
+   "Напоминай про зарядку по будням в 07:30" (или "по выходным")
+   "Напоминай про оплату счетов каждого 10 числа в 15:00"
+
+3. Создать задачу без напоминания:
+   "Добавь в список: купить цветы в пятницу"
+   "Добавь в список дел: позвонить маме в субботу"
+   "Запиши задачу позвонить в банк каждый понедельник"
+   • /todos - список задач, отмечайте выполненные кнопками
+   • /clear_todos - отметить все задачи выполненными
+
+   Шаблоны – чтобы быстро создавать похожие напоминания:
+   • /template save зарядка - сохранить последнее созданное напоминание как шаблон
+   • /template use зарядка 18:30 - создать напоминание по шаблону
+   • /templates - список шаблонов
+
+4. Посмотреть напоминания и задачи:
+   • /list - все активные напоминания и задачи
+   • /recurring - все повторяющиеся напоминания и задачи
+   • /next rec_5 - ближайшие 5 дат повторяющегося напоминания
+   • /today - напоминания и задачи на сегодня
+   • /soon - напоминания на ближайшие 3 часа, в том числе после полуночи (/soon 6 - на 6 часов)
+   • /tomorrow - напоминания и задачи на завтра
+   • /week - напоминания и задачи на 7 дней вперёд
+   • /month - обзор текущего месяца по дням
+   • /past - последние 10 сработавших напоминаний (/past 20 - последние 20)
+   • /chat_list - напоминания всех участников группы (/chat_list Иван - только созданные Иваном)
+   • "Покажи напоминания, которые создал Иван" - в группе
+   • /agenda 08:00 в группе - присылать в чат план на день (настраивают админы)
+   • /chat_settings - настройки группы, например кто может удалять напоминания
+   • /agenda 08:00 - каждый день в 08:00 присылать план на день (/agenda off - выключить)
+   • "Покажи мои дела на сегодня"
+   • "Что у меня запланировано на эту неделю?"
+
+5. Изменить напоминание:
+   "Перенеси напоминание о совещании на 11:00"
+   "Измени встречу с клиентом на завтра"
+   • /move - перенести напоминание в другой чат (например, из лички в группу)
+   • /transfer @username - передать все свои напоминания коллеге; они перейдут после того, как получатель нажмёт «Принять»
+
+6. Удалить напоминание или задачу:
+   "Удали напоминание о встрече"
+   "Отмени регулярное напоминание про йогу"
+   "Удали задачу купить цветы"
+
+7. Поставить напоминания на паузу:
+   • /pause - до команды /resume
+   • /pause 7 - на 7 дней
+   • /resume - снять паузу
+
+8. Отложить напоминание:
+   Нажмите кнопку «⏰» под пришедшим напоминанием.
+   • /snooze_default 20 - первая кнопка будет откладывать на 20 минут
+   • /origin on - добавлять к напоминанию, когда и какой фразой оно создано
+   • /delivery on - начинать напоминания со слова «Напоминаю:»
+
+Вы также можете отправлять голосовые сообщения и аудиофайлы (.m4a, .mp3)! Если распознавание затянулось, отправьте /cancel или новое сообщение.
+А ещё фото с подписью – фото придёт вместе с напоминанием.
+Перешлите сообщение или ответьте на пересланное, например «напомни прочитать завтра в 10» – его текст придёт вместе с напоминанием.
+
+Нашли ошибку или есть идея? Напишите /feedback и текст сообщения.`
+
+// welcomeText returns the /start message
+func (b *ReminderBot) welcomeText() string {
+	if b.config.WelcomeText != "" {
+		return b.config.WelcomeText
+	}
+	return defaultWelcomeText
+}
+
+// helpText returns the /help message
+func (b *ReminderBot) helpText() string {
+	if b.config.HelpText != "" {
+		return b.config.HelpText
+	}
+	return defaultHelpText
+}
+
+// handleCommand handles bot commands
+func (b *ReminderBot) handleCommand(msg *tgbotapi.Message) {
+	b.logger.Printf("Received command: %s from %d", msg.Command(), msg.From.ID)
+
+	// Bound storage queries made while handling the command
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	switch msg.Command() {
+	case "start":
+		welcome := b.welcomeText()
+
+		if pausedUntil, err := b.repo.GetUserPausedUntilContext(ctx, msg.From.ID); err != nil {
+			b.logger.Printf("Error getting pause state: %v", err)
+		} else if !pausedUntil.IsZero() {
+			welcome += "\n\n" + b.pauseStateText(ctx, msg.From.ID, pausedUntil)
+		}
+
+		// New users have no preferences yet, so ask for their timezone
+		known, err := b.repo.HasUserPreferencesContext(ctx, msg.From.ID)
+		if err != nil {
+			b.logger.Printf("Error checking user preferences: %v", err)
+			known = true
+		}
+
 		reply := tgbotapi.NewMessage(msg.Chat.ID, welcome)
+		if !known && msg.Chat.IsPrivate() {
+			reply.ReplyMarkup = locationKeyboard()
+		}
 		b.bot.Send(reply)
 
+		if !known {
+			b.sendTimezonePrompt(msg.Chat.ID, msg.Chat.IsPrivate())
+		}
+
+	case "pause":
+		b.handlePauseCommand(ctx, msg)
+
+	case "resume":
+		b.handleResumeCommand(ctx, msg)
+
+	case "snooze_default":
+		b.handleSnoozeDefaultCommand(ctx, msg)
+
+	case "daytime":
+		b.handleDayTimeCommand(ctx, msg)
+
+	case "default_hour":
+		b.handleDefaultHourCommand(ctx, msg)
+
+	case "origin":
+		b.handleOriginCommand(ctx, msg)
+
+	case "delivery":
+		b.handleDeliveryCommand(ctx, msg)
+
 	case "timezone":
 		b.handleTimezoneCommand(msg)
 
+	case "todos":
+		b.handleTodosCommand(ctx, msg)
+
+	case "clear_todos":
+		b.handleClearTodosCommand(ctx, msg)
+
+	case "move":
+		b.handleMoveCommand(ctx, msg)
+
+	case "transfer":
+		b.handleTransferCommand(ctx, msg)
+
 	case "list":
-		reminders, err := b.repo.GetUserReminders(msg.From.ID)
+		reminders, err := b.reminders.List(ctx, msg.From.ID)
 		if err != nil {
 			b.logger.Printf("Error getting reminders: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка напоминаний.")
@@ -42,29 +221,31 @@ func (b *ReminderBot) handleCommand(msg *tgbotapi.Message) {
 		}
 
 		if len(reminders) == 0 {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "У вас пока нет активных напоминаний.")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, b.emptyStateText(emptyList))
 			b.bot.Send(reply)
 			return
 		}
 
 		var lines []string
 		for _, r := range reminders {
-			lines = append(lines, fmt.Sprintf("%s – %s", r.ReminderTime.Format("02.01.2006 15:04"), r.Label))
+			lines = append(lines, fmt.Sprintf("%s %s – %s", categoryIcon(r.Category), formatReminderTime(r, "02.01.2006 15:04"), b.listLabel(r.Label)))
 		}
 
 		text := "Ваши активные напоминания:\n" + strings.Join(lines, "\n")
 		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		if keyboard, ok := b.fullTextKeyboard(reminders); ok {
+			reply.ReplyMarkup = keyboard
+		}
 		b.bot.Send(reply)
 
 	case "recurring":
-		b.processListRecurringOperation(msg)
+		b.processListRecurringOperation(llm.Operation{}, msg)
 
-	case "today":
-		now := time.Now()
-		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		end := start.Add(24 * time.Hour)
+	case "next":
+		b.handleNextCommand(ctx, msg)
 
-		reminders, err := b.repo.GetUserRemindersByPeriod(msg.From.ID, start, end)
+	case "today":
+		lines, err := b.todayLines(ctx, msg.From.ID)
 		if err != nil {
 			b.logger.Printf("Error getting today's reminders: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении напоминаний на сегодня.")
@@ -72,27 +253,27 @@ func (b *ReminderBot) handleCommand(msg *tgbotapi.Message) {
 			return
 		}
 
-		if len(reminders) == 0 {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "На сегодня нет напоминаний.")
+		if len(lines) == 0 {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, b.emptyStateText(emptyToday))
 			b.bot.Send(reply)
 			return
 		}
 
-		var lines []string
-		for _, r := range reminders {
-			lines = append(lines, fmt.Sprintf("%s – %s", r.ReminderTime.Format("15:04"), r.Label))
-		}
-
 		text := "Напоминания на сегодня:\n" + strings.Join(lines, "\n")
 		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 		b.bot.Send(reply)
 
+	case "soon":
+		b.handleSoonCommand(ctx, msg)
+
+	case "agenda":
+		b.handleAgendaCommand(ctx, msg)
+
 	case "tomorrow":
-		now := time.Now()
-		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(24 * time.Hour)
-		end := start.Add(24 * time.Hour)
+		start := core.StartOfDay(b.reminders.Now(ctx, msg.From.ID)).AddDate(0, 0, 1)
+		end := start.AddDate(0, 0, 1)
 
-		reminders, err := b.repo.GetUserRemindersByPeriod(msg.From.ID, start, end)
+		reminders, err := b.reminders.ListBetween(ctx, msg.From.ID, start, end)
 		if err != nil {
 			b.logger.Printf("Error getting tomorrow's reminders: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении напоминаний на завтра.")
@@ -101,59 +282,60 @@ func (b *ReminderBot) handleCommand(msg *tgbotapi.Message) {
 		}
 
 		if len(reminders) == 0 {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "На завтра нет напоминаний.")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, b.emptyStateText(emptyTomorrow))
 			b.bot.Send(reply)
 			return
 		}
 
 		var lines []string
 		for _, r := range reminders {
-			lines = append(lines, fmt.Sprintf("%s – %s", r.ReminderTime.Format("15:04"), r.Label))
+			lines = append(lines, fmt.Sprintf("%s %s – %s", categoryIcon(r.Category), formatReminderTime(r, "15:04"), r.Label))
 		}
 
 		text := "Напоминания на завтра:\n" + strings.Join(lines, "\n")
 		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 		b.bot.Send(reply)
 
-	case "help":
-		helpText := `Как пользоваться ботом:
+	case "week":
+		b.handleWeekCommand(ctx, msg)
 
-1. Создать напоминание:
-   Просто напишите, что и когда вам напомнить, например:
-   "Напомни купить молоко завтра в 18:00"
-   "Напомни позвонить маме через 2 часа"
-   "Совещание в понедельник в 10:00"
+	case "month":
+		b.handleMonthCommand(ctx, msg)
 
-2. Создать регулярное напоминание:
-   "Напоминай выпить таблетки каждый день в 10:00"
-   "Напоминай про йогу каждый вторник в 19:00"
-   "Напоминай про оплату счетов каждого 10 числа в 15:00"
+	case "past":
+		b.handlePastCommand(ctx, msg)
 
-3. Создать задачу без напоминания:
-   "Добавь в список: купить цветы в пятницу"
-   "Добавь в список дел: позвонить маме в субботу"
-   "Запиши задачу позвонить в банк каждый понедельник"
+	case "chat_list":
+		b.handleChatListCommand(ctx, msg)
 
-4. Посмотреть напоминания и задачи:
-   • /list - все активные напоминания и задачи
-   • /recurring - все повторяющиеся напоминания и задачи
-   • /today - напоминания и задачи на сегодня
-   • /tomorrow - напоминания и задачи на завтра
-   • "Покажи мои дела на сегодня"
-   • "Что у меня запланировано на эту неделю?"
+	case "chat_settings":
+		b.handleChatSettingsCommand(ctx, msg)
 
-5. Изменить напоминание:
-   "Перенеси напоминание о совещании на 11:00"
-   "Измени встречу с клиентом на завтра"
+	case "template":
+		b.handleTemplateCommand(ctx, msg)
 
-6. Удалить напоминание или задачу:
-   "Удали напоминание о встрече"
-   "Отмени регулярное напоминание про йогу"
-   "Удали задачу купить цветы"
+	case "templates":
+		b.handleTemplatesCommand(ctx, msg)
+
+	case "cancel":
+		b.handleCancelCommand(msg)
 
-Вы также можете отправлять голосовые сообщения!`
+	case "feedback":
+		b.handleFeedbackCommand(ctx, msg)
 
-		reply := tgbotapi.NewMessage(msg.Chat.ID, helpText)
+	case "parse":
+		b.handleParseCommand(ctx, msg)
+
+	case "broadcast", "users", "stats_global", "backup", "reply":
+		if !b.config.IsAdmin(msg.From.ID) {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная команда. Используйте /help для справки.")
+			b.bot.Send(reply)
+			return
+		}
+		b.handleAdminCommand(ctx, msg)
+
+	case "help":
+		reply := tgbotapi.NewMessage(msg.Chat.ID, b.helpText())
 		b.bot.Send(reply)
 
 	default:
@@ -169,10 +351,10 @@ func (b *ReminderBot) handleTimezoneCommand(msg *tgbotapi.Message) {
 
 	if args == "" {
 		// No timezone provided, show current timezone and instructions
-		timezone, err := b.repo.GetUserTimezone(msg.From.ID)
+		timezone, err := b.repo.GetUserTimezoneContext(context.Background(), msg.From.ID)
 		if err != nil {
 			b.logger.Printf("Error getting timezone: %v", err)
-			timezone = "Europe/Moscow"
+			timezone = b.config.DefaultTimezone
 		}
 
 		replyText := fmt.Sprintf(`Твой текущий часовой пояс: %s
@@ -181,6 +363,7 @@ func (b *ReminderBot) handleTimezoneCommand(msg *tgbotapi.Message) {
 /timezone Москва
 /timezone Екатеринбург
 /timezone [твой город]
+/timezone +3 (или UTC+5:30, если знаешь только смещение)
 
 Или просто отправь текстом или голосом команду: "Измени мой часовой пояс на Петербург"`, timezone)
 
@@ -189,6 +372,20 @@ func (b *ReminderBot) handleTimezoneCommand(msg *tgbotapi.Message) {
 		return
 	}
 
+	// Known city names, aliases and IANA names are resolved without the LLM
+	if zone, ok := utils.ResolveTimezone(args); ok {
+		if err := b.repo.SetUserTimezoneContext(context.Background(), msg.From.ID, zone); err != nil {
+			b.logger.Printf("Error setting timezone: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при установке часового пояса.")
+			b.bot.Send(reply)
+			return
+		}
+
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Часовой пояс установлен: %s", zone))
+		b.bot.Send(reply)
+		return
+	}
+
 	// If the input doesn't look like a standard IANA timezone (with a slash),
 	// we'll let the natural language processing handle it
 	if !strings.Contains(args, "/") {
@@ -197,10 +394,11 @@ func (b *ReminderBot) handleTimezoneCommand(msg *tgbotapi.Message) {
 		defer cancel()
 
 		// Use the LLM to determine the timezone
-		llmOutput, err := b.llmClient.ParseMessage(ctx, llmPrompt, "установи часовой пояс "+args, nil)
+		llmOutput, err := b.llmClient.ParseMessage(ctx, b.reminders.Now(ctx, msg.From.ID), llmPrompt, "установи часовой пояс "+args, nil)
 		if err != nil {
 			b.logger.Printf("Error parsing timezone with LLM: %v", err)
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось определить часовой пояс. Попробуйте указать в формате 'Europe/Moscow'.")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось определить часовой пояс. Попробуйте указать в формате 'Europe/Moscow'."+
+				timezoneSuggestionText(args))
 			b.bot.Send(reply)
 			return
 		}
@@ -214,20 +412,192 @@ func (b *ReminderBot) handleTimezoneCommand(msg *tgbotapi.Message) {
 		}
 
 		// If we got here, the LLM didn't return a timezone operation
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось определить часовой пояс для указанного города.")
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось определить часовой пояс для указанного города."+
+			timezoneSuggestionText(args))
+		b.bot.Send(reply)
+		return
+	}
+
+	// Looks like an IANA name, but ResolveTimezone couldn't load it
+	b.logger.Printf("Unknown timezone: %s", args)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат часового пояса. Пожалуйста, используйте формат 'Continent/City', например 'Europe/Moscow'."+
+		timezoneSuggestionText(args))
+	b.bot.Send(reply)
+}
+
+// timezoneSuggestionText suggests known cities close to an unrecognized timezone input
+func timezoneSuggestionText(input string) string {
+	suggestions := utils.SuggestTimezones(input, 3)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return "\n\nВозможно, вы имели в виду: " + strings.Join(suggestions, ", ")
+}
+
+// pauseForever is stored as paused_until for a pause without an end date
+var pauseForever = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// handlePauseCommand pauses all reminders for the user, optionally for N days
+func (b *ReminderBot) handlePauseCommand(ctx context.Context, msg *tgbotapi.Message) {
+	args := strings.TrimSpace(msg.CommandArguments())
+
+	until := pauseForever
+	if args != "" {
+		days, err := strconv.Atoi(args)
+		if err != nil || days <= 0 {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Укажи количество дней, например: /pause 7")
+			b.bot.Send(reply)
+			return
+		}
+		until = time.Now().AddDate(0, 0, days)
+	}
+
+	if err := b.repo.PauseUserContext(ctx, msg.From.ID, until); err != nil {
+		b.logger.Printf("Error pausing user %d: %v", msg.From.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось поставить напоминания на паузу.")
 		b.bot.Send(reply)
 		return
 	}
 
-	// Try to set the timezone directly (for standard IANA format input)
-	err := b.repo.SetUserTimezone(msg.From.ID, args)
+	b.logger.Printf("Paused reminders for user %d until %s", msg.From.ID, until.Format("2006-01-02 15:04:05"))
+
+	text := b.pauseStateText(ctx, msg.From.ID, until) + "\nЧтобы возобновить, отправь /resume"
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// handleResumeCommand lifts the user's pause
+func (b *ReminderBot) handleResumeCommand(ctx context.Context, msg *tgbotapi.Message) {
+	resumed, err := b.repo.ResumeUserContext(ctx, msg.From.ID)
 	if err != nil {
-		b.logger.Printf("Error setting timezone: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат часового пояса. Пожалуйста, используйте формат 'Continent/City', например 'Europe/Moscow'.")
+		b.logger.Printf("Error resuming user %d: %v", msg.From.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось снять паузу.")
 		b.bot.Send(reply)
 		return
 	}
 
-	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Часовой пояс установлен: %s", args))
+	if !resumed {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Напоминания и так активны.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Printf("Resumed reminders for user %d", msg.From.ID)
+
+	// Timed reminders missed during the pause were never marked as notified,
+	// so they are delivered by the next check.
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "▶️ Напоминания снова активны. Пропущенные за время паузы напоминания придут в ближайшее время.")
+	b.bot.Send(reply)
+}
+
+// handleClearTodosCommand marks all of the user's one-time todos as done
+func (b *ReminderBot) handleClearTodosCommand(ctx context.Context, msg *tgbotapi.Message) {
+	count, err := b.reminders.CompleteTodos(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error completing todos for user %d: %v", msg.From.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось отметить задачи выполненными.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if count == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Активных задач нет.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Printf("Completed %d todos for user %d", count, msg.From.ID)
+
+	text := fmt.Sprintf("✅ %s %d %s.",
+		utils.PluralRussian(int(count), "Выполнена", "Выполнены", "Выполнено"),
+		count, utils.PluralRussian(int(count), "задача", "задачи", "задач"))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// pauseStateText describes the user's pause in their timezone
+func (b *ReminderBot) pauseStateText(ctx context.Context, userID int64, pausedUntil time.Time) string {
+	if !pausedUntil.Before(pauseForever) {
+		return "⏸ Напоминания на паузе."
+	}
+	return fmt.Sprintf("⏸ Напоминания на паузе до %s.",
+		pausedUntil.In(b.reminders.Location(ctx, userID)).Format("02.01.2006 15:04"))
+}
+
+// handleWeekCommand shows reminders for the next seven days grouped by day
+func (b *ReminderBot) handleWeekCommand(ctx context.Context, msg *tgbotapi.Message) {
+	start := core.StartOfDay(b.reminders.Now(ctx, msg.From.ID))
+
+	days, err := b.agendaBetween(ctx, msg.From.ID, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		b.logger.Printf("Error getting week's reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении напоминаний на неделю.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(days) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "На ближайшую неделю нет напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	var sections []string
+	for _, day := range days {
+		lines := formatDayLines(day.Reminders, day.Events)
+		sections = append(sections, day.title()+":\n"+strings.Join(lines, "\n"))
+	}
+
+	text := "Напоминания на неделю:\n\n" + strings.Join(sections, "\n\n")
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// handleMonthCommand shows an overview of the current month grouped by day.
+// Busy days are collapsed into a counter with a button to expand them.
+func (b *ReminderBot) handleMonthCommand(ctx context.Context, msg *tgbotapi.Message) {
+	today := core.StartOfDay(b.reminders.Now(ctx, msg.From.ID))
+	start := today.AddDate(0, 0, 1-today.Day())
+	end := start.AddDate(0, 1, 0)
+
+	days, err := b.agendaBetween(ctx, msg.From.ID, start, end)
+	if err != nil {
+		b.logger.Printf("Error getting month's reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении напоминаний на месяц.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(days) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "В этом месяце нет напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	var sections []string
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for _, day := range days {
+		count := day.count()
+		header := fmt.Sprintf("%s — %d %s", day.title(), count,
+			utils.PluralRussian(count, "напоминание", "напоминания", "напоминаний"))
+
+		if count > monthDayCollapseLimit {
+			sections = append(sections, header)
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("Показать "+day.Date.Format("02.01"), "day_"+day.Date.Format("20060102")),
+			))
+			continue
+		}
+
+		lines := formatDayLines(day.Reminders, day.Events)
+		sections = append(sections, header+":\n"+strings.Join(lines, "\n"))
+	}
+
+	text := fmt.Sprintf("Напоминания на месяц (%s – %s):\n\n", start.Format("02.01"), end.AddDate(0, 0, -1).Format("02.01")) +
+		strings.Join(sections, "\n\n")
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	if len(buttons) > 0 {
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	}
 	b.bot.Send(reply)
 }
@@ -1,21 +1,27 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
+	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/api"
 	"reminders21/config"
+	"reminders21/core"
 	"reminders21/llm"
+	"reminders21/logging"
 	"reminders21/speech"
 	"reminders21/storage"
 )
 
 // NewReminderBot creates a new ReminderBot
-func NewReminderBot(cfg *config.Config, logger *log.Logger) (*ReminderBot, error) {
+func NewReminderBot(cfg *config.Config, logger logging.Logger) (*ReminderBot, error) {
 	// Initialize bot API
 	bot, err := tgbotapi.NewBotAPI(cfg.TelegramToken)
 	if err != nil {
@@ -25,25 +31,61 @@ func NewReminderBot(cfg *config.Config, logger *log.Logger) (*ReminderBot, error
 	bot.Debug = cfg.Debug
 
 	// Initialize database
-	repo, err := storage.NewReminderRepository(cfg.DatabasePath, logger)
+	repo, err := storage.NewReminderRepository(cfg.DatabasePath, cfg.DefaultTimezone, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize repository: %w", err)
 	}
+	repo.SetLimits(cfg.MaxRemindersPerUser, cfg.MaxRecurringPerUser)
 
 	// Initialize OpenAI client
-	llmClient := llm.NewOpenAIClient(cfg.OpenAIAPIKey, cfg.APITimeout)
+	llmClient := llm.NewOpenAIClient(cfg.OpenAIAPIKey, cfg.OpenAIBaseURL, cfg.APITimeout)
 
 	// Initialize transcriber
-	transcriber := speech.NewTranscriber(cfg.OpenAIAPIKey, cfg.APITimeout)
+	var transcriber speech.Transcriber = speech.NewOpenAITranscriber(cfg.OpenAIAPIKey, cfg.APITimeout)
+	if cfg.TranscriptionProvider == config.TranscriptionWhisperCpp {
+		transcriber = speech.NewWhisperCppTranscriber(cfg.WhisperCppURL, cfg.WhisperCppLanguage)
+	}
+
+	// Video messages need ffmpeg to extract the audio track
+	ffmpegPath, err := exec.LookPath(cfg.FFmpegPath)
+	if err != nil {
+		logger.Printf("Warning: ffmpeg not found (%s), video messages will not be processed: %v", cfg.FFmpegPath, err)
+		ffmpegPath = ""
+	}
+
+	reminders := core.NewService(repo, logger)
+
+	// The HTTP API shares the reminder engine, so API-created reminders are delivered as usual
+	var apiServer *api.Server
+	if cfg.APIListenAddr != "" {
+		apiServer = api.NewServer(cfg.APIListenAddr, cfg.APIToken, reminders, logger)
+	}
+
+	// The webhook server is created up front so Stop can shut it down safely
+	var webhookServer *http.Server
+	if cfg.WebhookURL != "" {
+		webhookServer = &http.Server{
+			Addr:              ":" + cfg.WebhookPort,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
 
 	return &ReminderBot{
-		config:      cfg,
-		bot:         bot,
-		repo:        repo,
-		llmClient:   llmClient,
-		transcriber: transcriber,
-		logger:      logger,
-		stopChan:    make(chan struct{}),
+		config:         cfg,
+		bot:            bot,
+		repo:           repo,
+		reminders:      reminders,
+		llmClient:      llmClient,
+		transcriber:    transcriber,
+		logger:         logger,
+		stopChan:       make(chan struct{}),
+		pending:        newPendingOperations(),
+		dialogs:        newDialogState(dialogTTL),
+		transcriptions: newTranscriptions(),
+		ffmpegPath:     ffmpegPath,
+		apiServer:      apiServer,
+
+		webhookServer: webhookServer,
 	}, nil
 }
 
@@ -61,24 +103,45 @@ func (b *ReminderBot) Start() error {
 	// Start reminder checker
 	go b.checkReminders()
 
+	// Start HTTP API if enabled
+	if b.apiServer != nil {
+		go func() {
+			if err := b.apiServer.Start(); err != nil {
+				b.logger.Printf("API server error: %v", err)
+			}
+		}()
+	}
+
 	// Start recurring reminder checker
 	go b.startRecurringChecker()
 
+	// Start scheduled backups if enabled
+	if b.config.BackupDir != "" && b.config.BackupInterval > 0 {
+		go b.scheduleBackups()
+	}
+
 	// Start update listener
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := b.bot.GetUpdatesChan(u)
+	var updates tgbotapi.UpdatesChannel
+	if b.config.WebhookURL != "" {
+		updates, err = b.startWebhook()
+		if err != nil {
+			return err
+		}
+	} else {
+		updates = b.startPolling()
+	}
 
-	for update := range updates {
+	for {
 		select {
 		case <-b.stopChan:
 			return nil
-		default:
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
 			go b.processUpdate(update)
 		}
 	}
-
-	return nil
 }
 
 // registerBotCommands registers the available commands for the bot
@@ -87,9 +150,31 @@ func (b *ReminderBot) registerBotCommands() error {
 		{Command: "start", Description: "Начать работу с ботом"},
 		{Command: "list", Description: "Показать все активные напоминания"},
 		{Command: "recurring", Description: "Показать регулярные напоминания"},
+		{Command: "next", Description: "Ближайшие даты регулярного напоминания"},
 		{Command: "today", Description: "Показать напоминания на сегодня"},
+		{Command: "soon", Description: "Показать напоминания на ближайшие часы"},
 		{Command: "tomorrow", Description: "Показать напоминания на завтра"},
+		{Command: "week", Description: "Показать напоминания на неделю"},
+		{Command: "month", Description: "Обзор напоминаний на месяц"},
+		{Command: "past", Description: "Показать сработавшие напоминания"},
+		{Command: "chat_list", Description: "Напоминания всех участников группы"},
+		{Command: "chat_settings", Description: "Настройки группы"},
+		{Command: "agenda", Description: "Присылать план на день по утрам"},
 		{Command: "timezone", Description: "Установить часовой пояс"},
+		{Command: "pause", Description: "Приостановить все напоминания"},
+		{Command: "resume", Description: "Возобновить напоминания"},
+		{Command: "snooze_default", Description: "Настроить, на сколько откладывать напоминания"},
+		{Command: "daytime", Description: "Настроить, во сколько «утром», «днём» и «вечером»"},
+		{Command: "default_hour", Description: "Настроить, во сколько напоминать, если указана только дата"},
+		{Command: "origin", Description: "Показывать, когда и как создано напоминание"},
+		{Command: "delivery", Description: "Настроить, как начинаются напоминания"},
+		{Command: "todos", Description: "Список задач"},
+		{Command: "clear_todos", Description: "Отметить все задачи выполненными"},
+		{Command: "move", Description: "Перенести напоминание в другой чат"},
+		{Command: "transfer", Description: "Передать все напоминания другому пользователю"},
+		{Command: "templates", Description: "Шаблоны напоминаний"},
+		{Command: "cancel", Description: "Отменить обработку голосового сообщения"},
+		{Command: "feedback", Description: "Сообщить о проблеме или предложить идею"},
 		{Command: "help", Description: "Показать справку по использованию бота"},
 	}
 
@@ -101,18 +186,53 @@ func (b *ReminderBot) registerBotCommands() error {
 // Stop stops the bot
 func (b *ReminderBot) Stop() {
 	close(b.stopChan)
+
+	if b.webhookServer != nil {
+		b.stopWebhook()
+	} else {
+		b.bot.StopReceivingUpdates()
+	}
+
+	if b.apiServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := b.apiServer.Shutdown(ctx); err != nil {
+			b.logger.Printf("Error shutting down API server: %v", err)
+		}
+	}
+
+	b.llmClient.Close()
+	b.transcriber.Close()
+
 	b.logger.Println("Bot stopped")
 }
 
 // processUpdate processes a single update
 func (b *ReminderBot) processUpdate(update tgbotapi.Update) {
 	if update.Message != nil {
+		b.rememberChatMember(update.Message)
+		if update.Message.From != nil {
+			b.updateLastActive(update.Message.From.ID)
+		}
+
+		// A new message stops the user's voice or video message still being processed
+		if update.Message.Command() != "cancel" {
+			b.cancelTranscription(update.Message)
+		}
+
 		if update.Message.IsCommand() {
 			b.handleCommand(update.Message)
 		} else if update.Message.Voice != nil {
 			b.handleVoiceMessage(update.Message)
+		} else if update.Message.Audio != nil ||
+			(update.Message.Document != nil && isAudioDocument(update.Message.Document)) {
+			b.handleAudioMessage(update.Message)
 		} else if update.Message.Video != nil {
 			b.handleVideoMessage(update.Message)
+		} else if len(update.Message.Photo) > 0 {
+			b.handlePhotoMessage(update.Message)
+		} else if update.Message.Location != nil {
+			b.handleLocationMessage(update.Message)
 		} else {
 			b.handleTextMessage(update.Message)
 		}
@@ -129,20 +249,44 @@ func (b *ReminderBot) checkReminders() {
 	ticker := time.NewTicker(b.config.ReminderCheckInterval)
 	defer ticker.Stop()
 
+	// Send messages left in the outbox by a restart mid-batch
+	b.flushOutbox()
+
 	for {
 		select {
 		case <-b.stopChan:
 			return
 		case <-ticker.C:
 			b.processDueReminders()
+			b.flushOutbox()
+			b.processUnacknowledgedReminders()
 		}
 	}
 }
 
-// processDueReminders processes due reminders
+// photoCaptionLength is Telegram's limit for photo captions
+const photoCaptionLength = 1024
+
+// reminderParseMode is the parse mode of delivered reminders. Labels and notes are user
+// input, so they go through escapeText, which keeps them literal if a parse mode is set here.
+const reminderParseMode = ""
+
+// reminderText returns the notification text for a reminder, including its note if any
+func reminderText(r storage.ReminderItem) string {
+	label := escapeText(reminderParseMode, r.Label)
+	if r.Note == "" {
+		return label
+	}
+	return label + "\n\n📎 " + escapeText(reminderParseMode, r.Note)
+}
+
+// processDueReminders queues due reminders for sending.
+// At most DueBatchSize reminders are queued (and sent by flushOutbox) per tick,
+// oldest first, so a large backlog (e.g. after downtime) drains over several ticks
+// instead of hitting Telegram rate limits all at once.
 func (b *ReminderBot) processDueReminders() {
-	now := time.Now()
-	reminders, err := b.repo.GetDueReminders(now)
+	ctx := context.Background()
+	reminders, err := b.reminders.Due(ctx, time.Now(), b.config.DueBatchSize)
 	if err != nil {
 		b.logger.Printf("Error getting due reminders: %v", err)
 		return
@@ -152,28 +296,29 @@ func (b *ReminderBot) processDueReminders() {
 		return
 	}
 
-	var reminderIDs []int64
+	if b.config.DueBatchSize > 0 && len(reminders) == b.config.DueBatchSize {
+		b.logger.Printf("Due reminders batch is full (%d), the rest will be sent on the next tick", len(reminders))
+	}
 
+	var messages []storage.OutboxMessage
 	for _, r := range reminders {
-		msg := tgbotapi.NewMessage(r.ChatID, r.Label)
-		if _, err := b.bot.Send(msg); err != nil {
-			b.logger.Printf("Error sending reminder: %v", err)
+		m, err := b.outboxMessage(ctx, r)
+		if err != nil {
+			b.logger.Printf("Error building reminder %d: %v", r.ID, err)
 			continue
 		}
-
-		b.logger.Printf("Sent reminder: ID=%d, chat=%d, label=%s", r.ID, r.ChatID, r.Label)
-		reminderIDs = append(reminderIDs, r.ID)
+		messages = append(messages, m)
 	}
 
-	// Mark reminders as notified in a single transaction
-	if err := b.repo.MarkMultipleAsNotified(reminderIDs); err != nil {
-		b.logger.Printf("Error marking reminders as notified: %v", err)
+	// Queued reminders are marked as notified; flushOutbox delivers them
+	if _, err := b.repo.EnqueueOutboxContext(ctx, messages); err != nil {
+		b.logger.Printf("Error queueing reminders: %v", err)
 	}
 }
 
 // getUserRemindersAsMap gets user reminders as map for LLM
-func (b *ReminderBot) getUserRemindersAsMap(userID int64) ([]map[string]string, error) {
-	reminders, err := b.repo.GetUserReminders(userID)
+func (b *ReminderBot) getUserRemindersAsMap(ctx context.Context, userID int64) ([]map[string]string, error) {
+	reminders, err := b.reminders.List(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -185,6 +330,9 @@ func (b *ReminderBot) getUserRemindersAsMap(userID int64) ([]map[string]string,
 			"datetime":    r.ReminderTime.Format("2006-01-02 15:04:05"),
 			"label":       r.Label,
 		}
+		if !r.EndTime.IsZero() {
+			reminder["end_datetime"] = r.EndTime.Format("2006-01-02 15:04:05")
+		}
 		result = append(result, reminder)
 	}
 
@@ -200,7 +348,42 @@ func (b *ReminderBot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	callback_resp := tgbotapi.NewCallback(query.ID, "")
 	b.bot.Request(callback_resp)
 
-	if strings.HasPrefix(callback, "delete_rec_") {
+	if callback == ackCallback {
+		b.handleAckCallback(query)
+	} else if strings.HasPrefix(callback, "pick_") {
+		b.handlePickCallback(query)
+	} else if strings.HasPrefix(callback, "picktime_") {
+		b.handlePickTimeCallback(query)
+	} else if strings.HasPrefix(callback, "tz_") {
+		b.handleTimezoneCallback(query)
+	} else if strings.HasPrefix(callback, "snooze_") {
+		b.handleSnoozeCallback(query)
+	} else if strings.HasPrefix(callback, "skip_rec_") {
+		b.handleSkipRecurringCallback(query)
+	} else if strings.HasPrefix(callback, "toggle_rec_") {
+		b.handleToggleRecurringCallback(query)
+	} else if strings.HasPrefix(callback, "edit_rec_") {
+		b.handleEditRecurringCallback(query)
+	} else if strings.HasPrefix(callback, "ask_del_rec_") {
+		b.handleAskDeleteRecurringCallback(query)
+	} else if strings.HasPrefix(callback, "day_") {
+		b.handleDayCallback(query)
+	} else if strings.HasPrefix(callback, "series_delete_") {
+		b.handleSeriesDeleteCallback(query)
+	} else if strings.HasPrefix(callback, "todo_done_") {
+		b.handleTodoDoneCallback(query)
+	} else if strings.HasPrefix(callback, "move_") {
+		b.handleMoveCallback(query)
+	} else if strings.HasPrefix(callback, "transfer_") {
+		b.handleTransferCallback(query)
+	} else if strings.HasPrefix(callback, "show_full_") {
+		b.handleShowFullCallback(query)
+	} else if callback == "cancel_delete" {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, "Удаление отменено.")
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error editing message: %v", err)
+		}
+	} else if strings.HasPrefix(callback, "delete_rec_") {
 		// Extract recurring reminder ID from callback data
 		reminderIDStr := strings.TrimPrefix(callback, "delete_rec_")
 		reminderID, err := strconv.ParseInt(reminderIDStr, 10, 64)
@@ -210,7 +393,7 @@ func (b *ReminderBot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		}
 
 		// Delete recurring reminder
-		deleted, err := b.repo.DeleteRecurringReminder(reminderID, query.From.ID)
+		deleted, err := b.reminders.DeleteRecurring(context.Background(), reminderID, query.From.ID)
 		if err != nil {
 			b.logger.Printf("Error deleting recurring reminder: %v", err)
 			return
@@ -243,8 +426,16 @@ func (b *ReminderBot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 			return
 		}
 
-		// Delete the reminder
-		deleted, err := b.repo.DeleteReminder(reminderID, query.From.ID)
+		// Delete the reminder; the chat may let any member delete its reminders
+		deleted, err := b.reminders.Delete(context.Background(), reminderID, query.From.ID)
+		if errors.Is(err, storage.ErrNotOwned) {
+			deleted, err = b.deleteChatReminder(context.Background(), query.Message.Chat, reminderID)
+		}
+		if text, ok := reminderErrorText(err); ok {
+			notification := tgbotapi.NewMessage(query.Message.Chat.ID, text)
+			b.bot.Send(notification)
+			return
+		}
 		if err != nil {
 			b.logger.Printf("Error deleting reminder: %v", err)
 			return
@@ -266,7 +457,6 @@ func (b *ReminderBot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 			b.bot.Send(notification)
 		}
 
-		b.logger.Printf("Deleted reminder via inline button: ID=%d (user %d)",
-			reminderID, query.From.ID)
+		b.logger.Info("reminder_deleted", "reminder_id", reminderID, "user_id", query.From.ID, "via", "button")
 	}
 }
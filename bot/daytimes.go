@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/storage"
+)
+
+// Times of day used for "утром", "днём" and "вечером" until the user sets their own with /daytime
+const (
+	defaultMorningTime   = "09:00"
+	defaultAfternoonTime = "13:00"
+	defaultEveningTime   = "19:00"
+)
+
+// defaultReminderHour is the hour of reminders given only a date until the user sets
+// their own with /default_hour
+const defaultReminderHour = 10
+
+// midnightPattern matches an explicit request for a midnight reminder
+var midnightPattern = regexp.MustCompile(`(?i)полноч|(^|[^\d])0?0[:.]00|24[:.]00|12 ночи`)
+
+// userDayTimes returns the times of day the user means by "утром", "днём" and "вечером"
+func (b *ReminderBot) userDayTimes(ctx context.Context, userID int64) storage.DayTimes {
+	times, err := b.repo.GetUserDayTimesContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting times of day for user %d: %v", userID, err)
+	}
+	if times.Morning == "" {
+		times.Morning = defaultMorningTime
+	}
+	if times.Afternoon == "" {
+		times.Afternoon = defaultAfternoonTime
+	}
+	if times.Evening == "" {
+		times.Evening = defaultEveningTime
+	}
+	return times
+}
+
+// dayTimesPrompt is appended to the LLM prompt so the model uses the user's times of day
+func dayTimesPrompt(times storage.DayTimes) string {
+	return fmt.Sprintf("\nЕсли указано только время суток без точного времени, используй: утром – %s, днём – %s, вечером – %s.\n",
+		times.Morning, times.Afternoon, times.Evening)
+}
+
+// handleDayTimeCommand shows or sets the times of day the user means by "утром", "днём"
+// and "вечером", e.g. "/daytime утром 08:00"
+func (b *ReminderBot) handleDayTimeCommand(ctx context.Context, msg *tgbotapi.Message) {
+	times := b.userDayTimes(ctx, msg.From.ID)
+	args := strings.Fields(strings.ToLower(msg.CommandArguments()))
+
+	if len(args) == 0 {
+		text := fmt.Sprintf(`Если не указать точное время, напомню:
+• утром – в %s
+• днём – в %s
+• вечером – в %s
+
+Чтобы изменить, укажите время суток и время, например:
+/daytime утром 08:00`, times.Morning, times.Afternoon, times.Evening)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	var at string
+	var err error
+	if len(args) == 2 {
+		at, err = storage.NormalizeClockTime(args[1])
+	}
+	if len(args) != 2 || err != nil || at == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Укажите время суток и время в формате ЧЧ:ММ, например: /daytime вечером 20:00")
+		b.bot.Send(reply)
+		return
+	}
+
+	var name string
+	switch args[0] {
+	case "утро", "утром":
+		times.Morning, name = at, "утром"
+	case "день", "днём", "днем":
+		times.Afternoon, name = at, "днём"
+	case "вечер", "вечером":
+		times.Evening, name = at, "вечером"
+	default:
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Можно настроить «утром», «днём» или «вечером», например: /daytime утром 08:00")
+		b.bot.Send(reply)
+		return
+	}
+
+	if err := b.repo.SetUserDayTimesContext(ctx, msg.From.ID, times); err != nil {
+		b.logger.Printf("Error setting times of day: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Готово! Теперь «%s» – это %s.", name, at))
+	b.bot.Send(reply)
+}
+
+// userDefaultReminderHour returns the hour of the user's reminders given only a date
+func (b *ReminderBot) userDefaultReminderHour(ctx context.Context, userID int64) int {
+	hour, ok, err := b.repo.GetUserDefaultReminderHourContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting default reminder hour for user %d: %v", userID, err)
+	}
+	if !ok {
+		return defaultReminderHour
+	}
+	return hour
+}
+
+// applyDefaultReminderHour moves a reminder the user gave only a date for from midnight
+// to their default hour. Reminders the user explicitly set for midnight are kept.
+func (b *ReminderBot) applyDefaultReminderHour(ctx context.Context, msg *tgbotapi.Message, at time.Time) time.Time {
+	if at.Hour() != 0 || at.Minute() != 0 || midnightPattern.MatchString(msg.Text+" "+msg.Caption) {
+		return at
+	}
+	return time.Date(at.Year(), at.Month(), at.Day(), b.userDefaultReminderHour(ctx, msg.From.ID), 0, 0, 0, at.Location())
+}
+
+// handleDefaultHourCommand shows or sets the hour of reminders given only a date
+func (b *ReminderBot) handleDefaultHourCommand(ctx context.Context, msg *tgbotapi.Message) {
+	args := strings.TrimSpace(msg.CommandArguments())
+	if args == "" {
+		text := fmt.Sprintf(`Если указать только дату, напомню в %02d:00.
+
+Чтобы изменить, укажите час от 0 до 23, например:
+/default_hour 10`, b.userDefaultReminderHour(ctx, msg.From.ID))
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	hour, err := strconv.Atoi(strings.TrimSuffix(args, ":00"))
+	if err != nil || hour < 0 || hour > 23 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Укажите час от 0 до 23, например: /default_hour 10")
+		b.bot.Send(reply)
+		return
+	}
+
+	if err := b.repo.SetUserDefaultReminderHourContext(ctx, msg.From.ID, hour); err != nil {
+		b.logger.Printf("Error setting default reminder hour: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Готово! Напоминания без времени теперь придут в %02d:00.", hour))
+	b.bot.Send(reply)
+}
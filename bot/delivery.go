@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultDeliveryPrefix is the prefix set with "/delivery on"
+const defaultDeliveryPrefix = "Напоминаю:"
+
+// maxDeliveryPrefixLength is the longest custom prefix in characters
+const maxDeliveryPrefixLength = 32
+
+// deliveryPrefix returns the text to put before the label of the user's delivered
+// reminders, followed by a space, or "" if they want the label alone
+func (b *ReminderBot) deliveryPrefix(ctx context.Context, userID int64) string {
+	prefix, err := b.repo.GetUserDeliveryPrefixContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting delivery prefix of user %d: %v", userID, err)
+	}
+	if prefix == "" {
+		return ""
+	}
+	return escapeText(reminderParseMode, prefix) + " "
+}
+
+// handleDeliveryCommand sets how delivered reminders start: "/delivery on" for
+// "Напоминаю: <label>", "/delivery 🔔" for a custom prefix, "/delivery off" for the label alone
+func (b *ReminderBot) handleDeliveryCommand(ctx context.Context, msg *tgbotapi.Message) {
+	arg := strings.TrimSpace(msg.CommandArguments())
+	var prefix string
+	switch strings.ToLower(arg) {
+	case "":
+		current, err := b.repo.GetUserDeliveryPrefixContext(ctx, msg.From.ID)
+		if err != nil {
+			b.logger.Printf("Error getting delivery prefix of user %d: %v", msg.From.ID, err)
+		}
+		text := "Сейчас напоминания приходят без вступления, только текст.\n\n" +
+			"Начинать их с «Напоминаю:»: /delivery on\nСо своего текста: /delivery 🔔"
+		if current != "" {
+			text = fmt.Sprintf("Сейчас напоминания начинаются с «%s».\n\nТолько текст напоминания: /delivery off", current)
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	case "on", "вкл":
+		prefix = defaultDeliveryPrefix
+	case "off", "выкл":
+		prefix = ""
+	default:
+		if utf8.RuneCountInString(arg) > maxDeliveryPrefixLength {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Вступление должно быть не длиннее %d символов.", maxDeliveryPrefixLength))
+			b.bot.Send(reply)
+			return
+		}
+		prefix = arg
+	}
+
+	if err := b.repo.SetUserDeliveryPrefixContext(ctx, msg.From.ID, prefix); err != nil {
+		b.logger.Printf("Error setting delivery prefix: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("Готово! Напоминания будут приходить так: «%s купить молоко».", prefix)
+	if prefix == "" {
+		text = "Готово! Напоминания будут приходить без вступления, только текст."
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
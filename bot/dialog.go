@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// dialogTTL is how long the bot waits for the user's answer to a question
+const dialogTTL = 10 * time.Minute
+
+// dialogKind is what the bot asked the user for
+type dialogKind int
+
+const (
+	// dialogRecurringTime waits for the new time of a recurring reminder
+	dialogRecurringTime dialogKind = iota + 1
+)
+
+// dialog is a question the bot asked and the reminder the answer applies to
+type dialog struct {
+	kind       dialogKind
+	reminderID int64
+	createdAt  time.Time
+}
+
+// dialogKey identifies a user's dialog in a chat
+type dialogKey struct {
+	chatID, userID int64
+}
+
+// dialogState stores the questions waiting for a user's next text message, at most one
+// per user and chat, so buttons and commands can ask for free-form input without going
+// through the LLM. Questions expire after ttl. It is safe for concurrent use.
+type dialogState struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	active map[dialogKey]dialog
+}
+
+// newDialogState creates an empty store whose questions expire after ttl
+func newDialogState(ttl time.Duration) *dialogState {
+	return &dialogState{ttl: ttl, active: make(map[dialogKey]dialog)}
+}
+
+// set asks the user for input, replacing any earlier question in the chat
+func (d *dialogState) set(chatID, userID int64, active dialog) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.expireLocked(now)
+
+	active.createdAt = now
+	d.active[dialogKey{chatID, userID}] = active
+}
+
+// get returns the user's unexpired dialog in the chat, if any, leaving it in place;
+// take is the consuming variant
+func (d *dialogState) get(chatID, userID int64) (dialog, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := dialogKey{chatID, userID}
+	active, ok := d.active[key]
+	if !ok {
+		return dialog{}, false
+	}
+	if time.Since(active.createdAt) > d.ttl {
+		delete(d.active, key)
+		return dialog{}, false
+	}
+
+	return active, true
+}
+
+// clear removes the user's dialog in the chat and reports whether an unexpired one was waiting
+func (d *dialogState) clear(chatID, userID int64) bool {
+	_, ok := d.take(chatID, userID)
+	return ok
+}
+
+// take removes and returns the user's unexpired dialog in the chat, if any
+func (d *dialogState) take(chatID, userID int64) (dialog, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := dialogKey{chatID, userID}
+	active, ok := d.active[key]
+	if !ok {
+		return dialog{}, false
+	}
+
+	delete(d.active, key)
+	return active, time.Since(active.createdAt) <= d.ttl
+}
+
+// expire drops the questions nobody answered in time
+func (d *dialogState) expire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireLocked(time.Now())
+}
+
+// expireLocked drops expired questions; d.mu must be held
+func (d *dialogState) expireLocked(now time.Time) {
+	for key, active := range d.active {
+		if now.Sub(active.createdAt) > d.ttl {
+			delete(d.active, key)
+		}
+	}
+}
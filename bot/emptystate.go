@@ -0,0 +1,50 @@
+package bot
+
+import "strings"
+
+// emptyState identifies the reply to an empty reminder list
+type emptyState string
+
+const (
+	emptyList     emptyState = "list"
+	emptyToday    emptyState = "today"
+	emptyTomorrow emptyState = "tomorrow"
+)
+
+// emptyStateTexts are the replies to empty lists, kept together so they can be localized
+var emptyStateTexts = map[emptyState]string{
+	emptyList:     "У вас пока нет активных напоминаний.",
+	emptyToday:    "На сегодня нет напоминаний.",
+	emptyTomorrow: "На завтра нет напоминаний.",
+}
+
+// emptyStateExamples are phrasings suggested after an empty list
+var emptyStateExamples = map[emptyState][]string{
+	emptyList: {
+		"Напомни купить молоко завтра в 18:00",
+		"Напоминай про йогу каждый вторник в 19:00",
+	},
+	emptyToday: {
+		"Напомни позвонить маме сегодня в 19:00",
+		"Через 2 часа напомни выключить духовку",
+	},
+	emptyTomorrow: {
+		"Завтра в 10:00 совещание",
+		"Добавь в список: купить цветы завтра",
+	},
+}
+
+// emptyStateText returns the reply to an empty list, with example phrasings
+// unless they are turned off in the config
+func (b *ReminderBot) emptyStateText(state emptyState) string {
+	text := emptyStateTexts[state]
+	if !b.config.EmptyStateExamples || len(emptyStateExamples[state]) == 0 {
+		return text
+	}
+
+	var examples []string
+	for _, example := range emptyStateExamples[state] {
+		examples = append(examples, "• «"+example+"»")
+	}
+	return text + "\n\nЧтобы добавить, просто напишите или надиктуйте, например:\n" + strings.Join(examples, "\n")
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/storage"
+	"reminders21/utils"
+)
+
+// handleFeedbackCommand stores the user's feedback and forwards it to the admins
+func (b *ReminderBot) handleFeedbackCommand(ctx context.Context, msg *tgbotapi.Message) {
+	text := strings.TrimSpace(utils.SanitizeText(msg.CommandArguments()))
+	if text == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Напишите отзыв или опишите проблему после команды, например:\n/feedback Не пришло напоминание в 10:00")
+		b.bot.Send(reply)
+		return
+	}
+
+	_, err := b.repo.AddFeedbackContext(ctx, storage.Feedback{
+		UserID:    msg.From.ID,
+		ChatID:    msg.Chat.ID,
+		Username:  msg.From.UserName,
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		b.logger.Printf("Error saving feedback from %d: %v", msg.From.ID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось отправить отзыв. Попробуйте позже.")
+		b.bot.Send(reply)
+		return
+	}
+
+	from := msg.From.FirstName
+	if msg.From.UserName != "" {
+		from += " (@" + msg.From.UserName + ")"
+	}
+	forward := fmt.Sprintf("📝 Отзыв от %s, ID %d:\n%s\n\nОтветить: /reply %d <текст>", from, msg.From.ID, text, msg.From.ID)
+
+	// In private chats the chat ID equals the user ID
+	for _, adminID := range b.config.AdminUserIDs {
+		if _, err := b.bot.Send(tgbotapi.NewMessage(adminID, forward)); err != nil {
+			b.logger.Printf("Error forwarding feedback to admin %d: %v", adminID, err)
+		}
+	}
+
+	b.logger.Printf("Received feedback from %d", msg.From.ID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Спасибо! Отзыв отправлен.")
+	b.bot.Send(reply)
+}
+
+// handleReplyCommand sends an admin's answer to a user's feedback, e.g. "/reply 123456789 Исправили, спасибо!"
+func (b *ReminderBot) handleReplyCommand(ctx context.Context, msg *tgbotapi.Message) {
+	idStr, text, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	userID, err := strconv.ParseInt(idStr, 10, 64)
+	text = strings.TrimSpace(text)
+	if err != nil || text == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /reply <user_id> <текст ответа>")
+		b.bot.Send(reply)
+		return
+	}
+
+	if _, err := b.bot.Send(tgbotapi.NewMessage(userID, "💬 Ответ на ваш отзыв:\n"+text)); err != nil {
+		b.logger.Printf("Error sending feedback reply to %d: %v", userID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Не удалось отправить ответ: %v", err))
+		b.bot.Send(reply)
+		return
+	}
+
+	if _, err := b.repo.MarkFeedbackRepliedContext(ctx, userID, time.Now()); err != nil {
+		b.logger.Printf("Error marking feedback of %d as replied: %v", userID, err)
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Ответ отправлен.")
+	b.bot.Send(reply)
+}
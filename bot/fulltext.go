@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"reminders21/storage"
+)
+
+const (
+	// maxFullTextButtons limits the "show full text" buttons under a list
+	maxFullTextButtons = 20
+	// fullTextButtonLength is how much of the label is shown on a "show full text" button
+	fullTextButtonLength = 30
+)
+
+// shortenLabel keeps a label within the configured maximum length, e.g. when a long
+// transcription became the whole label. The full label is kept at the top of the note.
+func (b *ReminderBot) shortenLabel(label, note string) (string, string) {
+	if b.config.MaxLabelLength <= 0 || len([]rune(label)) <= b.config.MaxLabelLength {
+		return label, note
+	}
+
+	full := label
+	if note != "" {
+		full += "\n\n" + note
+	}
+	return truncateText(label, b.config.MaxLabelLength), truncateText(full, maxNoteLength)
+}
+
+// listLabel truncates a label to the configured list length
+func (b *ReminderBot) listLabel(label string) string {
+	if b.config.ListLabelLength <= 0 {
+		return label
+	}
+	return truncateText(label, b.config.ListLabelLength)
+}
+
+// fullTextKeyboard builds buttons revealing the full text of reminders whose label
+// was truncated in a list or that have a note. It returns false if there are none.
+func (b *ReminderBot) fullTextKeyboard(reminders []storage.ReminderItem) (tgbotapi.InlineKeyboardMarkup, bool) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, r := range reminders {
+		if len(rows) == maxFullTextButtons {
+			break
+		}
+		if b.listLabel(r.Label) == r.Label && r.Note == "" {
+			continue
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📄 "+truncateText(r.Label, fullTextButtonLength),
+				"show_full_"+strconv.FormatInt(r.ID, 10)),
+		))
+	}
+
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), true
+}
+
+// handleShowFullCallback sends the full label and note of a reminder
+func (b *ReminderBot) handleShowFullCallback(query *tgbotapi.CallbackQuery) {
+	reminderID, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "show_full_"), 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing reminder ID from callback: %v", err)
+		return
+	}
+
+	reminder, err := b.reminders.Get(context.Background(), reminderID)
+	if err != nil || reminder.UserID != query.From.ID {
+		if err != nil {
+			b.logger.Printf("Error getting reminder %d: %v", reminderID, err)
+		}
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(notification)
+		return
+	}
+
+	text := formatReminderTime(*reminder, "02.01.2006 15:04") + "\n\n" + reminderText(*reminder)
+	reply := tgbotapi.NewMessage(query.Message.Chat.ID, text)
+	reply.ParseMode = reminderParseMode
+	b.bot.Send(reply)
+}
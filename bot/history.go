@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"reminders21/storage"
+)
+
+// ackCallback is the callback data of the "✅ Понял" button under delivered reminders
+const ackCallback = "ack"
+
+// resurfaceBatchSize limits how many unacknowledged reminders are re-sent per tick
+const resurfaceBatchSize = 20
+
+// ackRow builds the acknowledgement button row added to delivered reminders
+func ackRow() []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✅ Понял", ackCallback))
+}
+
+// recordDelivery stores which reminder was delivered in the sent message,
+// so the acknowledgement button can find it later
+func (b *ReminderBot) recordDelivery(ctx context.Context, sent tgbotapi.Message, userID, reminderID, recurringID int64, label string) {
+	_, err := b.repo.AddHistoryContext(ctx, storage.HistoryEntry{
+		UserID:      userID,
+		ChatID:      sent.Chat.ID,
+		MessageID:   sent.MessageID,
+		ReminderID:  reminderID,
+		RecurringID: recurringID,
+		Label:       label,
+		SentAt:      time.Now(),
+	})
+	if err != nil {
+		b.logger.Printf("Error recording delivery of reminder %d/%d: %v", reminderID, recurringID, err)
+	}
+}
+
+// handleAckCallback records that the user saw a delivered reminder and removes the button
+func (b *ReminderBot) handleAckCallback(query *tgbotapi.CallbackQuery) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	acknowledged, err := b.repo.AcknowledgeHistoryContext(ctx, query.Message.Chat.ID, query.Message.MessageID, query.From.ID, time.Now())
+	if err != nil {
+		b.logger.Printf("Error acknowledging reminder: %v", err)
+		return
+	}
+	if !acknowledged {
+		return
+	}
+
+	// Keep the other buttons, e.g. snooze
+	rows := [][]tgbotapi.InlineKeyboardButton{}
+	if query.Message.ReplyMarkup != nil {
+		for _, row := range query.Message.ReplyMarkup.InlineKeyboard {
+			if len(row) == 1 && row[0].CallbackData != nil && *row[0].CallbackData == ackCallback {
+				continue
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows})
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error removing acknowledgement button: %v", err)
+	}
+
+	b.logger.Printf("Acknowledged reminder message %d (user %d)", query.Message.MessageID, query.From.ID)
+}
+
+// processUnacknowledgedReminders sends once more reminders that weren't acknowledged
+// within AckResurfaceAfter, as a reply to the original message
+func (b *ReminderBot) processUnacknowledgedReminders() {
+	if b.config.AckResurfaceAfter <= 0 || time.Now().Before(b.sendPausedUntil) {
+		return
+	}
+
+	ctx := context.Background()
+	entries, err := b.repo.GetUnacknowledgedHistoryContext(ctx, time.Now().Add(-b.config.AckResurfaceAfter), resurfaceBatchSize)
+	if err != nil {
+		b.logger.Printf("Error getting unacknowledged reminders: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		text := tgbotapi.NewMessage(entry.ChatID, "🔔 Вы не отметили напоминание:\n"+escapeText(reminderParseMode, entry.Label))
+		text.ParseMode = reminderParseMode
+		text.ReplyToMessageID = entry.MessageID
+		text.AllowSendingWithoutReply = true
+		text.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(ackRow())
+
+		sent, err := b.bot.Send(text)
+		if err != nil {
+			if delay, ok := retryAfter(err); ok {
+				if delay == 0 {
+					delay = b.config.SendRetryDelay
+				}
+				b.sendPausedUntil = time.Now().Add(delay)
+				b.logger.Printf("Rate limited by Telegram, pausing reminder delivery for %s", delay)
+				return
+			}
+			// Don't retry forever, e.g. if the bot was blocked
+			b.logger.Printf("Error re-surfacing reminder: %v", err)
+		}
+
+		if err := b.repo.MarkHistoryResurfacedContext(ctx, entry.ID, sent.MessageID, time.Now()); err != nil {
+			b.logger.Printf("Error marking reminder as re-surfaced: %v", err)
+		}
+	}
+}
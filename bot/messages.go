@@ -2,108 +2,183 @@ package bot
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/speech"
+	"reminders21/utils"
+)
+
+const (
+	// maxNoteLength keeps a stored note well within Telegram's 4096-character message limit
+	maxNoteLength = 3500
+	// forwardedContextLength limits how much of a forwarded message is passed to the LLM
+	forwardedContextLength = 500
 )
 
 // handleTextMessage handles text messages
 func (b *ReminderBot) handleTextMessage(msg *tgbotapi.Message) {
-	b.logger.Printf("Received text message from %d: %s", msg.From.ID, msg.Text)
-
-	// Get user reminders for context
-	userReminders, err := b.getUserRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
-		b.bot.Send(reply)
+	// Stickers, non-audio documents and other content without text have nothing to parse
+	if strings.TrimSpace(msg.Text) == "" {
 		return
 	}
 
-	// Get user recurring reminders for context
-	recurringReminders, err := b.getUserRecurringRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user recurring reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
-		b.bot.Send(reply)
-		return
-	}
-
-	// Combine both reminder types
-	allReminders := append(userReminders, recurringReminders...)
+	b.logger.Printf("Received text message from %d: %s", msg.From.ID, msg.Text)
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
 	defer cancel()
 
-	// Parse message with LLM
-	llmOutput, err := b.llmClient.ParseMessage(ctx, llmPrompt, msg.Text, allReminders)
-	if err != nil {
-		b.logger.Printf("Error parsing message with LLM: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не смог разобрать запрос. Попробуйте переформулировать.")
-		b.bot.Send(reply)
+	// Answers to the bot's questions, e.g. a new time from the recurring list, skip the LLM
+	if b.handleDialogAnswer(ctx, msg) {
 		return
 	}
 
-	// Process operations
-	b.processOperations(llmOutput.Operations, msg)
+	input := msg.Text
+	if msg.ForwardDate == 0 {
+		// Let the LLM see what the reply refers to so the label can mention it
+		if note := forwardedNote(msg); note != "" {
+			input += "\n\nПересланное сообщение: " + truncateText(note, forwardedContextLength)
+		}
+	}
+
+	b.processUserInput(ctx, msg, input, "Не смог разобрать запрос. Попробуйте переформулировать.")
+}
+
+// forwardedNote returns the forwarded text a reminder is being set on: the message itself
+// if it was forwarded, or the forwarded message it replies to. It is empty otherwise.
+func forwardedNote(msg *tgbotapi.Message) string {
+	source := msg
+	if source.ForwardDate == 0 {
+		source = msg.ReplyToMessage
+	}
+	if source == nil || source.ForwardDate == 0 {
+		return ""
+	}
+
+	note := source.Text
+	if note == "" {
+		note = source.Caption
+	}
+	return truncateText(note, maxNoteLength)
 }
 
 // handleEditedMessage handles edited messages
 func (b *ReminderBot) handleEditedMessage(msg *tgbotapi.Message) {
 	b.logger.Printf("Received edited message from %d: %s", msg.From.ID, msg.Text)
 
-	// Get user reminders for context
-	userReminders, err := b.getUserRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
-		b.bot.Send(reply)
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	// Add prefix to indicate this is an edited message
+	editedText := "Отредактировано: " + msg.Text
+
+	b.processUserInput(ctx, msg, editedText, "Не смог разобрать отредактированный запрос. Попробуйте ещё раз.")
+}
+
+// handleVoiceMessage handles voice messages
+func (b *ReminderBot) handleVoiceMessage(msg *tgbotapi.Message) {
+	b.logger.Printf("Received voice message from %d", msg.From.ID)
+
+	if !b.checkMediaLimits(msg, msg.Voice.FileSize, msg.Voice.Duration) {
 		return
 	}
 
-	// Get user recurring reminders for context
-	recurringReminders, err := b.getUserRecurringRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user recurring reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
-		b.bot.Send(reply)
+	b.transcribeAndProcess(msg, msg.Voice.FileID)
+}
+
+// handleAudioMessage handles audio files sent as music or as documents (e.g. .m4a, .mp3)
+func (b *ReminderBot) handleAudioMessage(msg *tgbotapi.Message) {
+	var fileID string
+	var fileSize, duration int
+	if msg.Audio != nil {
+		fileID, fileSize, duration = msg.Audio.FileID, msg.Audio.FileSize, msg.Audio.Duration
+	} else {
+		// Documents don't report a duration
+		fileID, fileSize = msg.Document.FileID, msg.Document.FileSize
+	}
+
+	b.logger.Printf("Received audio file from %d", msg.From.ID)
+
+	if !b.checkMediaLimits(msg, fileSize, duration) {
 		return
 	}
 
-	// Combine both reminder types
-	allReminders := append(userReminders, recurringReminders...)
+	b.transcribeAndProcess(msg, fileID)
+}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
-	defer cancel()
+// fileSizeText formats a size in bytes for users, e.g. "20 МБ", "1.5 МБ" or "500 КБ"
+func fileSizeText(size int) string {
+	const kb, mb = 1024, 1024 * 1024
+	switch {
+	case size >= mb && size%mb == 0:
+		return fmt.Sprintf("%d МБ", size/mb)
+	case size >= mb:
+		return fmt.Sprintf("%.1f МБ", float64(size)/mb)
+	case size >= kb:
+		return fmt.Sprintf("%d КБ", size/kb)
+	default:
+		return fmt.Sprintf("%d байт", size)
+	}
+}
 
-	// Add prefix to indicate this is an edited message
-	editedText := "Отредактировано: " + msg.Text
+// checkMediaLimits rejects media files that are too large or too long to transcribe.
+// It replies to the user and returns false if the file is over a limit.
+func (b *ReminderBot) checkMediaLimits(msg *tgbotapi.Message, fileSize, durationSeconds int) bool {
+	if b.config.MaxMediaFileSize > 0 && fileSize > b.config.MaxMediaFileSize {
+		b.logger.Printf("Rejected media file from %d: size %d exceeds %d", msg.From.ID, fileSize, b.config.MaxMediaFileSize)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Файл слишком большой. Максимальный размер – %s.",
+			fileSizeText(b.config.MaxMediaFileSize)))
+		b.bot.Send(reply)
+		return false
+	}
 
-	// Parse message with LLM
-	llmOutput, err := b.llmClient.ParseMessage(ctx, llmPrompt, editedText, allReminders)
-	if err != nil {
-		b.logger.Printf("Error parsing edited message with LLM: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не смог разобрать отредактированный запрос. Попробуйте ещё раз.")
+	duration := time.Duration(durationSeconds) * time.Second
+	if b.config.MaxMediaDuration > 0 && duration > b.config.MaxMediaDuration {
+		b.logger.Printf("Rejected media file from %d: duration %s exceeds %s", msg.From.ID, duration, b.config.MaxMediaDuration)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Запись слишком длинная. Максимальная длительность – %s.",
+			formatMediaDuration(b.config.MaxMediaDuration)))
 		b.bot.Send(reply)
-		return
+		return false
 	}
 
-	// Process operations
-	b.processOperations(llmOutput.Operations, msg)
+	return true
 }
 
-// handleVoiceMessage handles voice messages
-func (b *ReminderBot) handleVoiceMessage(msg *tgbotapi.Message) {
-	b.logger.Printf("Received voice message from %d", msg.From.ID)
+// formatMediaDuration formats a duration limit for users, e.g. "5 мин" or "30 сек"
+func formatMediaDuration(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		return fmt.Sprintf("%d мин", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d сек", int(d.Seconds()))
+}
+
+// isAudioDocument reports whether a document is an audio file that can be transcribed
+func isAudioDocument(doc *tgbotapi.Document) bool {
+	if strings.HasPrefix(doc.MimeType, "audio/") {
+		return true
+	}
 
+	switch strings.ToLower(filepath.Ext(doc.FileName)) {
+	case ".m4a", ".mp3", ".ogg", ".oga", ".opus", ".wav", ".flac", ".webm":
+		return true
+	default:
+		return false
+	}
+}
+
+// transcribeAndProcess downloads an audio file, transcribes it and processes the text
+func (b *ReminderBot) transcribeAndProcess(msg *tgbotapi.Message, fileID string) {
 	// Send typing action
 	b.bot.Send(tgbotapi.NewChatAction(msg.Chat.ID, tgbotapi.ChatRecordVoice))
 
-	// Download voice file
-	fileID := msg.Voice.FileID
+	// Download audio file
 	filePath, err := b.downloadTelegramFile(fileID)
 	if err != nil {
 		b.logger.Printf("Error downloading voice file: %v", err)
@@ -113,11 +188,14 @@ func (b *ReminderBot) handleVoiceMessage(msg *tgbotapi.Message) {
 	}
 	defer os.Remove(filePath)
 
-	// Transcribe
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	// Transcribe; a new message from the user or /cancel stops it
+	ctx, done := b.transcriptions.begin(msg.From.ID)
+	defer done()
 
-	transcription, err := b.transcriber.TranscribeFile(ctx, filePath)
+	transcription, err := b.transcribe(ctx, filePath)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
 	if err != nil {
 		b.logger.Printf("Error transcribing voice: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось распознать голосовое сообщение.")
@@ -128,44 +206,41 @@ func (b *ReminderBot) handleVoiceMessage(msg *tgbotapi.Message) {
 	// Process transcription
 	b.logger.Printf("Transcription: %s", transcription)
 
-	// Get user reminders for context
-	userReminders, err := b.getUserRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
-		b.bot.Send(reply)
-		return
-	}
+	b.processUserInput(ctx, msg, transcription, "Не смог разобрать запрос из голосового сообщения. Попробуйте ещё раз.")
+}
 
-	// Get user recurring reminders for context
-	recurringReminders, err := b.getUserRecurringRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user recurring reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
-		b.bot.Send(reply)
-		return
+// transcribe transcribes an audio file. If the server rejects its encoding, the file is
+// converted to WAV with ffmpeg and sent once more.
+func (b *ReminderBot) transcribe(ctx context.Context, filePath string) (string, error) {
+	transcription, err := b.transcriber.TranscribeFile(ctx, filePath)
+	if !errors.Is(err, speech.ErrUnsupportedFormat) || b.ffmpegPath == "" {
+		return transcription, err
 	}
 
-	// Combine both reminder types
-	allReminders := append(userReminders, recurringReminders...)
-
-	// Parse transcription with LLM
-	llmOutput, err := b.llmClient.ParseMessage(ctx, llmPrompt, transcription, allReminders)
-	if err != nil {
-		b.logger.Printf("Error parsing transcription with LLM: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не смог разобрать запрос из голосового сообщения. Попробуйте ещё раз.")
-		b.bot.Send(reply)
-		return
+	b.logger.Printf("Warning: audio format rejected, retrying as WAV: %v", err)
+	wavPath, convertErr := b.convertToWAV(ctx, filePath)
+	if convertErr != nil {
+		return "", fmt.Errorf("%w (conversion to WAV failed: %v)", err, convertErr)
 	}
+	defer os.Remove(wavPath)
 
-	// Process operations
-	b.processOperations(llmOutput.Operations, msg)
+	return b.transcriber.TranscribeFile(ctx, wavPath)
 }
 
 // handleVideoMessage handles video messages
 func (b *ReminderBot) handleVideoMessage(msg *tgbotapi.Message) {
 	b.logger.Printf("Received video message from %d", msg.From.ID)
 
+	if b.ffmpegPath == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Обработка видео недоступна. Отправьте, пожалуйста, текст или голосовое сообщение.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if !b.checkMediaLimits(msg, msg.Video.FileSize, msg.Video.Duration) {
+		return
+	}
+
 	// Send typing action
 	b.bot.Send(tgbotapi.NewChatAction(msg.Chat.ID, tgbotapi.ChatRecordVoice))
 
@@ -190,11 +265,14 @@ func (b *ReminderBot) handleVideoMessage(msg *tgbotapi.Message) {
 	}
 	defer os.Remove(audioPath)
 
-	// Transcribe audio
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	// Transcribe audio; a new message from the user or /cancel stops it
+	ctx, done := b.transcriptions.begin(msg.From.ID)
+	defer done()
 
-	transcription, err := b.transcriber.TranscribeFile(ctx, audioPath)
+	transcription, err := b.transcribe(ctx, audioPath)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
 	if err != nil {
 		b.logger.Printf("Error transcribing audio from video: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось распознать аудио из видео.")
@@ -205,36 +283,78 @@ func (b *ReminderBot) handleVideoMessage(msg *tgbotapi.Message) {
 	// Process transcription
 	b.logger.Printf("Transcription from video: %s", transcription)
 
-	// Get user reminders for context
-	userReminders, err := b.getUserRemindersAsMap(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user reminders: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
+	b.processUserInput(ctx, msg, transcription, "Не смог разобрать запрос из видео. Попробуйте ещё раз.")
+}
+
+// handlePhotoMessage handles photos; the caption is parsed as the reminder request
+// and the photo is attached to reminders created from it
+func (b *ReminderBot) handlePhotoMessage(msg *tgbotapi.Message) {
+	b.logger.Printf("Received photo from %d: %s", msg.From.ID, msg.Caption)
+
+	if msg.Caption == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Добавь к фото подпись с текстом и временем напоминания, например: \"Оплатить счёт завтра в 10:00\".")
 		b.bot.Send(reply)
 		return
 	}
 
-	// Get user recurring reminders for context
-	recurringReminders, err := b.getUserRecurringRemindersAsMap(msg.From.ID)
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	b.processUserInput(ctx, msg, msg.Caption, "Не смог разобрать подпись к фото. Попробуйте переформулировать.")
+}
+
+// llmReminders returns the user's one-time and recurring reminders as context for the LLM
+func (b *ReminderBot) llmReminders(ctx context.Context, userID int64) ([]map[string]string, error) {
+	userReminders, err := b.getUserRemindersAsMap(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recurringReminders, err := b.getUserRecurringRemindersAsMap(ctx, userID)
 	if err != nil {
-		b.logger.Printf("Error getting user recurring reminders: %v", err)
+		return nil, err
+	}
+
+	return append(userReminders, recurringReminders...), nil
+}
+
+// prepareInput resolves fuzzy quantities like "полтора часа" and vague times of day like
+// "утром" in the input rather than leaving them to the LLM. It returns the input and the
+// prompt to parse it with.
+func (b *ReminderBot) prepareInput(ctx context.Context, userID int64, input string) (string, string) {
+	times := b.userDayTimes(ctx, userID)
+	input = utils.NormalizeQuantities(input)
+	input = utils.ApplyDayTimes(input, times.Morning, times.Afternoon, times.Evening)
+	return input, llmPrompt + dayTimesPrompt(times)
+}
+
+// processUserInput parses user input with the LLM, using the user's reminders as context,
+// and executes the resulting operations. parseErrorText is sent if the LLM call fails.
+func (b *ReminderBot) processUserInput(ctx context.Context, msg *tgbotapi.Message, input string, parseErrorText string) {
+	// Get user reminders for context
+	allReminders, err := b.llmReminders(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting user reminders: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
 		b.bot.Send(reply)
 		return
 	}
 
-	// Combine both reminder types
-	allReminders := append(userReminders, recurringReminders...)
-
-	// Parse transcription with LLM
-	llmOutput, err := b.llmClient.ParseMessage(ctx, llmPrompt, transcription, allReminders)
+	// Parse input with LLM
+	input, prompt := b.prepareInput(ctx, msg.From.ID, input)
+	llmOutput, err := b.llmClient.ParseMessage(ctx, b.reminders.Now(ctx, msg.From.ID), prompt, input, allReminders)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// The user moved on, e.g. sent a new message while a voice message was processed
+		return
+	}
 	if err != nil {
-		b.logger.Printf("Error parsing transcription with LLM: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не смог разобрать запрос из видео. Попробуйте ещё раз.")
+		b.logger.Printf("Error parsing message with LLM: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, parseErrorText)
 		b.bot.Send(reply)
 		return
 	}
 
 	// Process operations
-	b.processOperations(llmOutput.Operations, msg)
+	b.processOperations(llmOutput.Operations, msg, input)
 }
@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Errors from checkMoveTarget
+var (
+	errBotCantPost   = errors.New("bot can't post to the chat")
+	errUserNotMember = errors.New("user is not a member of the chat")
+)
+
+// handleMoveCommand starts moving a reminder to another chat: "/move 42", or "/move"
+// to pick the reminder from a list
+func (b *ReminderBot) handleMoveCommand(ctx context.Context, msg *tgbotapi.Message) {
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		reminderID, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /move или /move ID напоминания.")
+			b.bot.Send(reply)
+			return
+		}
+		b.sendMoveTargets(ctx, msg.Chat.ID, msg.From.ID, reminderID)
+		return
+	}
+
+	reminders, err := b.reminders.List(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, r := range reminders {
+		if len(rows) == maxFullTextButtons {
+			break
+		}
+		if r.IsTodo {
+			continue
+		}
+		text := formatReminderTime(r, "02.01 15:04") + " " + truncateText(r.Label, fullTextButtonLength)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("move_pick_%d", r.ID)),
+		))
+	}
+
+	if len(rows) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "У вас нет напоминаний, которые можно перенести.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, "Какое напоминание перенести в другой чат?")
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.bot.Send(reply)
+}
+
+// sendMoveTargets offers the chats a reminder can be moved to: the user's private chat
+// and the chats in which they have created reminders
+func (b *ReminderBot) sendMoveTargets(ctx context.Context, chatID, userID, reminderID int64) {
+	reminder, err := b.reminders.Get(ctx, reminderID)
+	if err != nil || reminder.UserID != userID || reminder.Notified {
+		reply := tgbotapi.NewMessage(chatID, "Напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(reply)
+		return
+	}
+
+	chatIDs, err := b.repo.GetUserChatIDsContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting chats of user %d: %v", userID, err)
+	}
+	// In private chats the chat ID equals the user ID
+	chatIDs = append([]int64{userID, chatID}, chatIDs...)
+
+	seen := map[int64]bool{reminder.ChatID: true}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, id := range chatIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.chatTitle(id, userID),
+				fmt.Sprintf("move_to_%d_%d", reminderID, id)),
+		))
+	}
+
+	if len(rows) == 0 {
+		reply := tgbotapi.NewMessage(chatID, "Некуда переносить: добавь меня в группу и создай там напоминание или напиши мне в личку.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("Куда перенести «%s»? Сейчас оно придёт в %s.", reminder.Label, b.chatTitle(reminder.ChatID, userID))
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.bot.Send(reply)
+}
+
+// chatTitle names a chat for the user, e.g. "личные сообщения" or "«Семья»"
+func (b *ReminderBot) chatTitle(chatID, userID int64) string {
+	if chatID == userID {
+		return "личные сообщения"
+	}
+
+	chat, err := b.bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil || chat.Title == "" {
+		return fmt.Sprintf("чат %d", chatID)
+	}
+	return "«" + chat.Title + "»"
+}
+
+// checkMoveTarget makes sure the bot can post to the chat and the user is a member of it,
+// so reminders can't be sent to arbitrary chats
+func (b *ReminderBot) checkMoveTarget(chatID, userID int64) error {
+	self, err := b.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: b.bot.Self.ID},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errBotCantPost, err)
+	}
+	if self.HasLeft() || self.WasKicked() || (self.Status == "restricted" && !self.CanSendMessages) {
+		return errBotCantPost
+	}
+
+	if chatID == userID {
+		return nil
+	}
+
+	member, err := b.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil || member.HasLeft() || member.WasKicked() {
+		return errUserNotMember
+	}
+	return nil
+}
+
+// handleMoveCallback handles "move_pick_<id>" (choose the reminder) and
+// "move_to_<id>_<chat>" (move it) callbacks
+func (b *ReminderBot) handleMoveCallback(query *tgbotapi.CallbackQuery) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	if idStr, ok := strings.CutPrefix(query.Data, "move_pick_"); ok {
+		reminderID, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			b.logger.Printf("Error parsing reminder ID from callback: %v", err)
+			return
+		}
+		b.sendMoveTargets(ctx, query.Message.Chat.ID, query.From.ID, reminderID)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(query.Data, "move_to_"), "_")
+	if len(parts) != 2 {
+		b.logger.Printf("Invalid move callback: %s", query.Data)
+		return
+	}
+	reminderID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing reminder ID from move callback: %v", err)
+		return
+	}
+	chatID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing chat ID from move callback: %v", err)
+		return
+	}
+
+	if err := b.checkMoveTarget(chatID, query.From.ID); err != nil {
+		b.logger.Printf("Can't move reminder %d to chat %d: %v", reminderID, chatID, err)
+		text := "Я не могу писать в этот чат. Проверь, что я всё ещё в нём и могу отправлять сообщения."
+		if errors.Is(err, errUserNotMember) {
+			text = "Ты не состоишь в этом чате, перенести туда напоминание нельзя."
+		} else if chatID == query.From.ID {
+			text = "Я не могу написать тебе в личку. Открой чат со мной и нажми «Старт»."
+		}
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, text)
+		b.bot.Send(notification)
+		return
+	}
+
+	moved, err := b.reminders.Move(ctx, reminderID, query.From.ID, chatID)
+	if text, ok := reminderErrorText(err); ok {
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, text)
+		b.bot.Send(notification)
+		return
+	}
+	if err != nil {
+		b.logger.Printf("Error moving reminder: %v", err)
+		return
+	}
+	if !moved {
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Напоминание не найдено или уже сработало.")
+		b.bot.Send(notification)
+		return
+	}
+
+	text := fmt.Sprintf("✅ Напоминание перенесено: теперь оно придёт в %s.", b.chatTitle(chatID, query.From.ID))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	b.logger.Printf("Moved reminder ID=%d to chat %d (user %d)", reminderID, chatID, query.From.ID)
+}
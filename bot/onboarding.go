@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"reminders21/utils"
+)
+
+// onboardingTimezones are the choices offered to new users, as button label and zone
+var onboardingTimezones = [][2]string{
+	{"Калининград (UTC+2)", "Europe/Kaliningrad"},
+	{"Москва (UTC+3)", "Europe/Moscow"},
+	{"Самара (UTC+4)", "Europe/Samara"},
+	{"Екатеринбург (UTC+5)", "Asia/Yekaterinburg"},
+	{"Омск (UTC+6)", "Asia/Omsk"},
+	{"Новосибирск (UTC+7)", "Asia/Novosibirsk"},
+	{"Иркутск (UTC+8)", "Asia/Irkutsk"},
+	{"Владивосток (UTC+10)", "Asia/Vladivostok"},
+}
+
+// locationKeyboard asks the user to share their location
+func locationKeyboard() tgbotapi.ReplyKeyboardMarkup {
+	keyboard := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButtonLocation("📍 Отправить геолокацию")),
+	)
+	keyboard.OneTimeKeyboard = true
+	keyboard.ResizeKeyboard = true
+	return keyboard
+}
+
+// sendTimezonePrompt asks a new user to choose their timezone. withLocation tells
+// whether the location keyboard was shown, which only works in private chats.
+func (b *ReminderBot) sendTimezonePrompt(chatID int64, withLocation bool) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i := 0; i < len(onboardingTimezones); i += 2 {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, choice := range onboardingTimezones[i:min(i+2, len(onboardingTimezones))] {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(choice[0], "tz_set_"+choice[1]))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Пропустить", "tz_skip"),
+	))
+
+	how := "Выбери город или напиши /timezone и свой город."
+	if withLocation {
+		how = "Выбери город, отправь геолокацию кнопкой внизу или напиши /timezone и свой город."
+	}
+	text := fmt.Sprintf("🌍 В каком ты часовом поясе? От этого зависит, когда придут напоминания.\n\n%s Пока пояс не выбран, время считается по %s.",
+		how, b.config.DefaultTimezone)
+
+	prompt := tgbotapi.NewMessage(chatID, text)
+	prompt.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.bot.Send(prompt)
+}
+
+// handleTimezoneCallback handles the onboarding timezone choices ("tz_set_<zone>", "tz_skip")
+func (b *ReminderBot) handleTimezoneCallback(query *tgbotapi.CallbackQuery) {
+	timezone := b.config.DefaultTimezone
+	if zone, ok := strings.CutPrefix(query.Data, "tz_set_"); ok {
+		timezone = zone
+	}
+
+	// Saving the preference (even the default on skip) marks onboarding as done
+	if err := b.repo.SetUserTimezoneContext(context.Background(), query.From.ID, timezone); err != nil {
+		b.logger.Printf("Error setting timezone: %v", err)
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Ошибка при установке часового пояса.")
+		b.bot.Send(notification)
+		return
+	}
+
+	b.logger.Printf("User %d chose timezone %s during onboarding", query.From.ID, timezone)
+
+	// Drop the choices, then remove the location keyboard with the confirmation
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	text := fmt.Sprintf("Часовой пояс установлен: %s\nИзменить его можно командой /timezone", timezone)
+	reply := tgbotapi.NewMessage(query.Message.Chat.ID, text)
+	reply.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	b.bot.Send(reply)
+}
+
+// handleLocationMessage sets the user's timezone from a shared location
+func (b *ReminderBot) handleLocationMessage(msg *tgbotapi.Message) {
+	timezone := utils.TimezoneByLocation(msg.Location.Latitude, msg.Location.Longitude)
+
+	if err := b.repo.SetUserTimezoneContext(context.Background(), msg.From.ID, timezone); err != nil {
+		b.logger.Printf("Error setting timezone: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при установке часового пояса.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Printf("Set timezone %s for user %d from location", timezone, msg.From.ID)
+
+	// Kept for reminders relative to sunrise and sunset
+	if err := b.repo.SetUserLocationContext(context.Background(), msg.From.ID, msg.Location.Latitude, msg.Location.Longitude); err != nil {
+		b.logger.Printf("Error saving location of user %d: %v", msg.From.ID, err)
+	}
+
+	text := fmt.Sprintf("Часовой пояс установлен по геолокации: %s\nЕсли он определился неверно, укажи город: /timezone Москва", timezone)
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	b.bot.Send(reply)
+}
@@ -1,41 +1,84 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"reminders21/storage"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
 	"reminders21/llm"
+	"reminders21/utils"
 )
 
-// processOperations processes operations from LLM
-func (b *ReminderBot) processOperations(operations []llm.Operation, msg *tgbotapi.Message) {
+// processOperations processes operations the LLM parsed from input. Several operations
+// are confirmed with a single summary; errors are still reported per operation.
+func (b *ReminderBot) processOperations(operations []llm.Operation, msg *tgbotapi.Message, input string) {
+	var batch *operationBatch
+	if len(operations) > 1 {
+		batch = &operationBatch{}
+		defer b.sendBatch(msg.Chat.ID, batch)
+	}
+
 	for _, op := range operations {
 		switch op.Action {
 		case "create":
-			b.processCreateOperation(op, msg)
+			b.processCreateOperation(op, msg, batch)
 		case "create_recurring":
-			b.processCreateRecurringOperation(op, msg)
+			b.processCreateRecurringOperation(op, msg, batch)
 		case "adjust":
-			b.processAdjustOperation(op, msg)
+			b.processAdjustOperation(op, msg, batch)
 		case "delete":
-			b.processDeleteOperation(op, msg)
+			b.processDeleteOperation(op, msg, batch)
+		case "skip":
+			b.processSkipOperation(op, msg, batch)
 		case "show_list":
 			b.processShowListOperation(op, msg)
 		case "show_recurring":
-			b.processListRecurringOperation(msg)
+			b.processListRecurringOperation(op, msg)
 		case "set_timezone":
 			b.processSetTimezoneOperation(op, msg)
+		case "clarify":
+			b.processClarifyOperation(op, msg, input)
 		default:
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная операция. Попробуйте переформулировать запрос.")
-			b.bot.Send(reply)
+			b.processUnknownOperation(op, msg, input)
 		}
 	}
 }
 
+// processClarifyOperation sends the model's clarifying question about a request it
+// couldn't turn into an operation
+func (b *ReminderBot) processClarifyOperation(op llm.Operation, msg *tgbotapi.Message, input string) {
+	b.logger.Printf("Clarifying request from user %d: %q", msg.From.ID, input)
+
+	text := strings.TrimSpace(op.Answer)
+	if text == "" {
+		text = b.config.UnknownOperationText
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// processUnknownOperation replies to an operation with an action the bot doesn't know.
+// The model's own answer is usually a clarifying question, so it's preferred over the
+// configured fallback. The input is logged to help improve the prompt.
+func (b *ReminderBot) processUnknownOperation(op llm.Operation, msg *tgbotapi.Message, input string) {
+	b.logger.Printf("Unknown operation %q from user %d for input %q", op.Action, msg.From.ID, input)
+
+	text := strings.TrimSpace(op.Answer)
+	if text == "" {
+		text = b.config.UnknownOperationText
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
 // processSetTimezoneOperation processes set_timezone operation
 func (b *ReminderBot) processSetTimezoneOperation(op llm.Operation, msg *tgbotapi.Message) {
 	timezone := strings.TrimSpace(op.Timezone)
@@ -45,10 +88,16 @@ func (b *ReminderBot) processSetTimezoneOperation(op llm.Operation, msg *tgbotap
 		return
 	}
 
-	err := b.repo.SetUserTimezone(msg.From.ID, timezone)
+	// The LLM may answer with a city name instead of an IANA zone
+	if zone, ok := utils.ResolveTimezone(timezone); ok {
+		timezone = zone
+	}
+
+	err := b.repo.SetUserTimezoneContext(context.Background(), msg.From.ID, timezone)
 	if err != nil {
 		b.logger.Printf("Error setting timezone: %v", err)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный часовой пояс. Пожалуйста, используйте формат 'Continent/City', например 'Europe/Moscow'.")
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный часовой пояс. Пожалуйста, используйте формат 'Continent/City', например 'Europe/Moscow'."+
+			timezoneSuggestionText(op.Timezone))
 		b.bot.Send(reply)
 		return
 	}
@@ -63,9 +112,63 @@ func (b *ReminderBot) processSetTimezoneOperation(op llm.Operation, msg *tgbotap
 }
 
 // processCreateOperation processes create operation
-func (b *ReminderBot) processCreateOperation(op llm.Operation, msg *tgbotapi.Message) {
-	// Parse the time in UTC (as stored in database)
-	reminderTimeUTC, err := time.Parse("2006-01-02 15:04:05", op.Datetime)
+func (b *ReminderBot) processCreateOperation(op llm.Operation, msg *tgbotapi.Message, batch *operationBatch) {
+	ctx := context.Background()
+
+	// The time is ambiguous; the reminder is created once the user picks one
+	if len(op.CandidateDatetimes) > 1 {
+		b.showTimePicker(op, msg)
+		return
+	}
+	if op.Datetime == "" && len(op.CandidateDatetimes) == 1 {
+		op.Datetime = op.CandidateDatetimes[0]
+	}
+
+	var reminderTimeUser time.Time
+	var err error
+	if op.SolarEvent != "" {
+		reminderTimeUser, err = b.resolveSolarTime(ctx, op, msg.From.ID)
+		if err != nil {
+			b.logger.Printf("Error resolving solar time in create operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, solarTimeErrorText(err))
+			b.bot.Send(reply)
+			return
+		}
+	} else if op.RelativeToReminderID != "" {
+		reminderTimeUser, err = b.resolveRelativeTime(ctx, op, msg.From.ID)
+		if err != nil {
+			b.logger.Printf("Error resolving relative time in create operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, relativeTimeErrorText(err))
+			b.bot.Send(reply)
+			return
+		}
+	} else {
+		// The LLM resolves times against the user's local time, so parse in the user's timezone
+		reminderTimeUser, err = b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02 15:04:05", op.Datetime)
+		if err == nil && op.BusinessDays != 0 {
+			reminderTimeUser, err = b.businessDaysLater(ctx, msg.From.ID, reminderTimeUser, op.BusinessDays)
+			// The model can't know the date, so describe the reminder from the computed one
+			op.Answer = ""
+		} else if err == nil && op.Months != 0 {
+			reminderTimeUser, err = b.monthsLater(ctx, msg.From.ID, reminderTimeUser, op.Months)
+			op.Answer = ""
+		}
+		if err == nil && op.DateOnly && !op.IsTodo {
+			reminderTimeUser = b.applyDefaultReminderHour(ctx, msg, reminderTimeUser)
+		}
+	}
+	if errors.Is(err, errInvalidBusinessDays) {
+		b.logger.Printf("Invalid business days in create operation: %d", op.BusinessDays)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Могу отсчитать от 1 до %d рабочих дней.", maxBusinessDays))
+		b.bot.Send(reply)
+		return
+	}
+	if errors.Is(err, errInvalidMonths) {
+		b.logger.Printf("Invalid months in create operation: %d", op.Months)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Могу отсчитать от 1 до %d месяцев.", maxMonths))
+		b.bot.Send(reply)
+		return
+	}
 	if err != nil {
 		b.logger.Printf("Error parsing date/time in create operation: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат даты/времени в операции создания.")
@@ -73,23 +176,76 @@ func (b *ReminderBot) processCreateOperation(op llm.Operation, msg *tgbotapi.Mes
 		return
 	}
 
-	// Get user's timezone preference
-	timezone, err := b.repo.GetUserTimezone(msg.From.ID)
-	if err != nil {
-		b.logger.Printf("Error getting user timezone: %v", err)
-		timezone = "Europe/Moscow" // Default fallback
+	// Optional end of the event window; delivery still happens at the start time
+	var endTime time.Time
+	if op.EndDatetime != "" && !op.IsTodo {
+		endTime, err = b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02 15:04:05", op.EndDatetime)
+		if err != nil || !endTime.After(reminderTimeUser) {
+			b.logger.Printf("Ignoring invalid end time %q in create operation: %v", op.EndDatetime, err)
+			endTime = time.Time{}
+		}
 	}
 
-	// Convert time to user's timezone for display
-	userLocation, err := time.LoadLocation(timezone)
-	if err != nil {
-		b.logger.Printf("Error loading timezone: %v", err)
-		userLocation = time.UTC
+	// Move the reminder past the user's other reminders, keeping its length
+	if op.FreeSlot && !op.IsTodo {
+		var duration time.Duration
+		if !endTime.IsZero() {
+			duration = endTime.Sub(reminderTimeUser)
+		}
+		reminderTimeUser, err = b.freeSlot(ctx, msg.From.ID, reminderTimeUser, duration, 0)
+		if errors.Is(err, errNoFreeSlot) {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, noFreeSlotText)
+			b.bot.Send(reply)
+			return
+		}
+		if err != nil {
+			b.logger.Printf("Error finding free slot in create operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при создании напоминания.")
+			b.bot.Send(reply)
+			return
+		}
+		if !endTime.IsZero() {
+			endTime = reminderTimeUser.Add(duration)
+		}
+		// The model doesn't know which slot is free, so describe the reminder from the found one
+		op.Answer = ""
+	}
+
+	if op.Repeat > 1 && !op.IsTodo {
+		b.createReminderSeries(ctx, op, msg, batch, reminderTimeUser)
+		return
+	}
+
+	// Attach the largest size of the photo the reminder was created from, if any
+	var photoFileID string
+	if len(msg.Photo) > 0 {
+		photoFileID = msg.Photo[len(msg.Photo)-1].FileID
 	}
-	reminderTimeUser := reminderTimeUTC.In(userLocation)
 
-	// Add reminder to database (still using UTC time)
-	id, err := b.repo.AddReminder(msg.Chat.ID, msg.From.ID, reminderTimeUTC, op.Label, op.IsTodo)
+	// Overly long labels are cut; the full text stays in the note
+	label, note := b.shortenLabel(op.Label, forwardedNote(msg))
+
+	id, err := b.reminders.Create(ctx, storage.ReminderItem{
+		ChatID:       msg.Chat.ID,
+		UserID:       msg.From.ID,
+		ReminderTime: reminderTimeUser,
+		Label:        label,
+		IsTodo:       op.IsTodo,
+		PhotoFileID:  photoFileID,
+		Note:         note,
+		EndTime:      endTime,
+		SourceText:   truncateText(strings.TrimSpace(msg.Text+msg.Caption), sourceTextLength),
+		Silent:       op.Silent && !op.IsTodo,
+		Category:     normalizeCategory(op.Category),
+	})
+	if errors.Is(err, storage.ErrLimitReached) {
+		b.logger.Printf("User %d reached the reminder limit", msg.From.ID)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Достигнут лимит в %d активных напоминаний и задач. Удали ненужные или дождись, пока старые сработают.",
+			b.config.MaxRemindersPerUser))
+		b.bot.Send(reply)
+		return
+	}
 	if err != nil {
 		b.logger.Printf("Error adding reminder: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при создании напоминания.")
@@ -97,25 +253,34 @@ func (b *ReminderBot) processCreateOperation(op llm.Operation, msg *tgbotapi.Mes
 		return
 	}
 
-	itemType := "напоминание"
-	if op.IsTodo {
-		itemType = "задачу"
-	}
-
-	b.logger.Printf("Created %s: ID=%d, '%s' at %s (chat %d, timezone %s)",
-		itemType, id, op.Label, reminderTimeUTC.Format("2006-01-02 15:04:05"), msg.Chat.ID, timezone)
+	b.logger.Info("reminder_created", "reminder_id", id, "user_id", msg.From.ID, "chat_id", msg.Chat.ID,
+		"todo", op.IsTodo, "at", reminderTimeUser.UTC().Format("2006-01-02 15:04:05"), "timezone", reminderTimeUser.Location().String())
 
 	// Format answer with human-readable time in user's timezone
 	answer := op.Answer
 	if answer == "" {
 		if op.IsTodo {
 			answer = fmt.Sprintf("Создана задача: %s на %s",
-				op.Label, reminderTimeUser.Format("02.01.2006"))
+				label, reminderTimeUser.Format("02.01.2006"))
 		} else {
 			answer = fmt.Sprintf("Создано напоминание: %s в %s",
-				op.Label, reminderTimeUser.Format("02.01.2006 15:04"))
+				label, formatReminderTime(storage.ReminderItem{ReminderTime: reminderTimeUser, EndTime: endTime}, "02.01.2006 15:04"))
+		}
+	}
+	if op.Silent && !op.IsTodo {
+		answer += "\n" + silentNote
+	}
+	if !op.IsTodo {
+		if note := b.nearTermNote(reminderTimeUser); note != "" {
+			answer += "\n\n" + note
+		}
+		if note := b.conflictNote(ctx, msg.From.ID, reminderTimeUser, endTime, id); note != "" {
+			answer += "\n\n" + note
 		}
 	}
+	if note := groupScopeNote(msg, op.IsTodo, b.chatDeletesByAnyone(ctx, msg.Chat)); note != "" {
+		answer += "\n\n" + note
+	}
 
 	// Create reply message with delete button
 	reply := tgbotapi.NewMessage(msg.Chat.ID, answer)
@@ -129,11 +294,164 @@ func (b *ReminderBot) processCreateOperation(op llm.Operation, msg *tgbotapi.Mes
 	)
 	reply.ReplyMarkup = keyboard
 
-	b.bot.Send(reply)
+	b.confirm(batch, confirmCreated, label, reply)
+}
+
+// maxBusinessDays is the furthest a reminder can be set in business days
+const maxBusinessDays = 365
+
+// errInvalidBusinessDays is returned by businessDaysLater for a count out of range
+var errInvalidBusinessDays = errors.New("invalid number of business days")
+
+// businessDaysLater moves at to the day n business days after today in the user's timezone,
+// keeping its time of day. Weekends and the configured holidays are skipped.
+func (b *ReminderBot) businessDaysLater(ctx context.Context, userID int64, at time.Time, n int) (time.Time, error) {
+	if n < 1 || n > maxBusinessDays {
+		return time.Time{}, errInvalidBusinessDays
+	}
+
+	day := utils.AddBusinessDays(b.reminders.Now(ctx, userID), n, b.config.Holidays)
+	return time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), at.Second(), 0, at.Location()), nil
+}
+
+// maxMonths is the furthest a reminder can be set in calendar months
+const maxMonths = 120
+
+// errInvalidMonths is returned by monthsLater for a count out of range
+var errInvalidMonths = errors.New("invalid number of months")
+
+// monthsLater moves at to the day n calendar months after today in the user's timezone,
+// keeping its time of day. Days missing from the target month fall on its last day.
+func (b *ReminderBot) monthsLater(ctx context.Context, userID int64, at time.Time, n int) (time.Time, error) {
+	if n < 1 || n > maxMonths {
+		return time.Time{}, errInvalidMonths
+	}
+
+	day := utils.AddMonths(b.reminders.Now(ctx, userID), n)
+	return time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), at.Second(), 0, at.Location()), nil
+}
+
+// silentNote is added to the confirmation of a reminder delivered without a sound
+const silentNote = "🔕 Придёт без звука."
+
+// nearTermNote tells the user a reminder due before the next delivery check is sent almost
+// immediately, or right away if its time has passed. It returns "" for later reminders.
+func (b *ReminderBot) nearTermNote(at time.Time) string {
+	until := time.Until(at)
+	if until <= 0 {
+		return "⏱ Это время уже прошло, поэтому напомню сразу."
+	}
+	if until > b.config.ReminderCheckInterval {
+		return ""
+	}
+
+	seconds := int(b.config.ReminderCheckInterval.Round(time.Second).Seconds())
+	return fmt.Sprintf("⏱ Это совсем скоро – напомню в течение %d %s.",
+		seconds, utils.PluralRussian(seconds, "секунды", "секунд", "секунд"))
+}
+
+// Errors from resolveRelativeTime
+var (
+	errReferenceNotFound  = errors.New("referenced reminder not found")
+	errReferenceRecurring = errors.New("referenced reminder is recurring")
+	errInvalidOffset      = errors.New("invalid offset")
+)
+
+// offsetPattern matches offsets like "3d", "-1h", "1d2h30m"
+var offsetPattern = regexp.MustCompile(`^([+-])?(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?$`)
+
+// resolveRelativeTime computes the time of a reminder created relative to another one
+// by applying op.Offset to the referenced reminder's time, in the user's timezone
+func (b *ReminderBot) resolveRelativeTime(ctx context.Context, op llm.Operation, userID int64) (time.Time, error) {
+	if isRecurringReminderID(op.RelativeToReminderID) {
+		return time.Time{}, errReferenceRecurring
+	}
+
+	reminderID, err := strconv.ParseInt(op.RelativeToReminderID, 10, 64)
+	if err != nil {
+		return time.Time{}, errReferenceNotFound
+	}
+
+	reference, err := b.reminders.Get(ctx, reminderID)
+	if errors.Is(err, storage.ErrReminderNotFound) || (err == nil && reference.UserID != userID) {
+		return time.Time{}, errReferenceNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if op.Offset == "" {
+		return time.Time{}, errInvalidOffset
+	}
+	days, duration, err := parseOffset(op.Offset)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Days are added on the calendar so the wall-clock time survives DST changes
+	return reference.ReminderTime.AddDate(0, 0, days).Add(duration), nil
+}
+
+// parseOffset parses an offset like "3d", "-1h" or "1d2h30m" into days and the rest.
+// It returns errInvalidOffset if the offset doesn't match offsetPattern.
+func parseOffset(offset string) (int, time.Duration, error) {
+	m := offsetPattern.FindStringSubmatch(strings.ReplaceAll(strings.ToLower(offset), " ", ""))
+	if m == nil {
+		return 0, 0, errInvalidOffset
+	}
+
+	days, _ := strconv.Atoi(m[2])
+	hours, _ := strconv.Atoi(m[3])
+	minutes, _ := strconv.Atoi(m[4])
+	if m[1] == "-" {
+		days, hours, minutes = -days, -hours, -minutes
+	}
+
+	return days, time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// relativeTimeErrorText explains why a relative reminder couldn't be created
+func relativeTimeErrorText(err error) string {
+	switch {
+	case errors.Is(err, errReferenceNotFound):
+		return "Не нашёл напоминание, от которого нужно отсчитать время. Уточни, о каком напоминании речь, или укажи дату явно."
+	case errors.Is(err, errReferenceRecurring):
+		return "Нельзя отсчитать время от регулярного напоминания. Укажи дату явно."
+	case errors.Is(err, errInvalidOffset):
+		return "Не понял, через сколько после напоминания нужно напомнить. Укажи дату явно."
+	default:
+		return "Ошибка при создании напоминания."
+	}
+}
+
+// groupScopeNote explains where a reminder created in a group chat will be delivered
+// and who can manage it. anyoneDeletes is set if the chat lets any member delete it.
+// It returns "" in private chats.
+func groupScopeNote(msg *tgbotapi.Message, isTodo, anyoneDeletes bool) string {
+	if msg.Chat.IsPrivate() {
+		return ""
+	}
+
+	chat := "этот чат"
+	if msg.Chat.Title != "" {
+		chat = fmt.Sprintf("чат «%s»", msg.Chat.Title)
+	}
+
+	// Reminders belong to their creator (lists, edits, deletes) but are delivered to the chat
+	if isTodo {
+		return fmt.Sprintf("Задача добавлена в личный список %s, в %s она не придёт.",
+			msg.From.FirstName, chat)
+	}
+	if anyoneDeletes {
+		return fmt.Sprintf("Напоминание придёт в %s, его увидят все участники. Изменить его может только %s, удалить – любой участник.",
+			chat, msg.From.FirstName)
+	}
+	return fmt.Sprintf("Напоминание придёт в %s, его увидят все участники. Изменить или удалить его может только %s.",
+		chat, msg.From.FirstName)
 }
 
 // processCreateRecurringOperation processes create recurring operation
-func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgbotapi.Message) {
+func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgbotapi.Message, batch *operationBatch) {
 	// Parse time (should be in format "15:04")
 	timeStr := op.Time
 	if timeStr == "" && !op.IsTodo {
@@ -146,7 +464,7 @@ func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgb
 		}
 	}
 
-	if timeStr == "" && !op.IsTodo {
+	if timeStr == "" && !op.IsTodo && op.SolarEvent == "" {
 		b.logger.Printf("Missing time in create recurring operation")
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не указано время для повторяющегося напоминания.")
 		b.bot.Send(reply)
@@ -158,8 +476,38 @@ func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgb
 		timeStr = "00:00"
 	}
 
+	// Reminders following the sun have no fixed time
+	if timeStr != "" {
+		var err error
+		if timeStr, err = storage.NormalizeClockTime(timeStr); err != nil {
+			b.logger.Printf("Invalid time in create recurring operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, invalidRecurringTimeText(op.Time))
+			b.bot.Send(reply)
+			return
+		}
+	}
+
+	item := storage.RecurringReminder{
+		Label:    op.Label,
+		Time:     timeStr,
+		IsTodo:   op.IsTodo,
+		Silent:   op.Silent && !op.IsTodo,
+		Category: normalizeCategory(op.Category),
+	}
+	if !op.IsTodo {
+		item.EndTime = recurringEndTime(op, timeStr)
+	}
+	if op.SolarEvent != "" && !op.IsTodo {
+		if err := b.applySolarSchedule(context.Background(), op, msg.From.ID, &item); err != nil {
+			b.logger.Printf("Error setting solar schedule in create recurring operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, solarTimeErrorText(err))
+			b.bot.Send(reply)
+			return
+		}
+	}
+
 	var recurringType storage.RecurringType
-	var dayOfWeek, dayOfMonth int
+	var dayOfWeek, dayOfMonth, weekOfMonth int
 
 	// Default values
 	dayOfWeek = -1
@@ -173,7 +521,7 @@ func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgb
 		// Parse day of week
 		if op.DayOfWeek != "" {
 			dow, err := strconv.Atoi(op.DayOfWeek)
-			if err == nil && dow >= 0 && dow <= 6 {
+			if err == nil && isWeeklyDay(dow) {
 				dayOfWeek = dow
 			} else {
 				// Try to parse day name
@@ -181,7 +529,7 @@ func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgb
 			}
 		}
 
-		if dayOfWeek < 0 || dayOfWeek > 6 {
+		if !isWeeklyDay(dayOfWeek) {
 			b.logger.Printf("Invalid day of week: %s", op.DayOfWeek)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный день недели для еженедельного напоминания.")
 			b.bot.Send(reply)
@@ -203,66 +551,192 @@ func (b *ReminderBot) processCreateRecurringOperation(op llm.Operation, msg *tgb
 			b.bot.Send(reply)
 			return
 		}
+	case "monthly_weekday":
+		recurringType = storage.RecurringMonthlyWeekday
+		dayOfWeek, weekOfMonth = parseWeekOfMonth(op, -1, 0)
+
+		if dayOfWeek < 0 || weekOfMonth == 0 {
+			b.logger.Printf("Invalid week of month: %s/%s", op.WeekOfMonth, op.DayOfWeek)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Не понял, в какой день месяца напоминать. Например: «в первый понедельник месяца» или «в последний рабочий день месяца».")
+			b.bot.Send(reply)
+			return
+		}
+	case "hourly":
+		recurringType = storage.RecurringHourly
+		if op.IsTodo || item.SolarEvent != "" {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, hourlyUnsupportedText)
+			b.bot.Send(reply)
+			return
+		}
+
+		hours, ok := hourlyInterval(op.IntervalHours)
+		if !ok {
+			b.logger.Printf("Invalid hourly interval: %d", op.IntervalHours)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, invalidHourlyIntervalText)
+			b.bot.Send(reply)
+			return
+		}
+		item.IntervalHours = hours
 	default:
 		b.logger.Printf("Invalid recurring type: %s", op.RecurringType)
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный тип повторения. Используйте 'daily', 'weekly' или 'monthly'.")
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный тип повторения. Используйте 'hourly', 'daily', 'weekly' или 'monthly'.")
 		b.bot.Send(reply)
 		return
 	}
 
-	b.addRecurringReminder(msg, op.Label, recurringType, timeStr, dayOfWeek, dayOfMonth, op.IsTodo)
+	item.RecurringType = recurringType
+	item.DayOfWeek = dayOfWeek
+	item.DayOfMonth = dayOfMonth
+	item.WeekOfMonth = weekOfMonth
+	b.addRecurringReminder(msg, item, batch)
+}
+
+// recurringEndTime returns the end of a recurring event window ("15:04") from the
+// operation's end_time or end_datetime. It returns "" if there is none or it isn't
+// after the start time.
+func recurringEndTime(op llm.Operation, start string) string {
+	end := op.EndTime
+	if end == "" && op.EndDatetime != "" {
+		if t, err := time.Parse("2006-01-02 15:04:05", op.EndDatetime); err == nil {
+			end = t.Format("15:04")
+		}
+	}
+
+	end, err := storage.NormalizeClockTime(end)
+	if err != nil {
+		return ""
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return ""
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil || !endTime.After(startTime) {
+		return ""
+	}
+	return endTime.Format("15:04")
+}
+
+// maxHourlyInterval is the most hours between occurrences of an hourly reminder
+const maxHourlyInterval = 12
+
+// hourlyInterval returns the hours between occurrences of an hourly reminder, 1 if not
+// given. It reports false if the interval is out of range.
+func hourlyInterval(hours int) (int, bool) {
+	if hours == 0 {
+		return 1, true
+	}
+	return hours, hours >= 1 && hours <= maxHourlyInterval
+}
+
+// Sent when an hourly reminder can't be created or its interval isn't valid
+var (
+	hourlyUnsupportedText     = "Каждые несколько часов можно только напоминать: задачи и напоминания по восходу или закату так не повторяются."
+	invalidHourlyIntervalText = fmt.Sprintf("Повторять можно раз в 1–%d часов. Например: «каждые 2 часа с 9 до 18».", maxHourlyInterval)
+)
+
+// invalidRecurringTimeText explains that the time of a recurring reminder wasn't understood
+func invalidRecurringTimeText(timeStr string) string {
+	return fmt.Sprintf("Не понял время «%s». Укажи его в формате ЧЧ:ММ, например 09:00.", timeStr)
+}
+
+// parseWeekOfMonth parses the weekday (0-6 or storage.Workday) and week of month
+// (1-5 or storage.LastWeekOfMonth) of a monthly_weekday operation, keeping the given
+// values for fields that are missing or invalid
+func parseWeekOfMonth(op llm.Operation, dayOfWeek, weekOfMonth int) (int, int) {
+	if op.DayOfWeek != "" {
+		if dow, err := strconv.Atoi(op.DayOfWeek); err == nil && dow >= 0 && dow <= storage.Workday {
+			dayOfWeek = dow
+		} else if dow := parseDayOfWeek(op.DayOfWeek); dow >= 0 {
+			dayOfWeek = dow
+		}
+	}
+
+	if week, err := strconv.Atoi(op.WeekOfMonth); err == nil &&
+		(week >= 1 && week <= 5 || week == storage.LastWeekOfMonth) {
+		weekOfMonth = week
+	}
+
+	return dayOfWeek, weekOfMonth
 }
 
-// parseDayOfWeek parses day of week from Russian or English name
+// dayOfWeekStems maps the beginnings of weekday names to time.Weekday numbers. Russian
+// stems cover every case form ("среда", "в среду", "до среды", "по средам") and the longer
+// abbreviations; English ones cover full names, plurals and abbreviations ("tues", "thurs").
+var dayOfWeekStems = []struct {
+	stem string
+	day  int
+}{
+	{"воск", 0}, {"вск", 0},
+	{"пон", 1}, {"пнд", 1},
+	{"вто", 2},
+	{"сред", 3}, {"срд", 3},
+	{"четв", 4}, {"чтв", 4},
+	{"пят", 5}, {"птн", 5},
+	{"суб", 6},
+	{"sun", 0}, {"mon", 1}, {"tue", 2}, {"wed", 3}, {"thu", 4}, {"fri", 5}, {"sat", 6},
+}
+
+// dayOfWeekAbbreviations are the two-letter weekday abbreviations, too short to match by stem
+var dayOfWeekAbbreviations = map[string]int{
+	"вс": 0, "пн": 1, "вт": 2, "ср": 3, "чт": 4, "пт": 5, "сб": 6,
+	"su": 0, "mo": 1, "tu": 2, "we": 3, "th": 4, "fr": 5, "sa": 6,
+}
+
+// dayOfWeekPrefixes are words that may come before a weekday name, e.g. "во вторник" or
+// "каждую пятницу"
+var dayOfWeekPrefixes = []string{"каждый", "каждую", "каждое", "по", "во", "в", "до", "со", "с", "ко", "к", "every", "on"}
+
+// parseDayOfWeek parses a day of week from a Russian or English name in any case form or
+// abbreviation, e.g. "среда", "в среду", "по средам", "ср.", "Wednesdays" or "wed". It
+// returns -1 if the day isn't recognized.
 func parseDayOfWeek(day string) int {
-	day = strings.ToLower(strings.TrimSpace(day))
-
-	// English
-	switch day {
-	case "sunday", "sun":
-		return 0
-	case "monday", "mon":
-		return 1
-	case "tuesday", "tue":
-		return 2
-	case "wednesday", "wed":
-		return 3
-	case "thursday", "thu":
-		return 4
-	case "friday", "fri":
-		return 5
-	case "saturday", "sat":
-		return 6
-	}
-
-	// Russian
-	switch day {
-	case "воскресенье", "вс":
-		return 0
-	case "понедельник", "пн":
-		return 1
-	case "вторник", "вт":
-		return 2
-	case "среда", "ср":
-		return 3
-	case "четверг", "чт":
-		return 4
-	case "пятница", "пт":
-		return 5
-	case "суббота", "сб":
-		return 6
+	day = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(day)), "ё", "е")
+	day = strings.TrimSuffix(day, ".")
+	if words := strings.Fields(day); len(words) == 2 && slices.Contains(dayOfWeekPrefixes, words[0]) {
+		day = words[1]
+	}
+
+	if dow, ok := dayOfWeekAbbreviations[day]; ok {
+		return dow
+	}
+	for _, s := range dayOfWeekStems {
+		if strings.HasPrefix(day, s.stem) {
+			return s.day
+		}
 	}
 
 	return -1
 }
 
+// reminderErrorText explains why a one-time reminder can't be changed. It returns false
+// for errors other than the storage ones about a missing, foreign or delivered reminder.
+func reminderErrorText(err error) (string, bool) {
+	switch {
+	case errors.Is(err, storage.ErrReminderNotFound):
+		return "Напоминание не найдено.", true
+	case errors.Is(err, storage.ErrNotOwned):
+		return "Это напоминание создал другой участник чата – изменить его может только автор.", true
+	case errors.Is(err, storage.ErrReminderDelivered):
+		return "Это напоминание уже сработало. Создайте новое или посмотрите /past.", true
+	}
+	return "", false
+}
+
 // processAdjustOperation processes adjust operation
-func (b *ReminderBot) processAdjustOperation(op llm.Operation, msg *tgbotapi.Message) {
+func (b *ReminderBot) processAdjustOperation(op llm.Operation, msg *tgbotapi.Message, batch *operationBatch) {
+	// Let the user choose when several reminders match
+	if len(op.CandidateIDs) > 1 {
+		b.showReminderPicker(op, msg)
+		return
+	}
+	if op.ReminderID == "" && len(op.CandidateIDs) == 1 {
+		op.ReminderID = op.CandidateIDs[0]
+	}
+
 	// Check if this is a recurring reminder (IDs start with "rec_")
-	if strings.HasPrefix(op.ReminderID, "rec_") {
-		// Extract the numeric ID
-		idStr := strings.TrimPrefix(op.ReminderID, "rec_")
-		reminderID, err := strconv.ParseInt(idStr, 10, 64)
+	if isRecurringReminderID(op.ReminderID) {
+		reminderID, err := parseRecurringReminderID(op.ReminderID)
 		if err != nil {
 			b.logger.Printf("Error parsing recurring reminder ID: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат ID повторяющегося напоминания.")
@@ -271,7 +745,7 @@ func (b *ReminderBot) processAdjustOperation(op llm.Operation, msg *tgbotapi.Mes
 		}
 
 		// Process as recurring reminder adjustment
-		b.processAdjustRecurringOperation(reminderID, op, msg)
+		b.processAdjustRecurringOperation(reminderID, op, msg, batch)
 		return
 	}
 
@@ -284,41 +758,64 @@ func (b *ReminderBot) processAdjustOperation(op llm.Operation, msg *tgbotapi.Mes
 		return
 	}
 
-	var updated bool
-	hasDate := op.Datetime != ""
-	hasLabel := op.Label != ""
+	if op.Datetime == "" && op.TimeDelta == "" && op.Label == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Нет данных для изменения напоминания.")
+		b.bot.Send(reply)
+		return
+	}
 
-	if hasDate && hasLabel {
-		// Update both time and label
-		reminderTime, err := time.Parse("2006-01-02 15:04:05", op.Datetime)
+	ctx := context.Background()
+
+	// A zero time leaves the reminder time unchanged
+	var reminderTime time.Time
+	if op.Datetime != "" {
+		reminderTime, err = b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02 15:04:05", op.Datetime)
 		if err != nil {
 			b.logger.Printf("Error parsing date/time in adjust operation: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат даты/времени в операции изменения.")
 			b.bot.Send(reply)
 			return
 		}
-
-		updated, err = b.repo.UpdateReminder(reminderID, msg.From.ID, reminderTime, op.Label)
-	} else if hasDate {
-		// Update only time
-		reminderTime, err := time.Parse("2006-01-02 15:04:05", op.Datetime)
+		if op.FreeSlot {
+			reminderTime, err = b.freeSlot(ctx, msg.From.ID, reminderTime, 0, reminderID)
+			if errors.Is(err, errNoFreeSlot) {
+				reply := tgbotapi.NewMessage(msg.Chat.ID, noFreeSlotText)
+				b.bot.Send(reply)
+				return
+			}
+			if err != nil {
+				b.logger.Printf("Error finding free slot in adjust operation: %v", err)
+				reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при изменении напоминания.")
+				b.bot.Send(reply)
+				return
+			}
+		}
+	} else if op.TimeDelta != "" {
+		reminderTime, err = b.shiftedReminderTime(ctx, reminderID, msg.From.ID, op.TimeDelta)
+		if text, ok := reminderErrorText(err); ok {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+			b.bot.Send(reply)
+			return
+		}
+		if errors.Is(err, errInvalidOffset) {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, timeDeltaErrorText)
+			b.bot.Send(reply)
+			return
+		}
 		if err != nil {
-			b.logger.Printf("Error parsing date/time in adjust operation: %v", err)
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат даты/времени в операции изменения.")
+			b.logger.Printf("Error shifting reminder time: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при изменении напоминания.")
 			b.bot.Send(reply)
 			return
 		}
+	}
 
-		updated, err = b.repo.UpdateReminderTime(reminderID, msg.From.ID, reminderTime)
-	} else if hasLabel {
-		// Update only label
-		updated, err = b.repo.UpdateReminderLabel(reminderID, msg.From.ID, op.Label)
-	} else {
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "Нет данных для изменения напоминания.")
+	updated, err := b.reminders.Update(ctx, reminderID, msg.From.ID, reminderTime, op.Label)
+	if text, ok := reminderErrorText(err); ok {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 		b.bot.Send(reply)
 		return
 	}
-
 	if err != nil {
 		b.logger.Printf("Error updating reminder: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при изменении напоминания.")
@@ -332,35 +829,66 @@ func (b *ReminderBot) processAdjustOperation(op llm.Operation, msg *tgbotapi.Mes
 		return
 	}
 
-	b.logger.Printf("Updated reminder: ID=%s (chat %d)", op.ReminderID, msg.Chat.ID)
+	b.logger.Info("reminder_updated", "reminder_id", reminderID, "user_id", msg.From.ID, "chat_id", msg.Chat.ID)
 
-	reply := tgbotapi.NewMessage(msg.Chat.ID, op.Answer)
-	b.bot.Send(reply)
+	// The model doesn't know the resulting time of a shift, so state it
+	answer := op.Answer
+	if op.Datetime == "" && op.TimeDelta != "" || op.FreeSlot && !reminderTime.IsZero() {
+		answer += "\nНовое время: " + reminderTime.Format("02.01.2006 15:04")
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, answer)
+	b.confirm(batch, confirmUpdated, op.Label, reply)
+}
+
+// timeDeltaErrorText is sent when the shift of an adjust operation can't be parsed
+const timeDeltaErrorText = "Не понял, на сколько сдвинуть напоминание. Укажи новое время явно."
+
+// shiftedReminderTime computes the time of a pending reminder owned by the user moved
+// by delta, e.g. "-1h". Days are added on the calendar so the wall-clock time survives
+// DST changes.
+func (b *ReminderBot) shiftedReminderTime(ctx context.Context, reminderID, userID int64, delta string) (time.Time, error) {
+	days, duration, err := parseOffset(delta)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	reminder, err := b.reminders.Get(ctx, reminderID)
+	switch {
+	case err != nil:
+		return time.Time{}, err
+	case reminder.UserID != userID:
+		return time.Time{}, storage.ErrNotOwned
+	case reminder.Notified:
+		return time.Time{}, storage.ErrReminderDelivered
+	}
+
+	return reminder.ReminderTime.AddDate(0, 0, days).Add(duration), nil
+}
+
+// shiftClockTime moves a "15:04" time of day by d, wrapping around midnight
+func shiftClockTime(clock string, d time.Duration) (string, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return "", err
+	}
+	return t.Add(d).Format("15:04"), nil
 }
 
 // processAdjustRecurringOperation adjusts a recurring reminder
-func (b *ReminderBot) processAdjustRecurringOperation(reminderID int64, op llm.Operation, msg *tgbotapi.Message) {
+func (b *ReminderBot) processAdjustRecurringOperation(reminderID int64, op llm.Operation, msg *tgbotapi.Message, batch *operationBatch) {
+	ctx := context.Background()
+
 	// Get current reminder
-	reminders, err := b.repo.GetUserRecurringReminders(msg.From.ID)
+	foundReminder, err := b.reminders.GetRecurring(ctx, reminderID, msg.From.ID)
 	if err != nil {
-		b.logger.Printf("Error getting recurring reminders: %v", err)
+		b.logger.Printf("Error getting recurring reminder: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении повторяющихся напоминаний.")
 		b.bot.Send(reply)
 		return
 	}
 
-	// Find the specific reminder
-	var foundReminder storage.RecurringReminder
-	found := false
-	for _, r := range reminders {
-		if r.ID == reminderID {
-			foundReminder = r
-			found = true
-			break
-		}
-	}
-
-	if !found {
+	if foundReminder == nil {
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
 		b.bot.Send(reply)
 		return
@@ -369,7 +897,43 @@ func (b *ReminderBot) processAdjustRecurringOperation(reminderID int64, op llm.O
 	// Update fields if provided
 	timeStr := foundReminder.Time
 	if op.Time != "" {
-		timeStr = op.Time
+		timeStr, err = storage.NormalizeClockTime(op.Time)
+		if err != nil {
+			b.logger.Printf("Invalid time in adjust recurring operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, invalidRecurringTimeText(op.Time))
+			b.bot.Send(reply)
+			return
+		}
+	}
+
+	// A shift moves the time of day and, unless a new window is given, the end of the
+	// event window with it. Solar reminders move their offset from the sun instead.
+	endTime := foundReminder.EndTime
+	solarOffset := foundReminder.SolarOffset
+	if op.TimeDelta != "" && op.Time == "" {
+		days, delta, err := parseOffset(op.TimeDelta)
+		if err == nil && days != 0 {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Регулярное напоминание можно сдвинуть только на часы и минуты. Чтобы сменить день, укажи его явно.")
+			b.bot.Send(reply)
+			return
+		}
+		if err == nil {
+			switch {
+			case foundReminder.SolarEvent != "":
+				solarOffset += int(delta / time.Minute)
+			default:
+				timeStr, err = shiftClockTime(timeStr, delta)
+				if err == nil && endTime != "" {
+					endTime, err = shiftClockTime(endTime, delta)
+				}
+			}
+		}
+		if err != nil {
+			b.logger.Printf("Error shifting recurring reminder time: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, timeDeltaErrorText)
+			b.bot.Send(reply)
+			return
+		}
 	}
 
 	label := foundReminder.Label
@@ -386,13 +950,27 @@ func (b *ReminderBot) processAdjustRecurringOperation(reminderID int64, op llm.O
 			recurringType = storage.RecurringWeekly
 		case "monthly":
 			recurringType = storage.RecurringMonthly
+		case "monthly_weekday":
+			recurringType = storage.RecurringMonthlyWeekday
+		case "hourly":
+			recurringType = storage.RecurringHourly
+		}
+	}
+
+	intervalHours := foundReminder.IntervalHours
+	if op.IntervalHours != 0 && recurringType == storage.RecurringHourly {
+		var ok bool
+		if intervalHours, ok = hourlyInterval(op.IntervalHours); !ok {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, invalidHourlyIntervalText)
+			b.bot.Send(reply)
+			return
 		}
 	}
 
 	dayOfWeek := foundReminder.DayOfWeek
 	if op.DayOfWeek != "" && recurringType == storage.RecurringWeekly {
 		dow, err := strconv.Atoi(op.DayOfWeek)
-		if err == nil && dow >= 0 && dow <= 6 {
+		if err == nil && isWeeklyDay(dow) {
 			dayOfWeek = dow
 		} else {
 			// Try to parse day name
@@ -411,16 +989,59 @@ func (b *ReminderBot) processAdjustRecurringOperation(reminderID int64, op llm.O
 		}
 	}
 
+	weekOfMonth := foundReminder.WeekOfMonth
+	if recurringType == storage.RecurringMonthlyWeekday {
+		dayOfWeek, weekOfMonth = parseWeekOfMonth(op, dayOfWeek, weekOfMonth)
+		if dayOfWeek < 0 || weekOfMonth == 0 {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Не понял, в какой день месяца напоминать. Например: «в первый понедельник месяца».")
+			b.bot.Send(reply)
+			return
+		}
+	}
+
+	item := storage.RecurringReminder{
+		ID:            reminderID,
+		UserID:        msg.From.ID,
+		Label:         label,
+		RecurringType: recurringType,
+		Time:          timeStr,
+		DayOfWeek:     dayOfWeek,
+		DayOfMonth:    dayOfMonth,
+		WeekOfMonth:   weekOfMonth,
+		IntervalHours: intervalHours,
+	}
+
+	// Keep the event window unless a new one is given; it's dropped if it no longer
+	// ends after the start time
+	if op.EndTime != "" || op.EndDatetime != "" {
+		item.EndTime = recurringEndTime(op, timeStr)
+	} else if endTime != "" {
+		item.EndTime = recurringEndTime(llm.Operation{EndTime: endTime}, timeStr)
+	}
+
+	// A new solar event replaces the schedule, a new fixed time drops it
+	switch {
+	case op.SolarEvent != "":
+		if err := b.applySolarSchedule(ctx, op, msg.From.ID, &item); err != nil {
+			b.logger.Printf("Error setting solar schedule in adjust operation: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, solarTimeErrorText(err))
+			b.bot.Send(reply)
+			return
+		}
+	case op.Time == "":
+		item.SolarEvent = foundReminder.SolarEvent
+		item.SolarOffset = solarOffset
+		item.Latitude = foundReminder.Latitude
+		item.Longitude = foundReminder.Longitude
+	}
+	if item.RecurringType == storage.RecurringHourly && (item.SolarEvent != "" || foundReminder.IsTodo) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, hourlyUnsupportedText)
+		b.bot.Send(reply)
+		return
+	}
+
 	// Update the reminder
-	updated, err := b.repo.UpdateRecurringReminder(
-		reminderID,
-		msg.From.ID,
-		label,
-		recurringType,
-		timeStr,
-		dayOfWeek,
-		dayOfMonth,
-	)
+	updated, err := b.reminders.UpdateRecurring(ctx, item)
 
 	if err != nil {
 		b.logger.Printf("Error updating recurring reminder: %v", err)
@@ -443,16 +1064,79 @@ func (b *ReminderBot) processAdjustRecurringOperation(reminderID int64, op llm.O
 	}
 
 	reply := tgbotapi.NewMessage(msg.Chat.ID, answer)
-	b.bot.Send(reply)
+	b.confirm(batch, confirmUpdated, label, reply)
+}
+
+// processSkipOperation skips a single occurrence of a recurring reminder on op.SkipDate
+// ("не напоминай про йогу в этот четверг")
+func (b *ReminderBot) processSkipOperation(op llm.Operation, msg *tgbotapi.Message, batch *operationBatch) {
+	ctx := context.Background()
+
+	reminderID, err := parseRecurringReminderID(op.ReminderID)
+	if !isRecurringReminderID(op.ReminderID) || err != nil {
+		b.logger.Printf("Invalid recurring reminder ID in skip operation: %q", op.ReminderID)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Пропустить можно только один раз регулярного напоминания. Обычное напоминание можно удалить или перенести.")
+		b.bot.Send(reply)
+		return
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", op.SkipDate, b.reminders.Location(ctx, msg.From.ID))
+	if err != nil {
+		b.logger.Printf("Invalid skip date %q: %v", op.SkipDate, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не понял, какой день пропустить. Укажи дату, например «в этот четверг» или «15 марта».")
+		b.bot.Send(reply)
+		return
+	}
+
+	reminder, err := b.reminders.GetRecurring(ctx, reminderID, msg.From.ID)
+	if err != nil || reminder == nil {
+		if err != nil {
+			b.logger.Printf("Error getting recurring reminder: %v", err)
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(reply)
+		return
+	}
+
+	day := fmt.Sprintf("%s, %s", utils.WeekdayToRussian(date.Weekday()), date.Format("02.01"))
+	if date.Before(core.StartOfDay(b.reminders.Now(ctx, msg.From.ID))) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("День %s уже прошёл – пропускать нечего.", date.Format("02.01")))
+		b.bot.Send(reply)
+		return
+	}
+	if !reminder.OccursOn(date) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("«%s» и так не повторяется в %s.", reminder.Label, day))
+		b.bot.Send(reply)
+		return
+	}
+
+	if _, err := b.reminders.SkipRecurringOn(ctx, reminderID, msg.From.ID, date); err != nil {
+		b.logger.Printf("Error skipping recurring reminder: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при пропуске напоминания.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Printf("Skipped recurring reminder ID=%d on %s (user %d)", reminderID, op.SkipDate, msg.From.ID)
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("⏭ Хорошо, в %s не напомню про «%s». Дальше – как обычно.", day, reminder.Label))
+	b.confirm(batch, confirmUpdated, reminder.Label, reply)
 }
 
 // processDeleteOperation processes delete operation
-func (b *ReminderBot) processDeleteOperation(op llm.Operation, msg *tgbotapi.Message) {
+func (b *ReminderBot) processDeleteOperation(op llm.Operation, msg *tgbotapi.Message, batch *operationBatch) {
+	// Let the user choose when several reminders match
+	if len(op.CandidateIDs) > 1 {
+		b.showReminderPicker(op, msg)
+		return
+	}
+	if op.ReminderID == "" && len(op.CandidateIDs) == 1 {
+		op.ReminderID = op.CandidateIDs[0]
+	}
+
 	// Check if this is a recurring reminder
-	if strings.HasPrefix(op.ReminderID, "rec_") {
-		// Extract the numeric ID
-		idStr := strings.TrimPrefix(op.ReminderID, "rec_")
-		reminderID, err := strconv.ParseInt(idStr, 10, 64)
+	if isRecurringReminderID(op.ReminderID) {
+		reminderID, err := parseRecurringReminderID(op.ReminderID)
 		if err != nil {
 			b.logger.Printf("Error parsing recurring reminder ID: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат ID повторяющегося напоминания.")
@@ -460,8 +1144,13 @@ func (b *ReminderBot) processDeleteOperation(op llm.Operation, msg *tgbotapi.Mes
 			return
 		}
 
+		if b.config.ConfirmDeletes {
+			b.confirmDeleteRecurring(reminderID, msg)
+			return
+		}
+
 		// Delete recurring reminder
-		b.processDeleteRecurringOperation(reminderID, msg, op.Answer)
+		b.processDeleteRecurringOperation(reminderID, msg, op.Answer, batch)
 		return
 	}
 
@@ -474,7 +1163,17 @@ func (b *ReminderBot) processDeleteOperation(op llm.Operation, msg *tgbotapi.Mes
 		return
 	}
 
-	deleted, err := b.repo.DeleteReminder(reminderID, msg.From.ID)
+	if b.config.ConfirmDeletes {
+		b.confirmDelete(reminderID, msg)
+		return
+	}
+
+	deleted, err := b.reminders.Delete(context.Background(), reminderID, msg.From.ID)
+	if text, ok := reminderErrorText(err); ok {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
 	if err != nil {
 		b.logger.Printf("Error deleting reminder: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при удалении напоминания.")
@@ -488,8 +1187,68 @@ func (b *ReminderBot) processDeleteOperation(op llm.Operation, msg *tgbotapi.Mes
 		return
 	}
 
-	b.logger.Printf("Deleted reminder: ID=%s (chat %d)", op.ReminderID, msg.Chat.ID)
+	b.logger.Info("reminder_deleted", "reminder_id", reminderID, "user_id", msg.From.ID, "chat_id", msg.Chat.ID)
 
 	reply := tgbotapi.NewMessage(msg.Chat.ID, op.Answer)
+	b.confirm(batch, confirmDeleted, "", reply)
+}
+
+// confirmDelete asks the user to confirm deleting a regular reminder.
+// The actual deletion is done by the "delete_" callback.
+func (b *ReminderBot) confirmDelete(reminderID int64, msg *tgbotapi.Message) {
+	reminder, err := b.reminders.Get(context.Background(), reminderID)
+	switch {
+	case err == nil && reminder.UserID != msg.From.ID:
+		err = storage.ErrNotOwned
+	case err == nil && reminder.Notified:
+		err = storage.ErrReminderDelivered
+	}
+	if text, ok := reminderErrorText(err); ok {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+	if err != nil {
+		b.logger.Printf("Error getting reminder: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при удалении напоминания.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("Удалить напоминание?\n%s – %s",
+		formatReminderTime(*reminder, "02.01.2006 15:04"), reminder.Label)
+	b.sendDeleteConfirmation(msg.Chat.ID, text, fmt.Sprintf("delete_%d", reminderID))
+}
+
+// confirmDeleteRecurring asks the user to confirm deleting a recurring reminder.
+// The actual deletion is done by the "delete_rec_" callback.
+func (b *ReminderBot) confirmDeleteRecurring(reminderID int64, msg *tgbotapi.Message) {
+	reminder, err := b.reminders.GetRecurring(context.Background(), reminderID, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting recurring reminder: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при удалении повторяющегося напоминания.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if reminder == nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("Удалить регулярное напоминание?\n%s – %s", describeRecurrence(*reminder), reminder.Label)
+	b.sendDeleteConfirmation(msg.Chat.ID, text, fmt.Sprintf("delete_rec_%d", reminderID))
+}
+
+// sendDeleteConfirmation sends a message with confirm/cancel buttons
+func (b *ReminderBot) sendDeleteConfirmation(chatID int64, text, deleteCallback string) {
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Подтвердить удаление", deleteCallback),
+			tgbotapi.NewInlineKeyboardButtonData("Отмена", "cancel_delete"),
+		),
+	)
 	b.bot.Send(reply)
 }
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"reminders21/storage"
 	"sort"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
 	"reminders21/llm"
 	"reminders21/utils"
 )
@@ -45,6 +47,13 @@ import (
 // processShowListOperation processes show_list operation
 // processShowListOperation processes show_list operation
 func (b *ReminderBot) processShowListOperation(op llm.Operation, msg *tgbotapi.Message) {
+	ctx := context.Background()
+
+	// Another member's reminders are only known in a group chat
+	if op.CreatedBy != "" && !msg.Chat.IsPrivate() {
+		b.sendChatList(ctx, msg, op.CreatedBy)
+		return
+	}
 	var reminders []storage.ReminderItem
 	var err error
 	var title string
@@ -52,7 +61,7 @@ func (b *ReminderBot) processShowListOperation(op llm.Operation, msg *tgbotapi.M
 	var start, end time.Time
 	if op.StartDate != "" {
 		// Show reminders for a specific period
-		start, err = time.Parse("2006-01-02", op.StartDate)
+		start, err = b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02", op.StartDate)
 		if err != nil {
 			b.logger.Printf("Error parsing start date: %v", err)
 			reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат даты начала.")
@@ -62,30 +71,30 @@ func (b *ReminderBot) processShowListOperation(op llm.Operation, msg *tgbotapi.M
 
 		if op.EndDate != "" {
 			// Period with end date
-			endParsed, err := time.Parse("2006-01-02", op.EndDate)
+			endParsed, err := b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02", op.EndDate)
 			if err != nil {
 				b.logger.Printf("Error parsing end date: %v", err)
 				reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат даты конца.")
 				b.bot.Send(reply)
 				return
 			}
-			end = endParsed.Add(24 * time.Hour)
+			end = endParsed.AddDate(0, 0, 1)
 
 			if op.EndDate != op.StartDate {
 				title = fmt.Sprintf("Список с %s по %s", start.Format("02.01.2006"), endParsed.Format("02.01.2006"))
 			} else {
-				title = formatDayTitle(start)
+				title = formatDayTitle(start, b.reminders.Now(ctx, msg.From.ID))
 			}
 		} else {
 			// Single day
-			end = start.Add(24 * time.Hour)
-			title = formatDayTitle(start)
+			end = start.AddDate(0, 0, 1)
+			title = formatDayTitle(start, b.reminders.Now(ctx, msg.From.ID))
 		}
 
-		reminders, err = b.repo.GetUserRemindersByPeriod(msg.From.ID, start, end)
+		reminders, err = b.reminders.ListBetween(ctx, msg.From.ID, start, end)
 	} else {
 		// Show all reminders
-		reminders, err = b.repo.GetUserReminders(msg.From.ID)
+		reminders, err = b.reminders.List(ctx, msg.From.ID)
 		title = "Все активные напоминания"
 	}
 
@@ -116,46 +125,28 @@ func (b *ReminderBot) processShowListOperation(op llm.Operation, msg *tgbotapi.M
 
 	if op.StartDate != "" && op.EndDate == "" {
 		// Format for single day (only time)
-		for _, r := range reminders {
-			if r.IsTodo {
-				lines = append(lines, fmt.Sprintf("☐ %s", r.Label))
-			} else {
-				lines = append(lines, fmt.Sprintf("%s – %s", r.ReminderTime.Format("15:04"), r.Label))
-			}
-		}
-
-		// Add recurring reminders
-		for _, r := range recurringEvents {
-			if r.IsTodo {
-				lines = append(lines, fmt.Sprintf("☐ %s (регулярное)", r.Label))
-			} else {
-				lines = append(lines, fmt.Sprintf("%s – %s (регулярное)", r.Time, r.Label))
-			}
-		}
-
-		// Sort lines differently based on todo vs reminder
-		sortLinesByTimeWithTodos(lines)
+		lines = formatDayLines(reminders, recurringEvents)
 	} else {
 		// Format with date and time
+		var entries []listEntry
 		for _, r := range reminders {
+			line := fmt.Sprintf("%s %s – %s", categoryIcon(r.Category), formatReminderTime(r, "02.01.2006 15:04"), r.Label)
 			if r.IsTodo {
-				lines = append(lines, fmt.Sprintf("%s ☐ %s", r.ReminderTime.Format("02.01.2006"), r.Label))
-			} else {
-				lines = append(lines, fmt.Sprintf("%s – %s", r.ReminderTime.Format("02.01.2006 15:04"), r.Label))
+				line = fmt.Sprintf("%s ☐ %s", r.ReminderTime.Format("02.01.2006"), r.Label)
 			}
+			entries = append(entries, listEntry{at: r.ReminderTime, isTodo: r.IsTodo, line: line})
 		}
 
 		// Add recurring reminders with date
 		for _, r := range recurringEvents {
+			line := fmt.Sprintf("%s %s %s – %s (регулярное)", categoryIcon(r.Category), r.Date.Format("02.01.2006"), r.Time, r.Label)
 			if r.IsTodo {
-				lines = append(lines, fmt.Sprintf("%s ☐ %s (регулярное)", r.Date.Format("02.01.2006"), r.Label))
-			} else {
-				lines = append(lines, fmt.Sprintf("%s %s – %s (регулярное)", r.Date.Format("02.01.2006"), r.Time, r.Label))
+				line = fmt.Sprintf("%s ☐ %s (регулярное)", r.Date.Format("02.01.2006"), r.Label)
 			}
+			entries = append(entries, listEntry{at: r.At, isTodo: r.IsTodo, line: line})
 		}
 
-		// Sort lines by date and time
-		sortLinesByDateTimeWithTodos(lines)
+		lines = sortedLines(entries)
 	}
 
 	text := title + ":\n" + strings.Join(lines, "\n")
@@ -163,127 +154,127 @@ func (b *ReminderBot) processShowListOperation(op llm.Operation, msg *tgbotapi.M
 	b.bot.Send(reply)
 }
 
-// sortLinesByTimeWithTodos sorts reminder lines with todos first
-func sortLinesByTimeWithTodos(lines []string) {
-	// First separate todos and timed reminders
-	var todos []string
-	var reminders []string
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "☐") {
-			todos = append(todos, line)
-		} else {
-			reminders = append(reminders, line)
-		}
+// formatReminderTime formats a reminder's time with layout, adding the end of its event window
+// if it has one, e.g. "10:00–11:00"
+func formatReminderTime(r storage.ReminderItem, layout string) string {
+	text := r.ReminderTime.Format(layout)
+	if r.EndTime.IsZero() {
+		return text
 	}
 
-	// Sort reminders by time
-	sort.Slice(reminders, func(i, j int) bool {
-		timeI := extractTimeFromLine(reminders[i])
-		timeJ := extractTimeFromLine(reminders[j])
-		return timeI < timeJ
-	})
-
-	// Combine todos and reminders, with todos first
-	copy(lines, append(todos, reminders...))
+	// Only the time is needed if the event ends on the same day
+	if core.StartOfDay(r.EndTime).Equal(core.StartOfDay(r.ReminderTime)) {
+		return text + "–" + r.EndTime.Format("15:04")
+	}
+	return text + "–" + r.EndTime.Format(layout)
 }
 
-// sortLinesByDateTimeWithTodos sorts reminder lines by date and time, with todos first per day
-func sortLinesByDateTimeWithTodos(lines []string) {
-	// Group by date
-	dateGroups := make(map[string][]string)
-
-	for _, line := range lines {
-		// Extract date portion (first 10 characters in format "02.01.2006")
-		datePart := line[:10]
-		dateGroups[datePart] = append(dateGroups[datePart], line)
+// formatDayLines formats a single day's reminders and recurring events (time only),
+// sorted by time with todos first. Reminders start with their category's icon.
+func formatDayLines(reminders []storage.ReminderItem, recurringEvents []RecurringEvent) []string {
+	var entries []listEntry
+	for _, r := range reminders {
+		line := fmt.Sprintf("%s %s – %s", categoryIcon(r.Category), formatReminderTime(r, "15:04"), r.Label)
+		if r.IsTodo {
+			line = fmt.Sprintf("☐ %s", r.Label)
+		}
+		entries = append(entries, listEntry{at: r.ReminderTime, isTodo: r.IsTodo, line: line})
 	}
 
-	// Sort each date group internally
-	for date, group := range dateGroups {
-		var todos []string
-		var reminders []string
-
-		for _, line := range group {
-			if strings.Contains(line, "☐") {
-				todos = append(todos, line)
-			} else {
-				reminders = append(reminders, line)
-			}
+	// Add recurring reminders
+	for _, r := range recurringEvents {
+		line := fmt.Sprintf("%s %s – %s (регулярное)", categoryIcon(r.Category), r.Time, r.Label)
+		if r.IsTodo {
+			line = fmt.Sprintf("☐ %s (регулярное)", r.Label)
 		}
-
-		// Sort reminders by time
-		sort.Slice(reminders, func(i, j int) bool {
-			// Extract time part (after the date)
-			timeI := reminders[i][11:]
-			timeJ := reminders[j][11:]
-			return timeI < timeJ
-		})
-
-		// Replace the group with sorted items, todos first
-		dateGroups[date] = append(todos, reminders...)
+		entries = append(entries, listEntry{at: r.At, isTodo: r.IsTodo, line: line})
 	}
 
-	// Get sorted dates
-	var dates []string
-	for date := range dateGroups {
-		dates = append(dates, date)
-	}
-	sort.Strings(dates)
+	return sortedLines(entries)
+}
 
-	// Rebuild the sorted list
-	var result []string
-	for _, date := range dates {
-		result = append(result, dateGroups[date]...)
-	}
+// listEntry is a formatted one-time reminder or recurring occurrence in a list
+type listEntry struct {
+	at     time.Time // Start of the reminder; only the day matters for todos
+	isTodo bool
+	line   string
+}
 
-	// Replace the original lines with the sorted result
-	for i := range lines {
-		if i < len(result) {
-			lines[i] = result[i]
+// sortedLines sorts entries by day and time, with todos first per day, and returns their lines
+func sortedLines(entries []listEntry) []string {
+	sort.SliceStable(entries, func(i, j int) bool {
+		dayI, dayJ := core.StartOfDay(entries[i].at), core.StartOfDay(entries[j].at)
+		if !dayI.Equal(dayJ) {
+			return dayI.Before(dayJ)
 		}
+		if entries[i].isTodo != entries[j].isTodo {
+			return entries[i].isTodo
+		}
+		return entries[i].at.Before(entries[j].at)
+	})
+
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = entry.line
 	}
+	return lines
 }
 
 // RecurringEvent represents a recurring reminder occurrence on a specific date
 type RecurringEvent struct {
-	ID     int64
-	Label  string
-	Time   string
-	Date   time.Time
-	IsTodo bool
+	ID       int64
+	Label    string
+	Time     string // Time of day as shown, e.g. "10:00" or "10:00–11:00"
+	Date     time.Time
+	At       time.Time // Start of the occurrence, for sorting
+	End      time.Time // End of the occurrence's event window, zero if it has none
+	IsTodo   bool
+	Category string
 }
 
-// getApplicableRecurringReminders retrieves recurring reminders applicable within a date range
+// getApplicableRecurringReminders retrieves the occurrences of recurring reminders and todos
+// within a date range
 func (b *ReminderBot) getApplicableRecurringReminders(userID int64, start, end time.Time) ([]RecurringEvent, error) {
-	recurringReminders, err := b.repo.GetUserRecurringReminders(userID)
+	recurringReminders, err := b.reminders.ListRecurring(context.Background(), userID)
 	if err != nil {
 		return nil, err
 	}
 
 	var events []RecurringEvent
-	for currentDate := start; currentDate.Before(end); currentDate = currentDate.Add(24 * time.Hour) {
-		dayOfWeek := int(currentDate.Weekday())
-		dayOfMonth := currentDate.Day()
-
+	for currentDate := start; currentDate.Before(end); currentDate = currentDate.AddDate(0, 0, 1) {
 		for _, reminder := range recurringReminders {
-			applicable := false
+			if reminder.Enabled && reminder.OccursOn(currentDate) && !reminder.Skips(currentDate) {
+				// Reminders following the sun occur at a different time each day
+				start, ok := reminder.TimeOn(currentDate)
+				if !ok {
+					continue
+				}
+				at := reminder.Time
+				if reminder.EndTime != "" {
+					at += "–" + reminder.EndTime
+				}
+				if reminder.SolarEvent != "" {
+					at = start.Format("15:04")
+				}
+				// An hourly reminder's window is when it repeats, not a busy event
+				if reminder.RecurringType == storage.RecurringHourly {
+					at += " " + hourlyIntervalText(reminder.IntervalHours)
+				}
+				var until time.Time
+				if t, err := time.Parse("15:04", reminder.EndTime); err == nil && reminder.SolarEvent == "" &&
+					reminder.RecurringType != storage.RecurringHourly {
+					until = time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), t.Hour(), t.Minute(), 0, 0, currentDate.Location())
+				}
 
-			switch reminder.RecurringType {
-			case storage.RecurringDaily:
-				applicable = true
-			case storage.RecurringWeekly:
-				applicable = reminder.DayOfWeek == dayOfWeek
-			case storage.RecurringMonthly:
-				applicable = reminder.DayOfMonth == dayOfMonth
-			}
-
-			if applicable {
 				events = append(events, RecurringEvent{
-					ID:    reminder.ID,
-					Label: reminder.Label,
-					Time:  reminder.Time,
-					Date:  currentDate,
+					ID:       reminder.ID,
+					Label:    reminder.Label,
+					Time:     at,
+					Date:     currentDate,
+					At:       start,
+					End:      until,
+					IsTodo:   reminder.IsTodo,
+					Category: reminder.Category,
 				})
 			}
 		}
@@ -292,53 +283,16 @@ func (b *ReminderBot) getApplicableRecurringReminders(userID int64, start, end t
 	return events, nil
 }
 
-// sortLinesByTime sorts reminder lines by time
-func sortLinesByTime(lines []string) {
-	sort.Slice(lines, func(i, j int) bool {
-		timeI := extractTimeFromLine(lines[i])
-		timeJ := extractTimeFromLine(lines[j])
-		return timeI < timeJ
-	})
-}
-
-// sortLinesByDateTime sorts reminder lines by date and time
-func sortLinesByDateTime(lines []string) {
-	sort.Slice(lines, func(i, j int) bool {
-		dateTimeI := extractDateTimeFromLine(lines[i])
-		dateTimeJ := extractDateTimeFromLine(lines[j])
-		return dateTimeI < dateTimeJ
-	})
-}
-
-// extractTimeFromLine extracts time from a line in format "15:04 – Label"
-func extractTimeFromLine(line string) string {
-	parts := strings.Split(line, " – ")
-	if len(parts) < 2 {
-		return ""
-	}
-	return parts[0]
-}
-
-// extractDateTimeFromLine extracts date and time from a line in format "02.01.2006 15:04 – Label"
-func extractDateTimeFromLine(line string) string {
-	parts := strings.Split(line, " – ")
-	if len(parts) < 2 {
-		return ""
-	}
-	return parts[0]
-}
-
-// formatDayTitle formats a title for day list
-func formatDayTitle(date time.Time) string {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	tomorrow := today.Add(24 * time.Hour)
-
-	if date.Equal(today) {
+// formatDayTitle formats a title for the list of date's reminders. now is the user's
+// current local time; "сегодня" and "завтра" are decided in its location.
+func formatDayTitle(date, now time.Time) string {
+	switch utils.CalendarDaysBetween(now, date) {
+	case 0:
 		return "Напоминания на сегодня"
-	} else if date.Equal(tomorrow) {
+	case 1:
 		return "Напоминания на завтра"
-	} else {
+	default:
+		date = date.In(now.Location())
 		weekdayName := utils.WeekdayToRussian(date.Weekday())
 		return fmt.Sprintf("Напоминания на %s, %s", weekdayName, date.Format("02.01.2006"))
 	}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"reminders21/core"
+	"reminders21/logging"
+	"reminders21/storage"
+)
+
+func TestFormatDayTitle(t *testing.T) {
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 23:50 on Saturday 8 March in Vladivostok is 13:50 UTC
+	now := time.Date(2025, 3, 8, 23, 50, 0, 0, vladivostok)
+
+	tests := []struct {
+		name string
+		date time.Time
+		now  time.Time
+		want string
+	}{
+		{"today", time.Date(2025, 3, 8, 9, 0, 0, 0, vladivostok), now, "Напоминания на сегодня"},
+		{"today in UTC", time.Date(2025, 3, 8, 13, 55, 0, 0, time.UTC), now, "Напоминания на сегодня"},
+		{"tomorrow after midnight", time.Date(2025, 3, 8, 14, 10, 0, 0, time.UTC), now, "Напоминания на завтра"},
+		{"tomorrow", time.Date(2025, 3, 9, 20, 0, 0, 0, vladivostok), now, "Напоминания на завтра"},
+		{"other day", time.Date(2025, 3, 11, 0, 0, 0, 0, vladivostok), now, "Напоминания на вторник, 11.03.2025"},
+		{"other day in UTC", time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC), now, "Напоминания на вторник, 11.03.2025"},
+		{"yesterday", time.Date(2025, 3, 7, 23, 59, 0, 0, vladivostok), now, "Напоминания на пятницу, 07.03.2025"},
+		// Clocks go forward in New York at 02:00 on 9 March, so that day has 23 hours
+		{"tomorrow across DST", time.Date(2025, 3, 9, 23, 30, 0, 0, newYork),
+			time.Date(2025, 3, 8, 23, 30, 0, 0, newYork), "Напоминания на завтра"},
+		{"day after across DST", time.Date(2025, 3, 10, 0, 30, 0, 0, newYork),
+			time.Date(2025, 3, 8, 23, 30, 0, 0, newYork), "Напоминания на понедельник, 10.03.2025"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDayTitle(tt.date, tt.now); got != tt.want {
+				t.Errorf("formatDayTitle(%v, %v) = %q, want %q", tt.date, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestBot creates a bot backed by a fresh database, without a Telegram connection
+func newTestBot(t *testing.T) (*ReminderBot, *storage.ReminderRepository) {
+	t.Helper()
+	logger := logging.New(io.Discard, "", logging.FormatPlain, false)
+	repo, err := storage.NewReminderRepository(filepath.Join(t.TempDir(), "reminders.db"), "UTC", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return &ReminderBot{repo: repo, reminders: core.NewService(repo, logger), logger: logger}, repo
+}
+
+func TestRecurringTodosInPeriodLists(t *testing.T) {
+	b, repo := newTestBot(t)
+	ctx := context.Background()
+	const userID = 42
+
+	add := func(label string, recurringType storage.RecurringType, at string, dayOfWeek int, isTodo bool) {
+		t.Helper()
+		if _, err := repo.AddRecurringReminderContext(ctx, userID, userID, label, recurringType, at, "",
+			dayOfWeek, -1, 0, isTodo, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("полить цветы", storage.RecurringDaily, "00:00", -1, true)
+	add("вынести мусор", storage.RecurringWeekly, "00:00", int(time.Wednesday), true)
+	add("зарядка", storage.RecurringDaily, "08:30", -1, false)
+
+	// Monday 10 March to Thursday 13 March
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	events, err := b.getApplicableRecurringReminders(userID, start, start.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos := map[string][]int{}
+	for _, e := range events {
+		if e.IsTodo {
+			todos[e.Label] = append(todos[e.Label], e.Date.Day())
+		}
+	}
+	want := map[string][]int{"полить цветы": {10, 11, 12}, "вынести мусор": {12}}
+	if !reflect.DeepEqual(todos, want) {
+		t.Errorf("recurring todos by day = %v, want %v", todos, want)
+	}
+
+	// A day's list shows the todos first, then the timed reminders
+	var wednesday []RecurringEvent
+	for _, e := range events {
+		if e.Date.Day() == 12 {
+			wednesday = append(wednesday, e)
+		}
+	}
+	lines := formatDayLines(nil, wednesday)
+	if len(lines) != 3 || lines[2] != "🔔 08:30 – зарядка (регулярное)" ||
+		!slices.Contains(lines[:2], "☐ полить цветы (регулярное)") ||
+		!slices.Contains(lines[:2], "☐ вынести мусор (регулярное)") {
+		t.Errorf("formatDayLines() = %q, want both todos before 08:30 – зарядка", lines)
+	}
+}
+
+func TestSortedLines(t *testing.T) {
+	day := func(d, hour, minute int) time.Time {
+		return time.Date(2025, 3, d, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		entries []listEntry
+		want    []string
+	}{
+		{
+			name: "todos only",
+			entries: []listEntry{
+				{at: day(11, 0, 0), isTodo: true, line: "☐ b"},
+				{at: day(10, 0, 0), isTodo: true, line: "☐ a"},
+				{at: day(11, 0, 0), isTodo: true, line: "☐ c"},
+			},
+			want: []string{"☐ a", "☐ b", "☐ c"},
+		},
+		{
+			name: "mixed short lines",
+			entries: []listEntry{
+				{at: day(10, 18, 0), line: "18:00 – x"},
+				{at: day(10, 0, 0), isTodo: true, line: "☐ y"},
+				{at: day(10, 9, 5), line: "09:05 – z"},
+				{at: day(11, 7, 0), line: "07:00 – w"},
+				{at: day(11, 0, 0), isTodo: true, line: "☐ v"},
+			},
+			want: []string{"☐ y", "09:05 – z", "18:00 – x", "☐ v", "07:00 – w"},
+		},
+		{
+			name: "same time keeps order",
+			entries: []listEntry{
+				{at: day(10, 9, 0), line: "09:00 – first"},
+				{at: day(10, 9, 0), line: "09:00 – second"},
+			},
+			want: []string{"09:00 – first", "09:00 – second"},
+		},
+		{
+			name:    "empty",
+			entries: nil,
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortedLines(tt.entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortedLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package bot
+
+import "testing"
+
+func TestParseDayOfWeek(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		// Full names
+		{"понедельник", 1}, {"вторник", 2}, {"среда", 3}, {"четверг", 4},
+		{"пятница", 5}, {"суббота", 6}, {"воскресенье", 0}, {"Воскресенье", 0},
+		// Accusative after "в"/"во"
+		{"в понедельник", 1}, {"во вторник", 2}, {"в среду", 3}, {"в четверг", 4},
+		{"в пятницу", 5}, {"в субботу", 6}, {"в воскресенье", 0},
+		// Genitive
+		{"понедельника", 1}, {"среды", 3}, {"пятницы", 5}, {"субботы", 6}, {"до среды", 3}, {"с пятницы", 5},
+		// Dative plural
+		{"по понедельникам", 1}, {"по средам", 3}, {"по воскресеньям", 0}, {"каждую пятницу", 5},
+		// Abbreviations
+		{"пн", 1}, {"в пн", 1}, {"вт", 2}, {"во вт", 2}, {"ср", 3}, {"ср.", 3}, {"в чт", 4},
+		{"пт", 5}, {"сб", 6}, {"вс", 0}, {"в вс", 0}, {"пнд", 1}, {"птн", 5}, {"вск", 0},
+		// English
+		{"Wednesday", 3}, {"wednesdays", 3}, {"on wed", 3}, {"mo", 1}, {"sun", 0},
+		// Unknown
+		{"", -1}, {"завтра", -1}, {"в", -1}, {"xyz", -1},
+	}
+
+	for _, tt := range tests {
+		if got := parseDayOfWeek(tt.in); got != tt.want {
+			t.Errorf("parseDayOfWeek(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/storage"
+	"reminders21/utils"
+)
+
+// sourceTextLength limits the stored phrasing a reminder was created from
+const sourceTextLength = 200
+
+// reminderOrigin describes when and how a reminder was created, e.g.
+// "🕓 Создано 3 дня назад: «напомни купить подарок 5 марта»", if the user turned it on with /origin
+func (b *ReminderBot) reminderOrigin(ctx context.Context, r storage.ReminderItem) string {
+	if r.CreatedAt.IsZero() {
+		return ""
+	}
+
+	show, err := b.repo.GetUserShowOriginContext(ctx, r.UserID)
+	if err != nil {
+		b.logger.Printf("Error getting origin setting of user %d: %v", r.UserID, err)
+	}
+	if !show {
+		return ""
+	}
+
+	origin := "🕓 Создано " + formatAge(time.Since(r.CreatedAt))
+	if r.SourceText != "" {
+		origin += ": «" + escapeText(reminderParseMode, r.SourceText) + "»"
+	}
+	return origin
+}
+
+// formatAge formats how long ago something happened, e.g. "3 дня назад"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "только что"
+	case d < time.Hour:
+		n := int(d / time.Minute)
+		return fmt.Sprintf("%d %s назад", n, utils.PluralRussian(n, "минуту", "минуты", "минут"))
+	case d < 24*time.Hour:
+		n := int(d / time.Hour)
+		return fmt.Sprintf("%d %s назад", n, utils.PluralRussian(n, "час", "часа", "часов"))
+	default:
+		n := int(d / (24 * time.Hour))
+		return fmt.Sprintf("%d %s назад", n, utils.PluralRussian(n, "день", "дня", "дней"))
+	}
+}
+
+// handleOriginCommand turns mentioning when and how a reminder was created on or off:
+// "/origin on", "/origin off"
+func (b *ReminderBot) handleOriginCommand(ctx context.Context, msg *tgbotapi.Message) {
+	var show bool
+	switch strings.ToLower(strings.TrimSpace(msg.CommandArguments())) {
+	case "on", "вкл":
+		show = true
+	case "off", "выкл":
+		show = false
+	default:
+		current, err := b.repo.GetUserShowOriginContext(ctx, msg.From.ID)
+		if err != nil {
+			b.logger.Printf("Error getting origin setting of user %d: %v", msg.From.ID, err)
+		}
+		text := "Сейчас в напоминаниях не видно, когда они созданы.\n\nЧтобы добавлять «🕓 Создано 3 дня назад» и исходную фразу: /origin on"
+		if current {
+			text = "Напоминания приходят с пометкой, когда и как они созданы.\n\nВыключить: /origin off"
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	if err := b.repo.SetUserShowOriginContext(ctx, msg.From.ID, show); err != nil {
+		b.logger.Printf("Error setting origin setting: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := "Готово! Напоминания будут приходить с пометкой, когда и как они созданы."
+	if !show {
+		text = "Готово! Напоминания будут приходить без пометки о создании."
+	}
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/storage"
+)
+
+// maxOutboxBackoff is the longest wait between attempts to send a message that keeps
+// failing; the wait starts at OutboxRetryDelay and doubles
+const maxOutboxBackoff = time.Hour
+
+// outboxMessage builds the queued message for a due one-time reminder
+func (b *ReminderBot) outboxMessage(ctx context.Context, r storage.ReminderItem) (storage.OutboxMessage, error) {
+	keyboard := b.snoozeKeyboard(ctx, r.UserID, r.ID)
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, ackRow())
+	markup, err := json.Marshal(keyboard)
+	if err != nil {
+		return storage.OutboxMessage{}, err
+	}
+
+	text := b.deliveryPrefix(ctx, r.UserID) + reminderText(r)
+	if b.config.MissedAfter > 0 && time.Since(r.ReminderTime) > b.config.MissedAfter {
+		// Reminders this late were missed, e.g. while the bot was down
+		at := r.ReminderTime.In(b.reminders.Location(ctx, r.UserID))
+		text += "\n\n⌛ (просрочено) Должно было прийти " + at.Format("02.01 в 15:04") + "."
+	}
+	if origin := b.reminderOrigin(ctx, r); origin != "" {
+		text += "\n\n" + origin
+	}
+
+	return storage.OutboxMessage{
+		ChatID:      r.ChatID,
+		UserID:      r.UserID,
+		ReminderID:  r.ID,
+		Label:       r.Label,
+		Text:        text,
+		PhotoFileID: r.PhotoFileID,
+		ReplyMarkup: string(markup),
+		Silent:      r.Silent,
+	}, nil
+}
+
+// outboxChattable converts a queued message to the Telegram message to send
+func outboxChattable(m storage.OutboxMessage) (tgbotapi.Chattable, error) {
+	var keyboard interface{}
+	if m.ReplyMarkup != "" {
+		var markup tgbotapi.InlineKeyboardMarkup
+		if err := json.Unmarshal([]byte(m.ReplyMarkup), &markup); err != nil {
+			return nil, err
+		}
+		keyboard = markup
+	}
+
+	if m.PhotoFileID != "" {
+		photo := tgbotapi.NewPhoto(m.ChatID, tgbotapi.FileID(m.PhotoFileID))
+		photo.Caption = truncateText(m.Text, photoCaptionLength)
+		photo.ParseMode = reminderParseMode
+		photo.ReplyMarkup = keyboard
+		photo.DisableNotification = m.Silent
+		return photo, nil
+	}
+
+	text := tgbotapi.NewMessage(m.ChatID, m.Text)
+	text.ParseMode = reminderParseMode
+	text.ReplyMarkup = keyboard
+	text.DisableNotification = m.Silent
+	return text, nil
+}
+
+// flushOutbox sends up to DueBatchSize queued reminder messages, oldest first, and
+// removes each from the outbox once Telegram accepts it. Messages that fail to send
+// stay queued and are retried with backoff unless Telegram rejected them for good.
+func (b *ReminderBot) flushOutbox() {
+	b.outboxLock.Lock()
+	defer b.outboxLock.Unlock()
+
+	ctx := context.Background()
+	if time.Now().Before(b.sendPausedUntil) {
+		return
+	}
+
+	messages, err := b.repo.GetOutboxContext(ctx, b.config.DueBatchSize)
+	if err != nil {
+		b.logger.Printf("Error getting outbox: %v", err)
+		return
+	}
+
+send:
+	for i, m := range messages {
+		// Throttle sends within a batch
+		if i > 0 && b.config.SendInterval > 0 {
+			select {
+			case <-b.stopChan:
+				break send
+			case <-time.After(b.config.SendInterval):
+			}
+		}
+
+		msg, err := outboxChattable(m)
+		if err != nil {
+			b.logger.Printf("Dropping malformed outbox message %d: %v", m.ID, err)
+			b.deleteOutbox(ctx, m.ID)
+			continue
+		}
+
+		sent, err := b.bot.Send(msg)
+		if err != nil {
+			if delay, ok := retryAfter(err); ok {
+				// The rest of the batch would be rejected too; unsent messages stay queued
+				if delay == 0 {
+					delay = b.config.SendRetryDelay
+				}
+				b.sendPausedUntil = time.Now().Add(delay)
+				b.logger.Printf("Rate limited by Telegram, pausing reminder delivery for %s", delay)
+				break
+			}
+			if rejected(err) {
+				b.logger.Printf("Dropping reminder %d/%d for chat %d rejected by Telegram: %v", m.ReminderID, m.RecurringID, m.ChatID, err)
+				b.deleteOutbox(ctx, m.ID)
+				continue
+			}
+			b.logger.Error("reminder_send_failed", "reminder_id", m.ReminderID, "recurring_id", m.RecurringID,
+				"user_id", m.UserID, "chat_id", m.ChatID, "attempt", m.Attempts+1, "error", err)
+			b.retryOutbox(ctx, m)
+			continue
+		}
+
+		b.deleteOutbox(ctx, m.ID)
+		if m.RecurringID > 0 {
+			b.logger.Info("recurring_reminder_sent", "reminder_id", m.RecurringID, "user_id", m.UserID, "chat_id", m.ChatID)
+		} else {
+			b.logger.Info("reminder_sent", "reminder_id", m.ReminderID, "user_id", m.UserID, "chat_id", m.ChatID)
+		}
+		b.recordDelivery(ctx, sent, m.UserID, m.ReminderID, m.RecurringID, m.Label)
+	}
+}
+
+// retryOutbox schedules the next attempt to send a message that failed, so failing
+// messages don't starve newer ones. Messages are never dropped for transient errors:
+// the reminder is already marked as notified and this is its only copy.
+func (b *ReminderBot) retryOutbox(ctx context.Context, m storage.OutboxMessage) {
+	delay := b.config.OutboxRetryDelay
+	for i := 0; i < m.Attempts && delay < maxOutboxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxOutboxBackoff)
+	if err := b.repo.DeferOutboxContext(ctx, m.ID, time.Now().Add(delay)); err != nil {
+		b.logger.Printf("Error deferring outbox message %d: %v", m.ID, err)
+	}
+}
+
+// deleteOutbox removes a sent or dropped message from the outbox
+func (b *ReminderBot) deleteOutbox(ctx context.Context, id int64) {
+	if err := b.repo.DeleteOutboxContext(ctx, id); err != nil {
+		b.logger.Printf("Error deleting outbox message %d: %v", id, err)
+	}
+}
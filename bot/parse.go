@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"reminders21/llm"
+)
+
+// maxParseReplyLength keeps the /parse reply within Telegram's 4096-character message limit
+const maxParseReplyLength = 4000
+
+// handleParseCommand runs a message through the LLM like a regular message but only
+// replies with the resulting operations and the absolute times they resolve to,
+// to diagnose misparses: "/parse напомни завтра утром позвонить маме".
+// It's available to admins, or to everyone in debug mode.
+func (b *ReminderBot) handleParseCommand(ctx context.Context, msg *tgbotapi.Message) {
+	if !b.config.IsAdmin(msg.From.ID) && !b.config.Debug {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная команда. Используйте /help для справки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	input := strings.TrimSpace(msg.CommandArguments())
+	if input == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /parse <текст сообщения>")
+		b.bot.Send(reply)
+		return
+	}
+
+	allReminders, err := b.llmReminders(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting user reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Произошла ошибка при обработке запроса.")
+		b.bot.Send(reply)
+		return
+	}
+
+	now := b.reminders.Now(ctx, msg.From.ID)
+	prepared, prompt := b.prepareInput(ctx, msg.From.ID, input)
+	output, err := b.llmClient.ParseMessage(ctx, now, prompt, prepared, allReminders)
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка LLM: %v", err))
+		b.bot.Send(reply)
+		return
+	}
+
+	operations, _ := json.MarshalIndent(output.Operations, "", "  ")
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Сейчас: %s\n", now.Format("2006-01-02 15:04:05 MST"))
+	if prepared != input {
+		fmt.Fprintf(&text, "Текст для LLM: %s\n", prepared)
+	}
+	for i, op := range output.Operations {
+		for _, line := range b.resolvedTimes(ctx, msg, op) {
+			fmt.Fprintf(&text, "#%d %s\n", i+1, line)
+		}
+	}
+	text.WriteString("\n" + string(operations))
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, truncateText(text.String(), maxParseReplyLength))
+	b.bot.Send(reply)
+}
+
+// resolvedTimes describes the absolute times an operation's times resolve to, in the
+// user's timezone and UTC, the same way executing the operation would resolve them
+func (b *ReminderBot) resolvedTimes(ctx context.Context, msg *tgbotapi.Message, op llm.Operation) []string {
+	format := func(name string, at time.Time, err error) string {
+		if err != nil {
+			return fmt.Sprintf("%s: ошибка: %v", name, err)
+		}
+		return fmt.Sprintf("%s → %s (%s UTC)", name,
+			at.Format("2006-01-02 15:04 MST"), at.UTC().Format("2006-01-02 15:04"))
+	}
+
+	var lines []string
+	switch {
+	case op.SolarEvent != "" && op.Action == "create":
+		at, err := b.resolveSolarTime(ctx, op, msg.From.ID)
+		lines = append(lines, format(op.SolarEvent+" "+op.Offset, at, err))
+	case op.RelativeToReminderID != "":
+		at, err := b.resolveRelativeTime(ctx, op, msg.From.ID)
+		lines = append(lines, format(op.RelativeToReminderID+" "+op.Offset, at, err))
+	case op.Datetime != "":
+		at, err := b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02 15:04:05", op.Datetime)
+		if err == nil && op.BusinessDays != 0 {
+			at, err = b.businessDaysLater(ctx, msg.From.ID, at, op.BusinessDays)
+		} else if err == nil && op.Months != 0 {
+			at, err = b.monthsLater(ctx, msg.From.ID, at, op.Months)
+		}
+		if err == nil && op.DateOnly && !op.IsTodo {
+			at = b.applyDefaultReminderHour(ctx, msg, at)
+		}
+		if err == nil && op.FreeSlot {
+			at, err = b.freeSlot(ctx, msg.From.ID, at, 0, 0)
+		}
+		lines = append(lines, format("datetime", at, err))
+	case op.TimeDelta != "" && !isRecurringReminderID(op.ReminderID):
+		reminderID, err := strconv.ParseInt(op.ReminderID, 10, 64)
+		var at time.Time
+		if err == nil {
+			at, err = b.shiftedReminderTime(ctx, reminderID, msg.From.ID, op.TimeDelta)
+		}
+		lines = append(lines, format("time_delta "+op.TimeDelta, at, err))
+	}
+
+	if op.EndDatetime != "" {
+		at, err := b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02 15:04:05", op.EndDatetime)
+		lines = append(lines, format("end_datetime", at, err))
+	}
+	return lines
+}
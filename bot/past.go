@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Number of reminders shown by /past without an argument, and the most it shows
+const (
+	defaultPastCount = 10
+	maxPastCount     = 50
+)
+
+// handlePastCommand shows the user's last delivered reminders and completed todos with
+// their times, e.g. "/past" or "/past 20", to confirm something fired or to recreate it
+func (b *ReminderBot) handlePastCommand(ctx context.Context, msg *tgbotapi.Message) {
+	count := defaultPastCount
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /past или /past 20, чтобы показать последние 20 напоминаний.")
+			b.bot.Send(reply)
+			return
+		}
+		count = min(n, maxPastCount)
+	}
+
+	reminders, err := b.reminders.Past(ctx, msg.From.ID, count)
+	if err != nil {
+		b.logger.Printf("Error getting past reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении прошедших напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(reminders) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Сработавших напоминаний пока нет.")
+		b.bot.Send(reply)
+		return
+	}
+
+	var lines []string
+	for _, r := range reminders {
+		line := fmt.Sprintf("%s – %s", formatReminderTime(r, "02.01.2006 15:04"), b.listLabel(r.Label))
+		if r.IsTodo {
+			line = "✔️ " + line
+		}
+		lines = append(lines, line)
+	}
+
+	text := "Последние сработавшие напоминания:\n" + strings.Join(lines, "\n")
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
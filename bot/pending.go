@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"reminders21/llm"
+)
+
+// pendingOperationTTL is how long a pending operation waits for the user's choice
+const pendingOperationTTL = time.Hour
+
+// pendingOperation is an LLM operation waiting for user input via inline buttons
+type pendingOperation struct {
+	op        llm.Operation
+	userID    int64
+	createdAt time.Time
+}
+
+// pendingOperations stores operations referenced from callback data by a short token,
+// since the full operation doesn't fit into Telegram's 64-byte callback data
+type pendingOperations struct {
+	mu   sync.Mutex
+	next int64
+	ops  map[string]pendingOperation
+}
+
+// newPendingOperations creates an empty store
+func newPendingOperations() *pendingOperations {
+	return &pendingOperations{ops: make(map[string]pendingOperation)}
+}
+
+// add stores an operation and returns its token
+func (p *pendingOperations) add(op llm.Operation, userID int64) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Drop expired operations
+	now := time.Now()
+	for token, pending := range p.ops {
+		if now.Sub(pending.createdAt) > pendingOperationTTL {
+			delete(p.ops, token)
+		}
+	}
+
+	p.next++
+	token := strconv.FormatInt(p.next, 36)
+	p.ops[token] = pendingOperation{op: op, userID: userID, createdAt: now}
+	return token
+}
+
+// take removes and returns the operation for a token if it belongs to the user
+func (p *pendingOperations) take(token string, userID int64) (llm.Operation, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, ok := p.ops[token]
+	if !ok || pending.userID != userID || time.Since(pending.createdAt) > pendingOperationTTL {
+		return llm.Operation{}, false
+	}
+
+	delete(p.ops, token)
+	return pending.op, true
+}
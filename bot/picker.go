@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
+	"reminders21/llm"
+	"reminders21/storage"
+)
+
+// pickerButtonLength is the maximum length of a reminder description on a picker button
+const pickerButtonLength = 40
+
+// showReminderPicker asks the user which of several matching reminders the operation refers to
+func (b *ReminderBot) showReminderPicker(op llm.Operation, msg *tgbotapi.Message) {
+	var token string
+	if op.Action == "adjust" {
+		token = b.pending.add(op, msg.From.ID)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, id := range op.CandidateIDs {
+		summary, ok := b.reminderSummary(msg.From.ID, id)
+		if !ok {
+			continue
+		}
+
+		// Deletes reuse the regular delete callbacks, choosing acts as confirmation
+		// ("delete_rec_<id>" for recurring, "delete_<id>" for regular)
+		callback := "delete_" + id
+		if op.Action == "adjust" {
+			callback = fmt.Sprintf("pick_%s_%s", token, id)
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(truncateText(summary, pickerButtonLength), callback),
+		))
+	}
+
+	if len(rows) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := "Какое напоминание изменить?"
+	if op.Action == "delete" {
+		text = "Какое напоминание удалить?"
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.bot.Send(reply)
+}
+
+// handlePickCallback applies a pending adjust operation to the reminder chosen in the picker.
+// Callback data format: pick_<token>_<reminder_id>
+func (b *ReminderBot) handlePickCallback(query *tgbotapi.CallbackQuery) {
+	parts := strings.SplitN(strings.TrimPrefix(query.Data, "pick_"), "_", 2)
+	if len(parts) != 2 {
+		b.logger.Printf("Invalid pick callback: %s", query.Data)
+		return
+	}
+
+	op, ok := b.pending.take(parts[0], query.From.ID)
+	if !ok {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, "Запрос устарел, попробуйте ещё раз.")
+		b.bot.Request(edit)
+		return
+	}
+
+	// Remove the picker buttons
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text)
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	op.ReminderID = parts[1]
+	op.CandidateIDs = nil
+	b.processAdjustOperation(op, callbackMessage(query), nil)
+}
+
+// showTimePicker asks the user which of the candidate times of a create operation they meant
+func (b *ReminderBot) showTimePicker(op llm.Operation, msg *tgbotapi.Message) {
+	ctx := context.Background()
+	token := b.pending.add(op, msg.From.ID)
+
+	// Dates are only shown if the candidates fall on different days
+	var times []time.Time
+	var buttons []tgbotapi.InlineKeyboardButton
+	layout := "15:04"
+	for _, candidate := range op.CandidateDatetimes {
+		at, err := b.reminders.ParseLocalTime(ctx, msg.From.ID, "2006-01-02 15:04:05", candidate)
+		if err != nil {
+			b.logger.Printf("Ignoring invalid candidate time %q: %v", candidate, err)
+		}
+		if len(times) > 0 && err == nil && !core.StartOfDay(at).Equal(core.StartOfDay(times[0])) {
+			layout = "02.01 15:04"
+		}
+		times = append(times, at)
+	}
+	for i, at := range times {
+		if at.IsZero() {
+			continue
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(at.Format(layout), fmt.Sprintf("picktime_%s_%d", token, i)))
+	}
+
+	if len(buttons) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат даты/времени в операции создания.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Во сколько напомнить «%s»?", op.Label))
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons)
+	b.bot.Send(reply)
+}
+
+// handlePickTimeCallback creates the reminder of a pending create operation at the time
+// chosen in the time picker. Callback data format: picktime_<token>_<candidate index>
+func (b *ReminderBot) handlePickTimeCallback(query *tgbotapi.CallbackQuery) {
+	parts := strings.SplitN(strings.TrimPrefix(query.Data, "picktime_"), "_", 2)
+	if len(parts) != 2 {
+		b.logger.Printf("Invalid pick time callback: %s", query.Data)
+		return
+	}
+
+	op, ok := b.pending.take(parts[0], query.From.ID)
+	index, err := strconv.Atoi(parts[1])
+	if !ok || err != nil || index < 0 || index >= len(op.CandidateDatetimes) {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, "Запрос устарел, попробуйте ещё раз.")
+		b.bot.Request(edit)
+		return
+	}
+
+	// Remove the picker buttons
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text)
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	// The model's answer may name another candidate, so describe the reminder from the chosen one
+	op.Datetime = op.CandidateDatetimes[index]
+	op.CandidateDatetimes = nil
+	op.Answer = ""
+	b.processCreateOperation(op, callbackMessage(query), nil)
+}
+
+// reminderSummary describes a regular or recurring ("rec_") reminder owned by the user
+func (b *ReminderBot) reminderSummary(userID int64, id string) (string, bool) {
+	if isRecurringReminderID(id) {
+		reminderID, err := parseRecurringReminderID(id)
+		if err != nil {
+			return "", false
+		}
+
+		reminder, err := b.reminders.GetRecurring(context.Background(), reminderID, userID)
+		if err != nil || reminder == nil {
+			return "", false
+		}
+		return fmt.Sprintf("%s – %s", describeRecurrence(*reminder), reminder.Label), true
+	}
+
+	reminderID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return "", false
+	}
+
+	reminder, err := b.reminders.Get(context.Background(), reminderID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrReminderNotFound) {
+			b.logger.Printf("Error getting reminder: %v", err)
+		}
+		return "", false
+	}
+	if reminder.UserID != userID || reminder.Notified {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s – %s", formatReminderTime(*reminder, "02.01 15:04"), reminder.Label), true
+}
+
+// callbackMessage builds a message on behalf of the user who pressed an inline button,
+// so callbacks can reuse the operation handlers
+func callbackMessage(query *tgbotapi.CallbackQuery) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: query.Message.MessageID,
+		Chat:      query.Message.Chat,
+		From:      query.From,
+	}
+}
+
+// truncateText shortens text to maxLen characters, adding an ellipsis
+func truncateText(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+	return string(runes[:maxLen-1]) + "…"
+}
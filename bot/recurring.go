@@ -1,9 +1,14 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reminders21/llm"
 	"reminders21/storage"
 	"reminders21/utils"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,14 +27,18 @@ func (b *ReminderBot) startRecurringChecker() {
 			return
 		case <-ticker.C:
 			b.processRecurringReminders()
+			b.processDailyAgendas()
+			b.processChatAgendas()
+			b.dialogs.expire()
 		}
 	}
 }
 
 // processRecurringReminders processes recurring reminders
 func (b *ReminderBot) processRecurringReminders() {
+	ctx := context.Background()
 	now := time.Now()
-	reminders, err := b.repo.GetDueRecurringReminders(now)
+	reminders, err := b.reminders.DueRecurring(ctx, now)
 	if err != nil {
 		b.logger.Printf("Error getting due recurring reminders: %v", err)
 		return
@@ -39,48 +48,82 @@ func (b *ReminderBot) processRecurringReminders() {
 		return
 	}
 
+	// Hold the outbox while queueing so flushOutbox doesn't send an occurrence
+	// that turns out to be claimed already
+	b.outboxLock.Lock()
 	for _, r := range reminders {
 		var recurringInfo string
 		switch r.RecurringType {
 		case storage.RecurringDaily:
 			recurringInfo = "ежедневно"
+		case storage.RecurringHourly:
+			recurringInfo = hourlyIntervalText(r.IntervalHours)
 		case storage.RecurringWeekly:
-			weekday := time.Weekday(r.DayOfWeek)
-			weekdayName := utils.WeekdayToRussian(weekday)
-			recurringInfo = fmt.Sprintf("еженедельно по %s", weekdayName)
+			recurringInfo = weeklyDaysText(r.DayOfWeek)
 		case storage.RecurringMonthly:
 			recurringInfo = fmt.Sprintf("ежемесячно %d числа", r.DayOfMonth)
+		case storage.RecurringMonthlyWeekday:
+			recurringInfo = "ежемесячно " + weekOfMonthText(r.WeekOfMonth, r.DayOfWeek)
 		}
 
-		message := fmt.Sprintf("%s\n(повторяется %s в %s)", r.Label, recurringInfo, r.Time)
-		msg := tgbotapi.NewMessage(r.ChatID, message)
-		if _, err := b.bot.Send(msg); err != nil {
-			b.logger.Printf("Error sending recurring reminder: %v", err)
+		markup, err := json.Marshal(tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⏭ Пропустить следующий раз", fmt.Sprintf("skip_rec_%d", r.ID)),
+			),
+			ackRow(),
+		))
+		if err != nil {
+			b.logger.Printf("Error building recurring reminder %d: %v", r.ID, err)
 			continue
 		}
 
-		// Update last triggered time
-		if err := b.repo.UpdateRecurringReminderLastTriggered(r.ID, now); err != nil {
-			b.logger.Printf("Error updating last triggered time: %v", err)
+		// Queue the message before claiming the occurrence, so a crash in between
+		// sends it twice rather than never
+		queued, err := b.repo.EnqueueOutboxContext(ctx, []storage.OutboxMessage{{
+			ChatID:      r.ChatID,
+			UserID:      r.UserID,
+			RecurringID: r.ID,
+			Label:       r.Label,
+			Text: fmt.Sprintf("%s%s\n(повторяется %s %s)", b.deliveryPrefix(ctx, r.UserID),
+				escapeText(reminderParseMode, r.Label), recurringInfo, recurrenceTimeText(r)),
+			ReplyMarkup: string(markup),
+			Silent:      r.Silent,
+		}})
+		if err != nil {
+			b.logger.Printf("Error queueing recurring reminder: %v", err)
+			continue
 		}
 
-		b.logger.Printf("Sent recurring reminder: ID=%d, chat=%d, label=%s", r.ID, r.ChatID, r.Label)
+		claimed, err := b.reminders.ClaimRecurring(ctx, r, now)
+		if err != nil || !claimed {
+			if err != nil {
+				b.logger.Printf("Error claiming recurring reminder: %v", err)
+			} else {
+				b.logger.Printf("Recurring reminder ID=%d already delivered today, skipping", r.ID)
+			}
+			b.deleteOutbox(ctx, queued[0].ID)
+		}
 	}
-}
+	b.outboxLock.Unlock()
 
-// addRecurringReminder adds a recurring reminder
-func (b *ReminderBot) addRecurringReminder(msg *tgbotapi.Message, label string, recurringType storage.RecurringType, timeStr string, dayOfWeek, dayOfMonth int, isTodo bool) {
-	id, err := b.repo.AddRecurringReminder(
-		msg.Chat.ID,
-		msg.From.ID,
-		label,
-		recurringType,
-		timeStr,
-		dayOfWeek,
-		dayOfMonth,
-		isTodo,
-	)
+	b.flushOutbox()
+}
 
+// addRecurringReminder adds a recurring reminder for the message's chat and sender
+func (b *ReminderBot) addRecurringReminder(msg *tgbotapi.Message, item storage.RecurringReminder, batch *operationBatch) {
+	ctx := context.Background()
+	item.ChatID = msg.Chat.ID
+	item.UserID = msg.From.ID
+	id, err := b.reminders.CreateRecurring(ctx, item)
+
+	if errors.Is(err, storage.ErrLimitReached) {
+		b.logger.Printf("User %d reached the recurring reminder limit", msg.From.ID)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Достигнут лимит в %d регулярных напоминаний. Удали ненужные, чтобы добавить новое.",
+			b.config.MaxRecurringPerUser))
+		b.bot.Send(reply)
+		return
+	}
 	if err != nil {
 		b.logger.Printf("Error adding recurring reminder: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при создании повторяющегося напоминания.")
@@ -88,28 +131,14 @@ func (b *ReminderBot) addRecurringReminder(msg *tgbotapi.Message, label string,
 		return
 	}
 
-	var recurringText string
-	switch recurringType {
-	case storage.RecurringDaily:
-		if isTodo {
-			recurringText = fmt.Sprintf("каждый день")
-		} else {
-			recurringText = fmt.Sprintf("каждый день в %s", timeStr)
-		}
-	case storage.RecurringWeekly:
-		weekday := time.Weekday(dayOfWeek)
-		weekdayName := utils.WeekdayToRussian(weekday)
-		if isTodo {
-			recurringText = fmt.Sprintf("каждую %s", weekdayName)
-		} else {
-			recurringText = fmt.Sprintf("каждую %s в %s", weekdayName, timeStr)
-		}
-	case storage.RecurringMonthly:
-		if isTodo {
-			recurringText = fmt.Sprintf("каждое %d число месяца", dayOfMonth)
-		} else {
-			recurringText = fmt.Sprintf("каждое %d число месяца в %s", dayOfMonth, timeStr)
-		}
+	label, isTodo := item.Label, item.IsTodo
+
+	recurringText := recurrenceScheduleText(item)
+	if !isTodo {
+		recurringText += " " + recurrenceTimeText(item)
+	}
+	if item.Silent {
+		recurringText += ", без звука"
 	}
 
 	itemType := "регулярное напоминание"
@@ -117,7 +146,12 @@ func (b *ReminderBot) addRecurringReminder(msg *tgbotapi.Message, label string,
 		itemType = "регулярную задачу"
 	}
 
-	answer := fmt.Sprintf("Создано %s: %s (%s)", itemType, label, recurringText)
+	// Show concrete dates so the user can check the schedule was understood right
+	answer := fmt.Sprintf("Создано %s: %s (%s)\n\n%s", itemType, label, recurringText, b.upcomingOccurrencesText(ctx, item))
+	// The chat's delete policy covers one-time reminders only
+	if note := groupScopeNote(msg, isTodo, false); note != "" {
+		answer += "\n\n" + note
+	}
 	reply := tgbotapi.NewMessage(msg.Chat.ID, answer)
 
 	// Add delete button
@@ -129,7 +163,7 @@ func (b *ReminderBot) addRecurringReminder(msg *tgbotapi.Message, label string,
 	)
 	reply.ReplyMarkup = keyboard
 
-	b.bot.Send(reply)
+	b.confirm(batch, confirmCreated, label, reply)
 
 	tt := "reminder"
 	if isTodo {
@@ -137,37 +171,78 @@ func (b *ReminderBot) addRecurringReminder(msg *tgbotapi.Message, label string,
 	}
 
 	b.logger.Printf("Created recurring %s: ID=%d, '%s' recurring=%s (chat %d)",
-		tt, id, label, string(recurringType), msg.Chat.ID)
+		tt, id, label, string(item.RecurringType), msg.Chat.ID)
+}
+
+// recurringIDPrefix marks recurring reminder IDs in the LLM context, lists and commands,
+// so they are never confused with one-time reminder IDs
+const recurringIDPrefix = "rec_"
+
+// recurringReminderID formats a recurring reminder ID, e.g. "rec_5"
+func recurringReminderID(id int64) string {
+	return recurringIDPrefix + strconv.FormatInt(id, 10)
+}
+
+// isRecurringReminderID reports whether id refers to a recurring reminder
+func isRecurringReminderID(id string) bool {
+	return strings.HasPrefix(id, recurringIDPrefix)
+}
+
+// parseRecurringReminderID extracts the numeric ID from a recurring reminder ID
+func parseRecurringReminderID(id string) (int64, error) {
+	return strconv.ParseInt(strings.TrimPrefix(id, recurringIDPrefix), 10, 64)
 }
 
 // getUserRecurringRemindersAsMap gets user recurring reminders as map for LLM
-func (b *ReminderBot) getUserRecurringRemindersAsMap(userID int64) ([]map[string]string, error) {
-	reminders, err := b.repo.GetUserRecurringReminders(userID)
+func (b *ReminderBot) getUserRecurringRemindersAsMap(ctx context.Context, userID int64) ([]map[string]string, error) {
+	reminders, err := b.reminders.ListRecurring(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	var result []map[string]string
 	for _, r := range reminders {
+		at := r.Time
+		if r.SolarEvent != "" {
+			at = fmt.Sprintf("%s %+d min", r.SolarEvent, r.SolarOffset)
+		}
+
 		var recurringText string
 		switch r.RecurringType {
 		case storage.RecurringDaily:
-			recurringText = fmt.Sprintf("daily at %s", r.Time)
+			recurringText = fmt.Sprintf("daily at %s", at)
+		case storage.RecurringHourly:
+			recurringText = fmt.Sprintf("every %d hours from %s", r.IntervalHours, at)
+			if r.EndTime != "" {
+				recurringText += " until " + r.EndTime
+			}
 		case storage.RecurringWeekly:
-			weekday := time.Weekday(r.DayOfWeek)
-			recurringText = fmt.Sprintf("weekly on %s at %s", weekday.String(), r.Time)
+			days := time.Weekday(r.DayOfWeek).String()
+			switch r.DayOfWeek {
+			case storage.Workday:
+				days = "weekdays"
+			case storage.Weekend:
+				days = "weekends"
+			}
+			recurringText = fmt.Sprintf("weekly on %s at %s", days, at)
 		case storage.RecurringMonthly:
-			recurringText = fmt.Sprintf("monthly on day %d at %s", r.DayOfMonth, r.Time)
+			recurringText = fmt.Sprintf("monthly on day %d at %s", r.DayOfMonth, at)
+		case storage.RecurringMonthlyWeekday:
+			recurringText = fmt.Sprintf("monthly on week %d, day of week %d at %s", r.WeekOfMonth, r.DayOfWeek, at)
 		}
 
 		reminder := map[string]string{
-			"reminder_id":    fmt.Sprintf("rec_%d", r.ID),
+			"reminder_id":    recurringReminderID(r.ID),
 			"recurring_type": string(r.RecurringType),
 			"time":           r.Time,
+			"end_time":       r.EndTime,
 			"day_of_week":    fmt.Sprintf("%d", r.DayOfWeek),
 			"day_of_month":   fmt.Sprintf("%d", r.DayOfMonth),
+			"week_of_month":  fmt.Sprintf("%d", r.WeekOfMonth),
+			"interval_hours": fmt.Sprintf("%d", r.IntervalHours),
 			"label":          r.Label,
 			"description":    recurringText,
+			"enabled":        strconv.FormatBool(r.Enabled),
 		}
 		result = append(result, reminder)
 	}
@@ -176,8 +251,8 @@ func (b *ReminderBot) getUserRecurringRemindersAsMap(userID int64) ([]map[string
 }
 
 // processDeleteRecurringOperation processes delete operation
-func (b *ReminderBot) processDeleteRecurringOperation(reminderID int64, msg *tgbotapi.Message, answer string) {
-	deleted, err := b.repo.DeleteRecurringReminder(reminderID, msg.From.ID)
+func (b *ReminderBot) processDeleteRecurringOperation(reminderID int64, msg *tgbotapi.Message, answer string, batch *operationBatch) {
+	deleted, err := b.reminders.DeleteRecurring(context.Background(), reminderID, msg.From.ID)
 	if err != nil {
 		b.logger.Printf("Error deleting recurring reminder: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при удалении повторяющегося напоминания.")
@@ -197,12 +272,21 @@ func (b *ReminderBot) processDeleteRecurringOperation(reminderID int64, msg *tgb
 		answer = "Регулярное напоминание удалено."
 	}
 	reply := tgbotapi.NewMessage(msg.Chat.ID, answer)
-	b.bot.Send(reply)
+	b.confirm(batch, confirmDeleted, "", reply)
 }
 
-// processListRecurringOperation processes show recurring list operation
-func (b *ReminderBot) processListRecurringOperation(msg *tgbotapi.Message) {
-	reminders, err := b.repo.GetUserRecurringReminders(msg.From.ID)
+// processListRecurringOperation processes show recurring list operation.
+// The operation's recurring_type and day_of_week, if set, narrow down the list.
+func (b *ReminderBot) processListRecurringOperation(op llm.Operation, msg *tgbotapi.Message) {
+	filter, filtered := recurringFilterFromOperation(op)
+
+	var reminders []storage.RecurringReminder
+	var err error
+	if filtered {
+		reminders, err = b.reminders.FindRecurring(context.Background(), msg.From.ID, filter)
+	} else {
+		reminders, err = b.reminders.ListRecurring(context.Background(), msg.From.ID)
+	}
 	if err != nil {
 		b.logger.Printf("Error getting recurring reminders: %v", err)
 		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка повторяющихся напоминаний.")
@@ -211,29 +295,331 @@ func (b *ReminderBot) processListRecurringOperation(msg *tgbotapi.Message) {
 	}
 
 	if len(reminders) == 0 {
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "У вас нет активных повторяющихся напоминаний.")
+		text := "У вас нет активных повторяющихся напоминаний."
+		if filtered {
+			text = "Нет повторяющихся напоминаний, подходящих под запрос."
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 		b.bot.Send(reply)
 		return
 	}
 
+	// Compared with skip dates, which are local dates
+	now := b.reminders.Now(context.Background(), msg.From.ID)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
 	var lines []string
+	var rows [][]tgbotapi.InlineKeyboardButton
 	for _, r := range reminders {
-		var recurringInfo string
-		switch r.RecurringType {
-		case storage.RecurringDaily:
-			recurringInfo = fmt.Sprintf("Ежедневно в %s", r.Time)
-		case storage.RecurringWeekly:
-			weekday := time.Weekday(r.DayOfWeek)
-			weekdayName := utils.WeekdayToRussian(weekday)
-			recurringInfo = fmt.Sprintf("Еженедельно по %s в %s", weekdayName, r.Time)
-		case storage.RecurringMonthly:
-			recurringInfo = fmt.Sprintf("Ежемесячно %d числа в %s", r.DayOfMonth, r.Time)
+		lines = append(lines, recurringListLine(r, today))
+		if len(rows) < maxListButtons {
+			rows = append(rows, recurringListRow(r))
 		}
+	}
+
+	title := "Ваши повторяющиеся напоминания"
+	if filter.DayOfWeek >= 0 {
+		title += " на " + utils.WeekdayToRussian(time.Weekday(filter.DayOfWeek))
+	}
+
+	text := title + ":\n" + strings.Join(lines, "\n") + "\n\nБлижайшие даты: /next rec_ID"
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.bot.Send(reply)
+}
+
+// maxListButtons limits the rows of buttons under the recurring list
+const maxListButtons = 20
+
+// recurringListLine formats a recurring reminder for the list, starting with its category's
+// icon. Disabled reminders are marked with ⏸ instead; today is the user's local date,
+// compared with skip dates.
+func recurringListLine(r storage.RecurringReminder, today time.Time) string {
+	line := fmt.Sprintf("%s – %s [%s]", describeRecurrence(r), r.Label, recurringReminderID(r.ID))
+	if !r.Enabled {
+		return "⏸ " + line + " (приостановлено)"
+	}
+	if !r.IsTodo {
+		line = categoryIcon(r.Category) + " " + line
+	}
+	var skips []string
+	for _, date := range r.SkipDates {
+		if skipped, err := time.Parse("2006-01-02", date); err == nil && !skipped.Before(today) {
+			skips = append(skips, skipped.Format("02.01"))
+		}
+	}
+	if len(skips) > 0 {
+		line += fmt.Sprintf(" (пропуск %s)", strings.Join(skips, ", "))
+	}
+	return line
+}
+
+// toggleRecurringButton builds the button disabling or re-enabling a recurring reminder
+func toggleRecurringButton(r storage.RecurringReminder) tgbotapi.InlineKeyboardButton {
+	text := "⏸ Приостановить"
+	if !r.Enabled {
+		text = "▶️ Возобновить"
+	}
+	text += ": " + truncateText(r.Label, fullTextButtonLength)
+	return tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("toggle_rec_%d", r.ID))
+}
+
+// handleToggleRecurringCallback disables or re-enables a recurring reminder from the list
+// and updates its line and button in place
+func (b *ReminderBot) handleToggleRecurringCallback(query *tgbotapi.CallbackQuery) {
+	reminderID, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "toggle_rec_"), 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing recurring reminder ID from callback: %v", err)
+		return
+	}
+
+	ctx := context.Background()
+	reminder, err := b.reminders.GetRecurring(ctx, reminderID, query.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting recurring reminder %d: %v", reminderID, err)
+		return
+	}
+	if reminder == nil {
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(notification)
+		return
+	}
+
+	reminder.Enabled = !reminder.Enabled
+	if _, err := b.reminders.SetRecurringActive(ctx, reminderID, query.From.ID, reminder.Enabled); err != nil {
+		b.logger.Printf("Error toggling recurring reminder %d: %v", reminderID, err)
+		return
+	}
+
+	now := b.reminders.Now(ctx, query.From.ID)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	lines := strings.Split(query.Message.Text, "\n")
+	tag := "[" + recurringReminderID(reminderID) + "]"
+	for i, line := range lines {
+		if strings.Contains(line, tag) {
+			lines[i] = recurringListLine(*reminder, today)
+		}
+	}
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	if query.Message.ReplyMarkup != nil {
+		markup = *query.Message.ReplyMarkup
+	}
+	for _, row := range markup.InlineKeyboard {
+		for i, button := range row {
+			if button.CallbackData != nil && *button.CallbackData == query.Data {
+				row[i] = toggleRecurringButton(*reminder)
+			}
+		}
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID,
+		strings.Join(lines, "\n"), markup)
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	b.logger.Printf("Set recurring reminder ID=%d enabled=%t (user %d)", reminderID, reminder.Enabled, query.From.ID)
+}
+
+// upcomingOccurrences is how many dates are shown for a recurring reminder
+const upcomingOccurrences = 5
+
+// upcomingOccurrencesText lists the next dates of a recurring reminder in its owner's timezone
+func (b *ReminderBot) upcomingOccurrencesText(ctx context.Context, r storage.RecurringReminder) string {
+	occurrences := r.NextOccurrences(b.reminders.Now(ctx, r.UserID), upcomingOccurrences)
+	if len(occurrences) == 0 {
+		return "Ближайших повторений нет."
+	}
+
+	layout := "02.01.2006 15:04"
+	if r.IsTodo {
+		layout = "02.01.2006"
+	}
+
+	var lines []string
+	for _, at := range occurrences {
+		lines = append(lines, "• "+at.Format(layout))
+	}
+
+	return "Ближайшие даты:\n" + strings.Join(lines, "\n")
+}
 
-		lines = append(lines, fmt.Sprintf("%s – %s", recurringInfo, r.Label))
+// handleNextCommand shows the next dates of a recurring reminder, e.g. "/next rec_5"
+func (b *ReminderBot) handleNextCommand(ctx context.Context, msg *tgbotapi.Message) {
+	reminderID, err := parseRecurringReminderID(strings.TrimSpace(msg.CommandArguments()))
+	if err != nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /next rec_ID, например /next rec_5. ID есть в списке /recurring.")
+		b.bot.Send(reply)
+		return
 	}
 
-	text := "Ваши повторяющиеся напоминания:\n" + strings.Join(lines, "\n")
+	reminder, err := b.reminders.GetRecurring(ctx, reminderID, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting recurring reminder %d: %v", reminderID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении регулярного напоминания.")
+		b.bot.Send(reply)
+		return
+	}
+	if reminder == nil {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("%s – %s\n\n%s", describeRecurrence(*reminder), reminder.Label, b.upcomingOccurrencesText(ctx, *reminder))
 	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 	b.bot.Send(reply)
 }
+
+// recurringFilterFromOperation builds a recurring list filter from a show_recurring operation.
+// It reports false if the operation has no valid filter fields.
+func recurringFilterFromOperation(op llm.Operation) (storage.RecurringFilter, bool) {
+	filter := storage.RecurringFilter{DayOfWeek: -1}
+
+	switch storage.RecurringType(op.RecurringType) {
+	case storage.RecurringDaily, storage.RecurringWeekly, storage.RecurringMonthly, storage.RecurringMonthlyWeekday,
+		storage.RecurringHourly:
+		filter.Type = storage.RecurringType(op.RecurringType)
+	}
+
+	if dow, err := strconv.Atoi(op.DayOfWeek); err == nil && dow >= 0 && dow <= 6 {
+		filter.DayOfWeek = dow
+	}
+
+	return filter, filter.Type != "" || filter.DayOfWeek >= 0
+}
+
+// describeRecurrence formats a recurring reminder schedule, e.g. "Ежедневно в 09:00"
+func describeRecurrence(r storage.RecurringReminder) string {
+	switch r.RecurringType {
+	case storage.RecurringDaily:
+		return fmt.Sprintf("Ежедневно %s", recurrenceTimeText(r))
+	case storage.RecurringWeekly:
+		days := weeklyDaysText(r.DayOfWeek)
+		return fmt.Sprintf("%s %s", utils.Capitalize(days), recurrenceTimeText(r))
+	case storage.RecurringMonthly:
+		return fmt.Sprintf("Ежемесячно %d числа %s", r.DayOfMonth, recurrenceTimeText(r))
+	case storage.RecurringMonthlyWeekday:
+		return fmt.Sprintf("Ежемесячно %s %s", weekOfMonthText(r.WeekOfMonth, r.DayOfWeek), recurrenceTimeText(r))
+	case storage.RecurringHourly:
+		return fmt.Sprintf("%s %s", utils.Capitalize(hourlyIntervalText(r.IntervalHours)), recurrenceTimeText(r))
+	default:
+		return r.Time
+	}
+}
+
+// weekOfMonthOrdinals are the ordinals for WeekOfMonth in the accusative case,
+// in masculine, feminine and neuter forms
+var weekOfMonthOrdinals = map[int][3]string{
+	1:                       {"первый", "первую", "первое"},
+	2:                       {"второй", "вторую", "второе"},
+	3:                       {"третий", "третью", "третье"},
+	4:                       {"четвёртый", "четвёртую", "четвёртое"},
+	5:                       {"пятый", "пятую", "пятое"},
+	storage.LastWeekOfMonth: {"последний", "последнюю", "последнее"},
+}
+
+// recurrenceScheduleText describes the days a recurring reminder repeats on,
+// e.g. "каждый день" or "каждый месяц в первый понедельник"
+func recurrenceScheduleText(item storage.RecurringReminder) string {
+	switch item.RecurringType {
+	case storage.RecurringDaily:
+		return "каждый день"
+	case storage.RecurringWeekly:
+		if item.DayOfWeek == storage.Workday || item.DayOfWeek == storage.Weekend {
+			return weeklyDaysText(item.DayOfWeek)
+		}
+		weekday := time.Weekday(item.DayOfWeek)
+		return fmt.Sprintf("каждую %s", utils.WeekdayToRussian(weekday))
+	case storage.RecurringMonthly:
+		return fmt.Sprintf("каждое %d число месяца", item.DayOfMonth)
+	case storage.RecurringMonthlyWeekday:
+		return "каждый месяц " + weekOfMonthText(item.WeekOfMonth, item.DayOfWeek)
+	case storage.RecurringHourly:
+		return hourlyIntervalText(item.IntervalHours)
+	}
+	return ""
+}
+
+// hourlyIntervalText describes how often an hourly reminder repeats, e.g. "каждый час"
+// or "каждые 3 часа"
+func hourlyIntervalText(hours int) string {
+	if hours <= 1 {
+		return "каждый час"
+	}
+	return fmt.Sprintf("каждые %d %s", hours, utils.PluralRussian(hours, "час", "часа", "часов"))
+}
+
+// weeklyDaysText describes the days of a weekly reminder compactly, e.g. "еженедельно по
+// среду", "по будням" or "по выходным"
+func weeklyDaysText(dayOfWeek int) string {
+	switch dayOfWeek {
+	case storage.Workday:
+		return "по будням"
+	case storage.Weekend:
+		return "по выходным"
+	}
+	return "еженедельно по " + utils.WeekdayToRussian(time.Weekday(dayOfWeek))
+}
+
+// isWeeklyDay reports whether dayOfWeek is valid for a weekly reminder: a weekday,
+// storage.Workday or storage.Weekend
+func isWeeklyDay(dayOfWeek int) bool {
+	return dayOfWeek >= 0 && dayOfWeek <= storage.Weekend
+}
+
+// weekOfMonthText describes a monthly_weekday schedule with its preposition,
+// e.g. "в первый понедельник", "во вторую среду", "в последний рабочий день"
+func weekOfMonthText(weekOfMonth, dayOfWeek int) string {
+	day, gender := "рабочий день", 0
+	if dayOfWeek != storage.Workday {
+		weekday := time.Weekday(dayOfWeek)
+		day = utils.WeekdayToRussian(weekday)
+		switch weekday {
+		case time.Wednesday, time.Friday, time.Saturday:
+			gender = 1
+		case time.Sunday:
+			gender = 2
+		}
+	}
+
+	ordinal := weekOfMonthOrdinals[weekOfMonth][gender]
+	preposition := "в"
+	if weekOfMonth == 2 {
+		preposition = "во"
+	}
+	return fmt.Sprintf("%s %s %s", preposition, ordinal, day)
+}
+
+// handleSkipRecurringCallback skips the next occurrence of a recurring reminder
+func (b *ReminderBot) handleSkipRecurringCallback(query *tgbotapi.CallbackQuery) {
+	reminderID, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "skip_rec_"), 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing recurring reminder ID from callback: %v", err)
+		return
+	}
+
+	next, err := b.reminders.SkipNextRecurring(context.Background(), reminderID, query.From.ID)
+	if err != nil {
+		b.logger.Printf("Error skipping recurring reminder: %v", err)
+		return
+	}
+
+	if next.IsZero() {
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(notification)
+		return
+	}
+
+	// Replace the button with the skipped state
+	text := fmt.Sprintf("%s\n\n⏭ Следующий раз (%s) пропущен.", query.Message.Text, next.Format("02.01"))
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	b.logger.Printf("Skipped recurring reminder ID=%d on %s (user %d)",
+		reminderID, next.Format("2006-01-02"), query.From.ID)
+}
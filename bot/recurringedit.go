@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/llm"
+	"reminders21/storage"
+)
+
+// weekdayButtons are the weekday choices offered when editing a weekly reminder, Monday first
+var weekdayButtons = []struct {
+	text string
+	day  int
+}{
+	{"Пн", 1}, {"Вт", 2}, {"Ср", 3}, {"Чт", 4}, {"Пт", 5}, {"Сб", 6}, {"Вс", 0},
+}
+
+// weekdaySetButtons are the choices of several days offered below weekdayButtons
+var weekdaySetButtons = []struct {
+	text string
+	day  int
+}{
+	{"По будням", storage.Workday}, {"По выходным", storage.Weekend},
+}
+
+// recurringListRow builds the buttons of a recurring reminder in the list:
+// disable/enable, edit and delete
+func recurringListRow(r storage.RecurringReminder) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		toggleRecurringButton(r),
+		tgbotapi.NewInlineKeyboardButtonData("✏️", fmt.Sprintf("edit_rec_%d", r.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("🗑", fmt.Sprintf("ask_del_rec_%d", r.ID)),
+	)
+}
+
+// handleAskDeleteRecurringCallback asks to confirm deleting a recurring reminder from the list.
+// Callback data format: ask_del_rec_<id>
+func (b *ReminderBot) handleAskDeleteRecurringCallback(query *tgbotapi.CallbackQuery) {
+	reminderID, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "ask_del_rec_"), 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing recurring reminder ID from callback: %v", err)
+		return
+	}
+
+	b.confirmDeleteRecurring(reminderID, callbackMessage(query))
+}
+
+// handleEditRecurringCallback runs the edit dialog of a recurring reminder from the list.
+// Callback data formats:
+//
+//	edit_rec_<id>                 choose what to change
+//	edit_rec_time_<id>            ask for a new time, answered by the next text message
+//	edit_rec_dow_<id>             choose a new weekday
+//	edit_rec_setdow_<id>_<day>    set the weekday
+//	edit_rec_cancel               stop waiting for the new time
+func (b *ReminderBot) handleEditRecurringCallback(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+	data := strings.TrimPrefix(query.Data, "edit_rec_")
+	chatID := query.Message.Chat.ID
+
+	if data == "cancel" {
+		b.dialogs.clear(chatID, query.From.ID)
+		edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, "Изменение отменено.")
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error editing message: %v", err)
+		}
+		return
+	}
+
+	action, arg := "", data
+	if i := strings.Index(data, "_"); i >= 0 {
+		action, arg = data[:i], data[i+1:]
+	}
+	idStr, dayStr, _ := strings.Cut(arg, "_")
+
+	reminderID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		b.logger.Printf("Invalid edit recurring callback: %s", query.Data)
+		return
+	}
+
+	reminder, err := b.reminders.GetRecurring(ctx, reminderID, query.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting recurring reminder %d: %v", reminderID, err)
+		return
+	}
+	if reminder == nil {
+		notification := tgbotapi.NewMessage(chatID, "Регулярное напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(notification)
+		return
+	}
+
+	switch action {
+	case "":
+		rows := [][]tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🕐 Время", fmt.Sprintf("edit_rec_time_%d", reminderID))),
+		}
+		if reminder.RecurringType == storage.RecurringWeekly {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📅 День недели", fmt.Sprintf("edit_rec_dow_%d", reminderID))))
+		}
+		reply := tgbotapi.NewMessage(chatID, fmt.Sprintf("Что изменить?\n%s – %s", describeRecurrence(*reminder), reminder.Label))
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		b.bot.Send(reply)
+
+	case "time":
+		b.dialogs.set(chatID, query.From.ID, dialog{kind: dialogRecurringTime, reminderID: reminderID})
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, query.Message.MessageID,
+			fmt.Sprintf("Напишите новое время для «%s» в формате ЧЧ:ММ, например 09:30.", reminder.Label),
+			tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("Отмена", "edit_rec_cancel"))))
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error editing message: %v", err)
+		}
+
+	case "dow":
+		var row []tgbotapi.InlineKeyboardButton
+		for _, weekday := range weekdayButtons {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(weekday.text,
+				fmt.Sprintf("edit_rec_setdow_%d_%d", reminderID, weekday.day)))
+		}
+		var setRow []tgbotapi.InlineKeyboardButton
+		for _, days := range weekdaySetButtons {
+			setRow = append(setRow, tgbotapi.NewInlineKeyboardButtonData(days.text,
+				fmt.Sprintf("edit_rec_setdow_%d_%d", reminderID, days.day)))
+		}
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, query.Message.MessageID,
+			fmt.Sprintf("В какой день недели напоминать «%s»?", reminder.Label),
+			tgbotapi.NewInlineKeyboardMarkup(row, setRow))
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error editing message: %v", err)
+		}
+
+	case "setdow":
+		day, err := strconv.Atoi(dayStr)
+		if err != nil || !isWeeklyDay(day) || reminder.RecurringType != storage.RecurringWeekly {
+			b.logger.Printf("Invalid edit recurring callback: %s", query.Data)
+			return
+		}
+		reminder.DayOfWeek = day
+		text := b.saveRecurringEdit(ctx, *reminder)
+		edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error editing message: %v", err)
+		}
+
+	default:
+		b.logger.Printf("Invalid edit recurring callback: %s", query.Data)
+	}
+}
+
+// handleDialogAnswer applies the user's text message to the question the bot asked them
+// in the chat. It reports false if there is no such question, so the message is parsed as usual.
+func (b *ReminderBot) handleDialogAnswer(ctx context.Context, msg *tgbotapi.Message) bool {
+	active, ok := b.dialogs.take(msg.Chat.ID, msg.From.ID)
+	if !ok {
+		return false
+	}
+
+	switch active.kind {
+	case dialogRecurringTime:
+		at, err := storage.NormalizeClockTime(msg.Text)
+		if err != nil || at == "" {
+			// Keep waiting for a valid time
+			b.dialogs.set(msg.Chat.ID, msg.From.ID, active)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, invalidRecurringTimeText(msg.Text))
+			b.bot.Send(reply)
+			return true
+		}
+
+		reminder, err := b.reminders.GetRecurring(ctx, active.reminderID, msg.From.ID)
+		if err != nil || reminder == nil {
+			if err != nil {
+				b.logger.Printf("Error getting recurring reminder %d: %v", active.reminderID, err)
+			}
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Регулярное напоминание не найдено или не принадлежит вам.")
+			b.bot.Send(reply)
+			return true
+		}
+
+		// A fixed time replaces a solar schedule; the event window is kept if it still fits
+		reminder.EndTime = recurringEndTime(llm.Operation{EndTime: reminder.EndTime}, at)
+		reminder.Time = at
+		reminder.SolarEvent = ""
+		reply := tgbotapi.NewMessage(msg.Chat.ID, b.saveRecurringEdit(ctx, *reminder))
+		b.bot.Send(reply)
+	}
+
+	return true
+}
+
+// saveRecurringEdit saves an edited recurring reminder and returns the text for the user
+func (b *ReminderBot) saveRecurringEdit(ctx context.Context, item storage.RecurringReminder) string {
+	updated, err := b.reminders.UpdateRecurring(ctx, item)
+	if err != nil {
+		b.logger.Printf("Error updating recurring reminder %d: %v", item.ID, err)
+		return "Ошибка при изменении повторяющегося напоминания."
+	}
+	if !updated {
+		return "Не удалось изменить регулярное напоминание."
+	}
+
+	b.logger.Printf("Edited recurring reminder ID=%d from the list (user %d)", item.ID, item.UserID)
+	return fmt.Sprintf("Готово! Теперь: %s – %s", describeRecurrence(item), item.Label)
+}
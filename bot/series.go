@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/llm"
+	"reminders21/storage"
+)
+
+// maxSeriesLength is the most reminders a single series can have
+const maxSeriesLength = 20
+
+// createReminderSeries creates op.Repeat reminders op.RepeatInterval apart, the first at at,
+// e.g. for "напомни 5 раз каждые 3 минуты". They are stored together and can be cancelled
+// with a single button.
+func (b *ReminderBot) createReminderSeries(ctx context.Context, op llm.Operation, msg *tgbotapi.Message, batch *operationBatch, at time.Time) {
+	if op.Repeat > maxSeriesLength {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Могу повторить напоминание не больше %d раз.", maxSeriesLength))
+		b.bot.Send(reply)
+		return
+	}
+	days, interval, err := parseOffset(op.RepeatInterval)
+	if err != nil || days < 0 || interval < 0 || days == 0 && interval < time.Minute {
+		b.logger.Printf("Invalid repeat interval %q in create operation: %v", op.RepeatInterval, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не понял, с каким интервалом повторять. Укажи его, например: «5 раз каждые 3 минуты».")
+		b.bot.Send(reply)
+		return
+	}
+
+	label, note := b.shortenLabel(op.Label, forwardedNote(msg))
+	sourceText := truncateText(strings.TrimSpace(msg.Text+msg.Caption), sourceTextLength)
+
+	items := make([]storage.ReminderItem, op.Repeat)
+	for i := range items {
+		items[i] = storage.ReminderItem{
+			ChatID:       msg.Chat.ID,
+			UserID:       msg.From.ID,
+			ReminderTime: at.AddDate(0, 0, days*i).Add(interval * time.Duration(i)),
+			Label:        label,
+			Note:         note,
+			SourceText:   sourceText,
+			Silent:       op.Silent,
+			Category:     normalizeCategory(op.Category),
+		}
+	}
+
+	ids, err := b.reminders.CreateSeries(ctx, items)
+	if errors.Is(err, storage.ErrLimitReached) {
+		b.logger.Printf("User %d reached the reminder limit with a series of %d", msg.From.ID, len(items))
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Серия из %d напоминаний не помещается в лимит в %d активных напоминаний и задач. Удали ненужные или сократи серию.",
+			len(items), b.config.MaxRemindersPerUser))
+		b.bot.Send(reply)
+		return
+	}
+	if err != nil {
+		b.logger.Printf("Error adding reminder series: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при создании напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Info("reminder_series_created", "reminder_id", ids[0], "user_id", msg.From.ID, "chat_id", msg.Chat.ID,
+		"count", len(ids), "at", at.UTC().Format("2006-01-02 15:04:05"))
+
+	// Times on the first reminder's day are shown without the date
+	var times []string
+	for _, item := range items {
+		layout := "15:04"
+		if item.ReminderTime.YearDay() != at.YearDay() || item.ReminderTime.Year() != at.Year() {
+			layout = "02.01 15:04"
+		}
+		times = append(times, item.ReminderTime.Format(layout))
+	}
+	answer := fmt.Sprintf("Напомню %d раз: %s\n%s – %s", len(items), label, at.Format("02.01.2006"), strings.Join(times, ", "))
+	if op.Silent {
+		answer += "\n" + silentNote
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, answer)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить серию", fmt.Sprintf("series_delete_%d", ids[0])),
+		),
+	)
+	b.confirm(batch, confirmCreated, label, reply)
+}
+
+// handleSeriesDeleteCallback deletes the reminders of a series that haven't been delivered yet
+func (b *ReminderBot) handleSeriesDeleteCallback(query *tgbotapi.CallbackQuery) {
+	seriesID, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "series_delete_"), 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing series ID from callback: %v", err)
+		return
+	}
+
+	deleted, err := b.reminders.DeleteSeries(context.Background(), seriesID, query.From.ID)
+	if err != nil {
+		b.logger.Printf("Error deleting reminder series: %v", err)
+		return
+	}
+
+	if deleted == 0 {
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Напоминаний из этой серии больше нет: они уже пришли или удалены.")
+		b.bot.Send(notification)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("✅ Серия отменена, удалено напоминаний: %d.", deleted))
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+
+	b.logger.Info("reminder_series_deleted", "reminder_id", seriesID, "user_id", query.From.ID, "count", deleted, "via", "button")
+}
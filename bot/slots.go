@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"reminders21/core"
+)
+
+// errNoFreeSlot is returned by freeSlot when the rest of the day is taken
+var errNoFreeSlot = errors.New("no free slot")
+
+// busyWindow is a span of the day taken by a reminder; start equals end for reminders
+// without an end time
+type busyWindow struct {
+	start, end time.Time
+	label      string
+}
+
+// near reports whether [start, start+duration] comes closer than gap to the window
+func (w busyWindow) near(start time.Time, duration, gap time.Duration) bool {
+	return start.Before(w.end.Add(gap)) && start.Add(duration).After(w.start.Add(-gap))
+}
+
+// busyWindows collects the spans taken by the user's reminders and recurring occurrences
+// in [start, end). The reminder excludeID, if not 0, is left out, so a reminder doesn't
+// conflict with itself. Todos have no time and take no span.
+func (b *ReminderBot) busyWindows(ctx context.Context, userID int64, start, end time.Time, excludeID int64) ([]busyWindow, error) {
+	reminders, err := b.reminders.ListBetween(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	events, err := b.getApplicableRecurringReminders(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var busy []busyWindow
+	for _, reminder := range reminders {
+		if reminder.IsTodo || reminder.ID == excludeID {
+			continue
+		}
+		until := reminder.ReminderTime
+		if reminder.EndTime.After(until) {
+			until = reminder.EndTime
+		}
+		busy = append(busy, busyWindow{start: reminder.ReminderTime, end: until, label: reminder.Label})
+	}
+	for _, event := range events {
+		if event.IsTodo {
+			continue
+		}
+		until := event.At
+		if event.End.After(until) {
+			until = event.End
+		}
+		busy = append(busy, busyWindow{start: event.At, end: until, label: event.Label})
+	}
+
+	slices.SortFunc(busy, func(a, b busyWindow) int { return a.start.Compare(b.start) })
+	return busy, nil
+}
+
+// freeSlot finds the earliest time at or after after, on the same day, at which a reminder
+// lasting duration stays at least the configured gap away from the user's other reminders
+// and recurring occurrences. The reminder excludeID, if not 0, is ignored.
+func (b *ReminderBot) freeSlot(ctx context.Context, userID int64, after time.Time, duration time.Duration, excludeID int64) (time.Time, error) {
+	if now := b.reminders.Now(ctx, userID); after.Before(now) {
+		after = now.Truncate(time.Minute).Add(time.Minute)
+	}
+	dayStart := core.StartOfDay(after)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	busy, err := b.busyWindows(ctx, userID, dayStart, dayEnd, excludeID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	slot := findFreeSlot(after, duration, b.config.MinSlotGap, busy)
+	if !slot.Before(dayEnd) {
+		return time.Time{}, errNoFreeSlot
+	}
+	return slot, nil
+}
+
+// findFreeSlot returns the earliest time from after at which [t, t+duration] is at least
+// gap away from every busy window, moving past each window that's too close
+func findFreeSlot(after time.Time, duration, gap time.Duration, busy []busyWindow) time.Time {
+	slot := after
+	for moved := true; moved; {
+		moved = false
+		for _, window := range busy {
+			if window.near(slot, duration, gap) {
+				slot = window.end.Add(gap)
+				moved = true
+			}
+		}
+	}
+	return slot
+}
+
+// noFreeSlotText is sent when a reminder asked for the nearest free time doesn't fit the day
+const noFreeSlotText = "До конца этого дня свободного времени не нашлось. Укажи время явно или выбери другой день."
+
+// maxConflictsShown is the most conflicting reminders named in a conflict note
+const maxConflictsShown = 3
+
+// conflictNote warns about the user's reminders closer than the configured conflict window
+// to a new reminder at [at, end], e.g. "⚠️ В это время у тебя уже есть: встреча (15:00)".
+// It returns "" if there are none or the check is disabled.
+func (b *ReminderBot) conflictNote(ctx context.Context, userID int64, at, end time.Time, excludeID int64) string {
+	window := b.config.ConflictWindow
+	if window <= 0 {
+		return ""
+	}
+	duration := time.Duration(0)
+	if end.After(at) {
+		duration = end.Sub(at)
+	}
+
+	// Look from the start of the day, as an earlier event window may still be running
+	busy, err := b.busyWindows(ctx, userID, core.StartOfDay(at.Add(-window)), at.Add(duration+window+time.Minute), excludeID)
+	if err != nil {
+		b.logger.Printf("Error checking reminder conflicts: %v", err)
+		return ""
+	}
+
+	var conflicts []string
+	for _, w := range busy {
+		if !w.near(at, duration, window) {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s (%s)", b.listLabel(w.label), formatBusyWindow(w)))
+	}
+	if len(conflicts) == 0 {
+		return ""
+	}
+	if len(conflicts) > maxConflictsShown {
+		conflicts = append(conflicts[:maxConflictsShown], fmt.Sprintf("и ещё %d", len(conflicts)-maxConflictsShown))
+	}
+	return "⚠️ В это время у тебя уже есть: " + strings.Join(conflicts, ", ")
+}
+
+// formatBusyWindow formats a window's time of day, e.g. "15:00" or "15:00–16:00"
+func formatBusyWindow(w busyWindow) string {
+	if w.end.After(w.start) {
+		return w.start.Format("15:04") + "–" + w.end.Format("15:04")
+	}
+	return w.start.Format("15:04")
+}
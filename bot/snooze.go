@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// defaultSnoozeMinutes is used until the user sets their own default with /snooze_default
+	defaultSnoozeMinutes = 15
+	// maxSnoozeMinutes is the longest default snooze a user can set
+	maxSnoozeMinutes = 24 * 60
+)
+
+// snoozeOptions are offered after the user's default snooze interval
+var snoozeOptions = []int{10, 30, 60}
+
+// userDefaultSnooze returns the user's default snooze interval in minutes
+func (b *ReminderBot) userDefaultSnooze(ctx context.Context, userID int64) int {
+	minutes, err := b.repo.GetUserDefaultSnoozeContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting default snooze for user %d: %v", userID, err)
+	}
+	if minutes <= 0 {
+		return defaultSnoozeMinutes
+	}
+	return minutes
+}
+
+// snoozeKeyboard builds the snooze buttons for a delivered reminder,
+// starting with the user's default interval
+func (b *ReminderBot) snoozeKeyboard(ctx context.Context, userID, reminderID int64) tgbotapi.InlineKeyboardMarkup {
+	options := []int{b.userDefaultSnooze(ctx, userID)}
+	for _, minutes := range snoozeOptions {
+		if minutes != options[0] {
+			options = append(options, minutes)
+		}
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, minutes := range options {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			"⏰ +"+formatSnoozeInterval(minutes),
+			fmt.Sprintf("snooze_%d_%d", reminderID, minutes),
+		))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// formatSnoozeInterval formats minutes as "15 мин" or "2 ч"
+func formatSnoozeInterval(minutes int) string {
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d ч", minutes/60)
+	}
+	return fmt.Sprintf("%d мин", minutes)
+}
+
+// handleSnoozeCallback re-schedules a delivered reminder
+func (b *ReminderBot) handleSnoozeCallback(query *tgbotapi.CallbackQuery) {
+	parts := strings.Split(strings.TrimPrefix(query.Data, "snooze_"), "_")
+	if len(parts) != 2 {
+		b.logger.Printf("Invalid snooze callback: %s", query.Data)
+		return
+	}
+
+	reminderID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing reminder ID from snooze callback: %v", err)
+		return
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes <= 0 {
+		b.logger.Printf("Error parsing snooze interval from callback: %s", query.Data)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.config.APITimeout)
+	defer cancel()
+
+	until, err := b.reminders.Snooze(ctx, reminderID, query.From.ID, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		b.logger.Printf("Error snoozing reminder: %v", err)
+		return
+	}
+
+	if until.IsZero() {
+		notification := tgbotapi.NewMessage(query.Message.Chat.ID, "Напоминание не найдено или не принадлежит вам.")
+		b.bot.Send(notification)
+		return
+	}
+
+	// Snoozing counts as seeing the reminder
+	if _, err := b.repo.AcknowledgeHistoryContext(ctx, query.Message.Chat.ID, query.Message.MessageID, query.From.ID, time.Now()); err != nil {
+		b.logger.Printf("Error acknowledging snoozed reminder: %v", err)
+	}
+
+	// Remove the snooze buttons so the reminder isn't snoozed twice
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error removing snooze buttons: %v", err)
+	}
+
+	reply := tgbotapi.NewMessage(query.Message.Chat.ID, fmt.Sprintf("⏰ Напомню снова в %s", until.Format("15:04")))
+	b.bot.Send(reply)
+
+	b.logger.Printf("Snoozed reminder ID=%d for %d minutes (user %d)", reminderID, minutes, query.From.ID)
+}
+
+// handleSnoozeDefaultCommand shows or sets the user's default snooze interval
+func (b *ReminderBot) handleSnoozeDefaultCommand(ctx context.Context, msg *tgbotapi.Message) {
+	args := strings.TrimSpace(msg.CommandArguments())
+	if args == "" {
+		text := fmt.Sprintf(`Сейчас напоминания по умолчанию откладываются на %s.
+
+Чтобы изменить, укажите число минут, например:
+/snooze_default 20`, formatSnoozeInterval(b.userDefaultSnooze(ctx, msg.From.ID)))
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+		return
+	}
+
+	minutes, err := strconv.Atoi(args)
+	if err != nil || minutes <= 0 || minutes > maxSnoozeMinutes {
+		reply := tgbotapi.NewMessage(msg.Chat.ID,
+			fmt.Sprintf("Укажите число минут от 1 до %d, например: /snooze_default 20", maxSnoozeMinutes))
+		b.bot.Send(reply)
+		return
+	}
+
+	if err := b.repo.SetUserDefaultSnoozeContext(ctx, msg.From.ID, minutes); err != nil {
+		b.logger.Printf("Error setting default snooze: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении настройки.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Готово! Теперь напоминания по умолчанию откладываются на %s.", formatSnoozeInterval(minutes)))
+	b.bot.Send(reply)
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"reminders21/llm"
+	"reminders21/storage"
+	"reminders21/utils"
+)
+
+// Errors from resolveSolarTime and applySolarSchedule
+var (
+	errNoLocation      = errors.New("user location unknown")
+	errNoSunEvent      = errors.New("no sunrise or sunset on that day")
+	errUnknownSunEvent = errors.New("unknown solar event")
+)
+
+// solarEventNames are the genitive Russian names of solar events
+var solarEventNames = map[string]string{
+	storage.SunriseEvent: "восхода",
+	storage.SunsetEvent:  "заката",
+}
+
+// userCoordinates returns the location the user shared, or the coordinates of a city
+// in their timezone. It reports false if neither is known.
+func (b *ReminderBot) userCoordinates(ctx context.Context, userID int64) (float64, float64, bool) {
+	lat, lon, ok, err := b.repo.GetUserLocationContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting location of user %d: %v", userID, err)
+	}
+	if ok {
+		return lat, lon, true
+	}
+
+	timezone, err := b.repo.GetUserTimezoneContext(ctx, userID)
+	if err != nil {
+		b.logger.Printf("Error getting timezone of user %d: %v", userID, err)
+		return 0, 0, false
+	}
+	return utils.TimezoneCoordinates(timezone)
+}
+
+// solarOffset parses op.Offset as minutes relative to the solar event; empty means 0
+func solarOffset(op llm.Operation) (int, error) {
+	if op.Offset == "" {
+		return 0, nil
+	}
+	days, duration, err := parseOffset(op.Offset)
+	if err != nil {
+		return 0, err
+	}
+	return days*24*60 + int(duration/time.Minute), nil
+}
+
+// resolveSolarTime computes the time of a one-time reminder relative to sunrise or sunset
+// on the day of op.Datetime (today if empty) at the user's location
+func (b *ReminderBot) resolveSolarTime(ctx context.Context, op llm.Operation, userID int64) (time.Time, error) {
+	if _, ok := solarEventNames[op.SolarEvent]; !ok {
+		return time.Time{}, errUnknownSunEvent
+	}
+
+	offset, err := solarOffset(op)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lat, lon, ok := b.userCoordinates(ctx, userID)
+	if !ok {
+		return time.Time{}, errNoLocation
+	}
+
+	day := b.reminders.Now(ctx, userID)
+	if op.Datetime != "" {
+		day, err = b.reminders.ParseLocalTime(ctx, userID, "2006-01-02 15:04:05", op.Datetime)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	at, ok := utils.SunEvent(day, lat, lon, op.SolarEvent == storage.SunriseEvent)
+	if !ok {
+		return time.Time{}, errNoSunEvent
+	}
+
+	return at.Add(time.Duration(offset) * time.Minute), nil
+}
+
+// applySolarSchedule makes a recurring reminder follow the sun at the user's location
+func (b *ReminderBot) applySolarSchedule(ctx context.Context, op llm.Operation, userID int64, item *storage.RecurringReminder) error {
+	if _, ok := solarEventNames[op.SolarEvent]; !ok {
+		return errUnknownSunEvent
+	}
+
+	offset, err := solarOffset(op)
+	if err != nil {
+		return err
+	}
+
+	lat, lon, ok := b.userCoordinates(ctx, userID)
+	if !ok {
+		return errNoLocation
+	}
+
+	item.SolarEvent = op.SolarEvent
+	item.SolarOffset = offset
+	item.Latitude = lat
+	item.Longitude = lon
+	item.Time = ""
+	return nil
+}
+
+// solarTimeText describes a time relative to a solar event, e.g. "за 1 ч до заката"
+func solarTimeText(event string, offset int) string {
+	name := solarEventNames[event]
+	switch {
+	case offset < 0:
+		return fmt.Sprintf("за %s до %s", formatSnoozeInterval(-offset), name)
+	case offset > 0:
+		return fmt.Sprintf("через %s после %s", formatSnoozeInterval(offset), name)
+	default:
+		return "в момент " + name
+	}
+}
+
+// recurrenceTimeText describes when on its day a recurring reminder occurs,
+// e.g. "в 09:00", "в 10:00–12:00", "за 1 ч до заката" or, for hourly ones, "с 09:00 до 18:00"
+func recurrenceTimeText(r storage.RecurringReminder) string {
+	if r.SolarEvent != "" {
+		return solarTimeText(r.SolarEvent, r.SolarOffset)
+	}
+	if r.RecurringType == storage.RecurringHourly {
+		if r.EndTime != "" {
+			return "с " + r.Time + " до " + r.EndTime
+		}
+		return "с " + r.Time
+	}
+	if r.EndTime != "" {
+		return "в " + r.Time + "–" + r.EndTime
+	}
+	return "в " + r.Time
+}
+
+// solarTimeErrorText explains why a reminder relative to sunrise or sunset couldn't be created
+func solarTimeErrorText(err error) string {
+	switch {
+	case errors.Is(err, errNoLocation):
+		return "Чтобы рассчитать восход и закат, мне нужно знать, где ты. Отправь геопозицию (📎 → Геопозиция) или укажи город: /timezone Москва"
+	case errors.Is(err, errNoSunEvent):
+		return "В этот день у тебя солнце не восходит или не заходит. Укажи время явно."
+	case errors.Is(err, errInvalidOffset):
+		return "Не понял, за сколько до восхода или заката напомнить. Например: «за час до заката»."
+	default:
+		return "Ошибка при расчёте времени восхода или заката."
+	}
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/utils"
+)
+
+// Hours ahead shown by /soon without an argument, and the most it shows
+const (
+	defaultSoonHours = 3
+	maxSoonHours     = 24
+)
+
+// handleSoonCommand shows the user's reminders and recurring occurrences due in the next
+// few hours, e.g. "/soon" or "/soon 6". Unlike /today it looks past midnight, so late in
+// the evening it shows what's coming up early tomorrow.
+func (b *ReminderBot) handleSoonCommand(ctx context.Context, msg *tgbotapi.Message) {
+	hours := defaultSoonHours
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /soon или /soon 6, чтобы показать напоминания на 6 часов вперёд.")
+			b.bot.Send(reply)
+			return
+		}
+		hours = min(n, maxSoonHours)
+	}
+
+	now := b.reminders.Now(ctx, msg.From.ID)
+	lines, err := b.soonLines(ctx, msg.From.ID, now, now.Add(time.Duration(hours)*time.Hour))
+	if err != nil {
+		b.logger.Printf("Error getting reminders due soon: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении ближайших напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	period := fmt.Sprintf("%d %s", hours, utils.PluralRussian(hours, "час", "часа", "часов"))
+	if len(lines) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("В ближайшие %s напоминаний нет.", period))
+		b.bot.Send(reply)
+		return
+	}
+
+	text := fmt.Sprintf("Ближайшие %s:\n%s", period, strings.Join(lines, "\n"))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// soonLines formats the user's one-time reminders and recurring occurrences in [now, end),
+// sorted by time. Todos have no time and are left out; times on a later day than now are
+// marked "завтра".
+func (b *ReminderBot) soonLines(ctx context.Context, userID int64, now, end time.Time) ([]string, error) {
+	reminders, err := b.reminders.ListBetween(ctx, userID, now, end)
+	if err != nil {
+		return nil, err
+	}
+	recurring, err := b.reminders.ListRecurring(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := func(t time.Time) string {
+		if utils.CalendarDaysBetween(now, t) == 1 {
+			return "завтра " + t.Format("15:04")
+		}
+		return t.Format("15:04")
+	}
+
+	var entries []listEntry
+	for _, r := range reminders {
+		if r.IsTodo {
+			continue
+		}
+		line := fmt.Sprintf("%s %s – %s", categoryIcon(r.Category), at(r.ReminderTime), b.listLabel(r.Label))
+		entries = append(entries, listEntry{at: r.ReminderTime, line: line})
+	}
+	for _, r := range recurring {
+		if r.IsTodo || !r.Enabled {
+			continue
+		}
+		// An hourly reminder can occur every hour of the window
+		for _, t := range r.NextOccurrences(now, maxSoonHours+1) {
+			if !t.Before(end) {
+				break
+			}
+			line := fmt.Sprintf("%s %s – %s (регулярное)", categoryIcon(r.Category), at(t), b.listLabel(r.Label))
+			entries = append(entries, listEntry{at: t, line: line})
+		}
+	}
+
+	return sortedLines(entries), nil
+}
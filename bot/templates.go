@@ -0,0 +1,254 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
+	"reminders21/llm"
+	"reminders21/storage"
+)
+
+// maxTemplateNameLength is the longest template name in characters
+const maxTemplateNameLength = 32
+
+// templateUsageText explains the /template subcommands
+const templateUsageText = `Шаблоны помогают быстро создавать похожие напоминания:
+• /template save зарядка – сохранить последнее созданное напоминание как шаблон «зарядка»
+• /template use зарядка 18:30 – создать напоминание по шаблону (время: 18:30, 25.12 18:30 или 25.12.2025 18:30)
+• /template delete зарядка – удалить шаблон
+• /templates – список шаблонов`
+
+// Errors from parseTemplateTime
+var (
+	errTemplateTimeFormat = errors.New("invalid template time")
+	errTemplateTimePassed = errors.New("template time has passed")
+)
+
+// handleTemplateCommand saves, uses and deletes reminder templates, e.g. "/template save
+// зарядка" or "/template use зарядка 18:30"
+func (b *ReminderBot) handleTemplateCommand(ctx context.Context, msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 2 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, templateUsageText)
+		b.bot.Send(reply)
+		return
+	}
+
+	name := strings.ToLower(args[1])
+	switch strings.ToLower(args[0]) {
+	case "save":
+		b.saveTemplate(ctx, msg, name)
+	case "use":
+		b.useTemplate(ctx, msg, name, strings.Join(args[2:], " "))
+	case "delete":
+		deleted, err := b.repo.DeleteTemplateContext(ctx, msg.From.ID, name)
+		if err != nil {
+			b.logger.Printf("Error deleting template: %v", err)
+			reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при удалении шаблона.")
+			b.bot.Send(reply)
+			return
+		}
+		text := fmt.Sprintf("Шаблон «%s» удалён.", name)
+		if !deleted {
+			text = fmt.Sprintf("Шаблона «%s» нет. Список шаблонов: /templates", name)
+		}
+		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+		b.bot.Send(reply)
+	default:
+		reply := tgbotapi.NewMessage(msg.Chat.ID, templateUsageText)
+		b.bot.Send(reply)
+	}
+}
+
+// saveTemplate saves the label and recurrence of the user's last created reminder as a template
+func (b *ReminderBot) saveTemplate(ctx context.Context, msg *tgbotapi.Message, name string) {
+	if utf8.RuneCountInString(name) > maxTemplateNameLength {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Название шаблона должно быть не длиннее %d символов.", maxTemplateNameLength))
+		b.bot.Send(reply)
+		return
+	}
+
+	template, err := b.repo.GetLastCreatedTemplateContext(ctx, msg.From.ID)
+	if errors.Is(err, storage.ErrReminderNotFound) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Сначала создайте напоминание – шаблон сохраняется из последнего созданного.")
+		b.bot.Send(reply)
+		return
+	}
+	if err != nil {
+		b.logger.Printf("Error getting last reminder for template: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении шаблона.")
+		b.bot.Send(reply)
+		return
+	}
+
+	template.Name = name
+	if err := b.repo.SaveTemplateContext(ctx, *template); err != nil {
+		b.logger.Printf("Error saving template: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при сохранении шаблона.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Printf("Saved template %q for user %d", name, msg.From.ID)
+
+	text := fmt.Sprintf("Шаблон «%s» сохранён: %s\nСоздать по нему напоминание: /template use %s %s",
+		name, templateText(*template), name, templateTimeExample(*template))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
+
+// useTemplate creates a reminder from the user's template at the given time. Recurring
+// templates take a time of day and default to the saved one.
+func (b *ReminderBot) useTemplate(ctx context.Context, msg *tgbotapi.Message, name, at string) {
+	template, err := b.repo.GetTemplateContext(ctx, msg.From.ID, name)
+	if errors.Is(err, storage.ErrTemplateNotFound) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Шаблона «%s» нет. Список шаблонов: /templates", name))
+		b.bot.Send(reply)
+		return
+	}
+	if err != nil {
+		b.logger.Printf("Error getting template: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении шаблона.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if template.RecurringType != "" {
+		if at == "" {
+			at = template.Time
+		}
+		b.processCreateRecurringOperation(llm.Operation{
+			Action:        "create_recurring",
+			Label:         template.Label,
+			IsTodo:        template.IsTodo,
+			RecurringType: string(template.RecurringType),
+			Time:          at,
+			DayOfWeek:     strconv.Itoa(template.DayOfWeek),
+			DayOfMonth:    strconv.Itoa(template.DayOfMonth),
+			WeekOfMonth:   strconv.Itoa(template.WeekOfMonth),
+		}, msg, nil)
+		return
+	}
+
+	reminderTime, dateOnly, err := parseTemplateTime(b.reminders.Now(ctx, msg.From.ID), at)
+	if errors.Is(err, errTemplateTimePassed) && !template.IsTodo {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Это время уже прошло. Укажите время в будущем.")
+		b.bot.Send(reply)
+		return
+	}
+	if errors.Is(err, errTemplateTimeFormat) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Укажите время: /template use %s 18:30, /template use %s 25.12 18:30 или /template use %s 25.12", name, name, name))
+		b.bot.Send(reply)
+		return
+	}
+
+	b.processCreateOperation(llm.Operation{
+		Action:   "create",
+		Label:    template.Label,
+		IsTodo:   template.IsTodo,
+		Datetime: reminderTime.Format("2006-01-02 15:04:05"),
+		DateOnly: dateOnly,
+	}, msg, nil)
+}
+
+// parseTemplateTime parses the time given to /template use in now's location: "18:30"
+// (today, or tomorrow if it has passed), "25.12 18:30" (this year, or the next one if it
+// has passed), "25.12.2025 18:30", or a date alone, in which case dateOnly is true.
+// A passed time is returned along with errTemplateTimePassed.
+func parseTemplateTime(now time.Time, value string) (t time.Time, dateOnly bool, err error) {
+	loc := now.Location()
+
+	if clock, err := time.ParseInLocation("15:04", value, loc); err == nil {
+		t = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, false, nil
+	}
+
+	for _, layout := range []string{"02.01 15:04", "02.01"} {
+		if day, err := time.ParseInLocation(layout, value, loc); err == nil {
+			dateOnly = layout == "02.01"
+			t = time.Date(now.Year(), day.Month(), day.Day(), day.Hour(), day.Minute(), 0, 0, loc)
+			if dateOnly && t.Before(core.StartOfDay(now)) || !dateOnly && !t.After(now) {
+				t = t.AddDate(1, 0, 0)
+			}
+			return t, dateOnly, nil
+		}
+	}
+
+	for _, layout := range []string{"02.01.2006 15:04", "02.01.2006"} {
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			dateOnly = layout == "02.01.2006"
+			if dateOnly && t.Before(core.StartOfDay(now)) || !dateOnly && !t.After(now) {
+				return t, dateOnly, errTemplateTimePassed
+			}
+			return t, dateOnly, nil
+		}
+	}
+
+	return time.Time{}, false, errTemplateTimeFormat
+}
+
+// templateText describes a template, e.g. "зарядка (каждый день в 07:00)"
+func templateText(template storage.Template) string {
+	if template.RecurringType == "" {
+		if template.IsTodo {
+			return template.Label + " (задача)"
+		}
+		return template.Label
+	}
+
+	schedule := recurrenceScheduleText(storage.RecurringReminder{
+		RecurringType: template.RecurringType,
+		DayOfWeek:     template.DayOfWeek,
+		DayOfMonth:    template.DayOfMonth,
+		WeekOfMonth:   template.WeekOfMonth,
+	})
+	if template.IsTodo {
+		return fmt.Sprintf("%s (регулярная задача, %s)", template.Label, schedule)
+	}
+	return fmt.Sprintf("%s (%s в %s)", template.Label, schedule, template.Time)
+}
+
+// templateTimeExample is the time shown in the /template use hint for a template
+func templateTimeExample(template storage.Template) string {
+	if template.RecurringType != "" && template.Time != "" {
+		return template.Time
+	}
+	return "18:30"
+}
+
+// handleTemplatesCommand lists the user's templates
+func (b *ReminderBot) handleTemplatesCommand(ctx context.Context, msg *tgbotapi.Message) {
+	templates, err := b.repo.GetTemplatesContext(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting templates: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка шаблонов.")
+		b.bot.Send(reply)
+		return
+	}
+
+	if len(templates) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Шаблонов пока нет.\n\n"+templateUsageText)
+		b.bot.Send(reply)
+		return
+	}
+
+	var lines []string
+	for _, template := range templates {
+		lines = append(lines, fmt.Sprintf("• %s – %s", template.Name, b.listLabel(templateText(template))))
+	}
+
+	text := "Ваши шаблоны:\n" + strings.Join(lines, "\n") + "\n\nСоздать напоминание по шаблону: /template use название время"
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	b.bot.Send(reply)
+}
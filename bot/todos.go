@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/core"
+)
+
+// handleTodosCommand lists the user's active one-time todos with buttons to complete them
+func (b *ReminderBot) handleTodosCommand(ctx context.Context, msg *tgbotapi.Message) {
+	text, keyboard, err := b.todosView(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting todos: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка задач.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	if keyboard != nil {
+		reply.ReplyMarkup = *keyboard
+	}
+	b.bot.Send(reply)
+}
+
+// todosView builds the /todos checklist and its buttons. The keyboard is nil if there
+// are no todos.
+func (b *ReminderBot) todosView(ctx context.Context, userID int64) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	todos, err := b.reminders.Todos(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(todos) == 0 {
+		return "Активных задач нет 🎉\n\nДобавить: «Добавь в список: купить цветы в пятницу»", nil, nil
+	}
+
+	today := core.StartOfDay(b.reminders.Now(ctx, userID))
+	var lines []string
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, todo := range todos {
+		line := fmt.Sprintf("☐ %s – %s", todo.ReminderTime.Format("02.01"), b.listLabel(todo.Label))
+		if todo.ReminderTime.Before(today) {
+			line += " (просрочено)"
+		}
+		lines = append(lines, line)
+
+		if len(rows) < maxListButtons {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				"✅ "+truncateText(todo.Label, fullTextButtonLength), fmt.Sprintf("todo_done_%d", todo.ID))))
+		}
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return "Ваши задачи:\n" + strings.Join(lines, "\n"), &keyboard, nil
+}
+
+// handleTodoDoneCallback marks a todo from the /todos list as done and updates the list.
+// Callback data format: todo_done_<id>
+func (b *ReminderBot) handleTodoDoneCallback(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+
+	todoID, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "todo_done_"), 10, 64)
+	if err != nil {
+		b.logger.Printf("Error parsing todo ID from callback: %v", err)
+		return
+	}
+
+	completed, err := b.reminders.CompleteTodo(ctx, todoID, query.From.ID)
+	if err != nil {
+		b.logger.Printf("Error completing todo: %v", err)
+		return
+	}
+	if completed {
+		b.logger.Printf("Completed todo ID=%d for user %d", todoID, query.From.ID)
+	}
+
+	text, keyboard, err := b.todosView(ctx, query.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting todos: %v", err)
+		return
+	}
+
+	var edit tgbotapi.EditMessageTextConfig
+	if keyboard != nil {
+		edit = tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, *keyboard)
+	} else {
+		edit = tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	}
+	if _, err := b.bot.Request(edit); err != nil {
+		b.logger.Printf("Error editing message: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"reminders21/storage"
+)
+
+// transferOfferTTL is how long the recipient of a transfer has to accept it
+const transferOfferTTL = 24 * time.Hour
+
+// handleTransferCommand offers another user to take over all of the sender's reminders,
+// e.g. "/transfer @username" or "/transfer 123456789". Nothing moves until the recipient
+// accepts with the button the bot sends them in private messages.
+func (b *ReminderBot) handleTransferCommand(ctx context.Context, msg *tgbotapi.Message) {
+	arg := strings.TrimSpace(msg.CommandArguments())
+	if arg == "" {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Использование: /transfer @username или /transfer ID пользователя. Все ваши напоминания перейдут получателю, когда тот примет передачу.")
+		b.bot.Send(reply)
+		return
+	}
+
+	toUserID, ok := b.transferRecipient(ctx, arg)
+	if !ok {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf(
+			"Не знаю пользователя %s. Укажите его числовой ID или @username участника группы, где есть я.", arg))
+		b.bot.Send(reply)
+		return
+	}
+	if toUserID == msg.From.ID {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Нельзя передать напоминания самому себе.")
+		b.bot.Send(reply)
+		return
+	}
+
+	reminders, err := b.reminders.List(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении списка напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+	recurring, err := b.reminders.ListRecurring(ctx, msg.From.ID)
+	if err != nil {
+		b.logger.Printf("Error getting recurring reminders: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при получении повторяющихся напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+	if len(reminders) == 0 && len(recurring) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Передавать нечего: у вас нет активных напоминаний.")
+		b.bot.Send(reply)
+		return
+	}
+
+	token, err := b.repo.CreateTransferOfferContext(ctx, msg.From.ID, toUserID, time.Now().Add(transferOfferTTL))
+	if err != nil {
+		b.logger.Printf("Error saving transfer offer: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Ошибка при создании запроса на передачу.")
+		b.bot.Send(reply)
+		return
+	}
+
+	from := msg.From.FirstName
+	if msg.From.UserName != "" {
+		from += " (@" + msg.From.UserName + ")"
+	}
+	offer := tgbotapi.NewMessage(toUserID, fmt.Sprintf(
+		"%s хочет передать вам свои напоминания: %s. После передачи они будут приходить вам.\n\nПринять?",
+		from, transferCountsText(int64(len(reminders)), int64(len(recurring)))))
+	offer.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Принять", "transfer_accept_"+token),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", "transfer_decline_"+token),
+		),
+	)
+	// In private chats the chat ID equals the user ID
+	if _, err := b.bot.Send(offer); err != nil {
+		b.logger.Printf("Error sending transfer offer from %d to %d: %v", msg.From.ID, toUserID, err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "Не могу написать этому пользователю: пусть сначала начнёт диалог со мной командой /start.")
+		b.bot.Send(reply)
+		return
+	}
+
+	b.logger.Info("reminders_transfer_offered", "user_id", msg.From.ID, "to_user_id", toUserID)
+	reply := tgbotapi.NewMessage(msg.Chat.ID,
+		"Запрос на передачу отправлен. Напоминания перейдут, когда получатель примет его (в течение суток).")
+	b.bot.Send(reply)
+}
+
+// transferRecipient resolves the recipient of a transfer from a user ID or a @username
+// seen in a group chat
+func (b *ReminderBot) transferRecipient(ctx context.Context, arg string) (int64, bool) {
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		return id, id > 0
+	}
+
+	userID, ok, err := b.repo.FindUserByUsernameContext(ctx, strings.TrimPrefix(arg, "@"))
+	if err != nil {
+		b.logger.Printf("Error finding user %s: %v", arg, err)
+	}
+	return userID, ok
+}
+
+// handleTransferCallback handles the recipient's answer to a transfer offer:
+// "transfer_accept_<token>" or "transfer_decline_<token>". The offer is looked up by its
+// token and consumed, so only its recipient can answer it, and only once.
+func (b *ReminderBot) handleTransferCallback(query *tgbotapi.CallbackQuery) {
+	ctx := context.Background()
+	action, token, _ := strings.Cut(strings.TrimPrefix(query.Data, "transfer_"), "_")
+	toUserID := query.From.ID
+
+	fromUserID, ok, err := b.repo.TakeTransferOfferContext(ctx, token, toUserID)
+	if err != nil {
+		b.logger.Printf("Error getting transfer offer: %v", err)
+		return
+	}
+
+	answer := func(recipientText, senderText string) {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, recipientText)
+		if _, err := b.bot.Request(edit); err != nil {
+			b.logger.Printf("Error editing message: %v", err)
+		}
+		if senderText != "" {
+			b.bot.Send(tgbotapi.NewMessage(fromUserID, senderText))
+		}
+	}
+
+	if !ok {
+		answer("Этот запрос уже не действует: он истёк или на него уже ответили. Попросите отправить его снова.", "")
+		return
+	}
+
+	if action != "accept" {
+		b.logger.Info("reminders_transfer_declined", "user_id", fromUserID, "to_user_id", toUserID)
+		answer("Вы отклонили передачу напоминаний.", "Получатель отклонил передачу напоминаний, они остались у вас.")
+		return
+	}
+
+	reminders, recurring, err := b.reminders.TransferReminders(ctx, fromUserID, toUserID)
+	if errors.Is(err, storage.ErrLimitReached) {
+		answer("Не получилось: с этими напоминаниями у вас будет больше, чем позволяет лимит. Удалите ненужные и попросите отправить запрос снова.",
+			"Передача не удалась: у получателя не хватает места в лимите напоминаний.")
+		return
+	}
+	if err != nil {
+		b.logger.Printf("Error transferring reminders from %d to %d: %v", fromUserID, toUserID, err)
+		answer("Ошибка при передаче напоминаний.", "")
+		return
+	}
+
+	b.logger.Info("reminders_transferred", "user_id", fromUserID, "to_user_id", toUserID,
+		"reminders", reminders, "recurring", recurring)
+	counts := transferCountsText(reminders, recurring)
+	answer("✅ Теперь это ваши напоминания: "+counts+". Посмотреть: /list и /recurring",
+		"✅ Получатель принял передачу напоминаний: "+counts+".")
+}
+
+// transferCountsText describes how many reminders are transferred, e.g. "разовых – 3, регулярных – 1"
+func transferCountsText(reminders, recurring int64) string {
+	return fmt.Sprintf("разовых – %d, регулярных – %d", reminders, recurring)
+}
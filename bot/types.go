@@ -2,11 +2,16 @@ package bot
 
 import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"log"
+	"net/http"
+	"reminders21/api"
 	"reminders21/config"
+	"reminders21/core"
 	"reminders21/llm"
+	"reminders21/logging"
 	"reminders21/speech"
 	"reminders21/storage"
+	"sync"
+	"time"
 )
 
 // The LLM prompt template
@@ -14,6 +19,7 @@ const llmPrompt = `
 Текущее время: %s
 
 Анализируй следующий запрос пользователя. Пользователь может просить создать, изменить или удалить несколько напоминаний в одном запросе, либо показать список своих напоминаний.
+Если запрос содержит одну операцию, вызови подходящую функцию. Если операций несколько, не вызывай функции, а верни JSON со всеми операциями.
 
 Пользователь также может создавать задачи (todo) без напоминаний. Задачи отличаются от напоминаний тем, что они не требуют указания времени и не будут отправлять уведомления. Если запрос похож на задачу ("купить цветы в пятницу", "позвонить маме каждый день"), то:
 - Установи флаг "is_todo" в true.
@@ -27,17 +33,33 @@ const llmPrompt = `
 
 Если запрос на создание обычного напоминания, то:
 - Извлеки дату и время напоминания ("datetime" в формате "2006-01-02 15:04:05"). Если дата не указана, используй сегодняшнюю. Пользователь может использовать относительные обозначения (например, "сегодня", "завтра", "через 10 минут", "после 1 часа") – рассчитай время на основе текущего времени.
+- Если время неоднозначно и из контекста непонятно, утро это или вечер (например, "в 8" – это 08:00 или 20:00), не выбирай сам: перечисли варианты в "candidate_datetimes" (в формате "2006-01-02 15:04:05", только будущие), а "datetime" оставь пустым. Пользователь выберет время кнопкой. Если вариант очевиден ("в 8 утра", "в 3 ночи", "в 8" когда 8 утра уже прошло и речь о сегодня), укажи только "datetime".
+- Если указана только дата без времени (например, "напомни 5 марта купить подарок"), поставь время 00:00 и установи флаг "date_only" в true. Если пользователь явно сказал "в полночь" или "в 00:00", флаг не ставь.
 - Извлеки текст напоминания ("label").
+- Если указана продолжительность или время окончания события (например, "совещание на 2 часа", "с 10 до 11"), рассчитай время окончания ("end_datetime" в формате "2006-01-02 15:04:05"). Иначе оставь "end_datetime" пустым.
+- Если время задано относительно другого напоминания из списка пользователя (например, "через 3 дня после встречи с юристом", "за час до совещания"), укажи его ID в "relative_to_reminder_id" и смещение от его времени в "offset" (например, "3d", "2h", "1d2h30m", "-1h"), а "datetime" оставь пустым.
+- Если срок указан в рабочих днях (например, "через 3 рабочих дня"), укажи их число в "business_days", а в "datetime" – сегодняшнюю дату и нужное время (или 00:00 с флагом "date_only", если время не указано). Дату с учётом выходных и праздников вычислит бот, поэтому "answer" оставь пустым.
+- Если срок указан в месяцах или годах (например, "через месяц", "через 3 месяца", "через год"), не считай дату сам: укажи число календарных месяцев в "months" (год – 12), а в "datetime" – сегодняшнюю дату и нужное время (или 00:00 с флагом "date_only", если время не указано). Дату с учётом длины месяцев вычислит бот, поэтому "answer" оставь пустым.
+- Если пользователь просит ближайшее свободное время (например, "на ближайшее свободное время после 15:00", "в первое свободное окно сегодня"), укажи в "datetime" самое раннее подходящее время (сейчас, если не указано) и установи флаг "free_slot" в true. Свободное время среди напоминаний подберёт бот, поэтому "answer" оставь пустым.
+- Если напомнить нужно несколько раз подряд через равные промежутки (например, "напомни 5 раз каждые 3 минуты", "3 раза с интервалом в час"), создай одну операцию: в "datetime" – время первого напоминания (сейчас плюс интервал, если не указано), в "repeat" – число повторов, в "repeat_interval" – интервал, например "3m", "1h". Времена вычислит бот, поэтому "answer" оставь пустым.
+- Если время задано относительно восхода или заката (например, "за час до заката", "на рассвете"), укажи "solar_event": "sunrise" или "sunset" и смещение в "offset" (например, "-1h", "30m"; пусто – ровно в момент события). В "datetime" укажи только нужную дату, время в нём не важно.
+- Если пользователь просит напомнить тихо, без звука или не беспокоить (например, "тихо напомни", "без звука"), установи флаг "silent" в true.
+- Определи категорию ("category"): "work" (работа), "health" (здоровье, лекарства, врачи), "shopping" (покупки), "home" (дом, быт), "family" (семья, дети), "finance" (деньги, платежи), "study" (учёба), "sport" (спорт), "travel" (поездки). Если ни одна не подходит, оставь пустым.
 - Укажи действие "create".
 - Установи флаг "is_todo" в false.
 - Сгенерируй ответ на русском в неформальном, но вежливом стиле, например: "Окей, я запомнил, что [label] в [время]."
 
 Если запрос на создание повторяющегося напоминания, то:
-- Распознай тип повторения ("recurring_type"): "daily" (каждый день), "weekly" (каждую неделю), "monthly" (каждый месяц).
+- Распознай тип повторения ("recurring_type"): "hourly" (каждый час или каждые несколько часов), "daily" (каждый день), "weekly" (каждую неделю), "monthly" (каждый месяц в определённое число), "monthly_weekday" (каждый месяц в определённый по счёту день недели, например "в первый понедельник", "в последнюю пятницу", "в последний рабочий день месяца").
 - Извлеки время ("time" в формате "15:04").
-- Для weekly: укажи день недели ("day_of_week": 0-6, где 0=воскресенье, 1=понедельник и т.д.).
+- Для weekly: укажи день недели ("day_of_week": 0-6, где 0=воскресенье, 1=понедельник и т.д.). "По будням" – это weekly с "day_of_week": 7, "по выходным" – weekly с "day_of_week": 8.
 - Для monthly: укажи день месяца ("day_of_month": 1-31).
+- Если указан промежуток времени (например, "с 10 до 12 каждый день"), укажи начало в "time", а окончание в "end_time" (формат "15:04"). Напоминание придёт в начале промежутка.
+- Для monthly_weekday: укажи день недели ("day_of_week": 0-6, или 7 для рабочего дня) и его номер в месяце ("week_of_month": 1-5, или -1 для последнего).
+- Для hourly: укажи, через сколько часов повторять ("interval_hours": 1-12), с какого времени ("time") и до какого ("end_time", если указано). Например, "каждые 2 часа с 9 до 18" – "time": "09:00", "end_time": "18:00", "interval_hours": 2. Если начало не указано, начни с ближайшего целого часа.
+- Если время задано относительно восхода или заката (например, "каждый день за 30 минут до заката"), укажи "solar_event" и "offset" так же, как для обычного напоминания, а "time" оставь пустым.
 - Извлеки текст напоминания ("label").
+- Флаг "silent" и категорию ("category") ставь так же, как для обычного напоминания.
 - Укажи действие "create_recurring".
 - Установи флаг "is_todo" в false, если не указано явно, что это задача без напоминания.
 - Сгенерируй ответ, например: "Создал регулярное напоминание о [label] [периодичность]. При генерации соблюдай грамматику русского языка."
@@ -46,40 +68,73 @@ const llmPrompt = `
 • Извлеки reminder_id напоминания, которое нужно изменить (выбери самое подходящее из списка).
 • Для обычных напоминаний ID - это число, для повторяющихся - строка вида "rec_NUMBER".
 • Извлеки новые дату/время (необязательно) и/или новый текст ("label") (необязательно).
+• Если время сдвигается относительно текущего ("на час раньше", "на 30 минут позже", "на день вперёд"), не вычисляй новое время сам: укажи "time_delta", например "-1h", "30m", "1d", "-1d2h", без "datetime" и "time".
+• Если напоминание нужно перенести на ближайшее свободное время ("перенеси на ближайшее свободное время после обеда"), укажи в "datetime" самое раннее подходящее время и установи флаг "free_slot" в true, а "answer" оставь пустым.
 • Для повторяющихся напоминаний: можно изменить тип повторения, день недели, день месяца или время.
+• Если под запрос одинаково хорошо подходят несколько напоминаний, перечисли их ID в "candidate_ids" вместо выбора одного.
 • Укажи действие "adjust".
 • Сгенерируй ответ, например: "Окей, я поменял напоминание."
 
 Если запрос на удаление напоминания, то:
 • Извлеки reminder_id напоминания, которое нужно удалить (выбери самое подходящее из списка).
 • Для обычных напоминаний ID - это число, для повторяющихся - строка вида "rec_NUMBER".
+• Если под запрос одинаково хорошо подходят несколько напоминаний, перечисли их ID в "candidate_ids" вместо выбора одного.
 • Укажи действие "delete".
 • Сгенерируй ответ, например: "Окей, напоминание удалено."
 
+Если просят пропустить один раз регулярного напоминания, не удаляя его (например, "не напоминай про йогу в этот четверг", "завтра планёрки не будет"), то:
+• Извлеки reminder_id регулярного напоминания (строка вида "rec_NUMBER").
+• Укажи дату пропускаемого повторения в "skip_date" в формате "2006-01-02".
+• Укажи действие "skip".
+
 Если запрос на показ списка обычных напоминаний, то:
 • Укажи действие "show_list".
 • Если пользователь задал период (например, "скажи дела на сегодня"), включи в ответ поля "start_date" и "end_date" (в формате "2006-01-02"). Если указана только start_date, значит запрос на конкретный день.
+• Если просят показать напоминания, которые создал другой участник группы (например, "покажи напоминания, которые создал Иван"), укажи его имя в поле "created_by" как в запросе, например "Иван" или "@ivan".
 • Сгенерируй ответ, например: "Вот твои напоминания."
 
 Если запрос на показ списка повторяющихся напоминаний, то:
 • Укажи действие "show_recurring".
+• Если пользователь спрашивает только о части напоминаний, укажи фильтр: "recurring_type" (например, "какие у меня ежемесячные напоминания" → "monthly") и/или "day_of_week" (например, "что у меня по понедельникам" → "1"). Без уточнений оставь эти поля пустыми.
 • Сгенерируй ответ, например: "Вот твои повторяющиеся напоминания."
+
+Если непонятно, что нужно сделать, или не хватает данных (например, не ясно, когда напомнить), то:
+• Укажи действие "clarify".
+• Задай в "answer" короткий уточняющий вопрос, например: "Во сколько напомнить про встречу?"
 	
 Выходной JSON должен иметь следующую структуру:
 {
   "operations": [
     {
-      "action": "create|create_recurring|adjust|delete|show_list|show_recurring",
+      "action": "create|create_recurring|adjust|delete|skip|show_list|show_recurring|clarify",
       "datetime": "2006-01-02 15:04:05",
+      "end_datetime": "2006-01-02 15:04:05",
       "label": "string",
       "reminder_id": "string",
       "answer": "string",
       "start_date": "2006-01-02",
       "end_date": "2006-01-02",
-      "recurring_type": "daily|weekly|monthly",
+      "recurring_type": "hourly|daily|weekly|monthly|monthly_weekday",
       "time": "15:04",
-      "day_of_week": "0-6",
-      "day_of_month": "1-31"
+      "end_time": "15:04",
+      "day_of_week": "0-8",
+      "day_of_month": "1-31",
+      "week_of_month": "1-5 или -1",
+      "interval_hours": 0,
+      "candidate_ids": ["string"],
+      "skip_date": "2006-01-02",
+      "candidate_datetimes": ["2006-01-02 15:04:05"],
+      "date_only": false,
+      "silent": false,
+      "business_days": 0,
+      "months": 0,
+      "free_slot": false,
+      "category": "work|health|shopping|home|family|finance|study|sport|travel",
+      "repeat": 0,
+      "repeat_interval": "3m",
+      "relative_to_reminder_id": "string",
+      "offset": "1d2h30m",
+      "solar_event": "sunrise|sunset"
     }
   ],
   "user_reminders": [
@@ -93,9 +148,13 @@ const llmPrompt = `
 }
 
 При распознавании повторяющихся напоминаний, обрати внимание:
+• "Каждый час", "каждые 3 часа", "ежечасно" → recurring_type: "hourly"
 • "Каждый день" или "ежедневно" → recurring_type: "daily"
 • "Каждую неделю", "каждый вторник", "еженедельно" → recurring_type: "weekly"
+• "По будням", "каждый рабочий день" → recurring_type: "weekly", day_of_week: "7"
+• "По выходным", "каждые выходные" → recurring_type: "weekly", day_of_week: "8"
 • "Каждый месяц", "каждого 15 числа", "ежемесячно" → recurring_type: "monthly"
+• "Каждый первый понедельник", "в последний рабочий день месяца" → recurring_type: "monthly_weekday"
 
 При использовании русских дней недели, преобразуй их в числовые значения:
 • Воскресенье → 0
@@ -113,11 +172,33 @@ const llmPrompt = `
 
 // ReminderBot represents the Telegram bot
 type ReminderBot struct {
-	config      *config.Config
-	bot         *tgbotapi.BotAPI
-	repo        *storage.ReminderRepository
-	llmClient   *llm.OpenAIClient
-	transcriber *speech.Transcriber
-	logger      *log.Logger
-	stopChan    chan struct{}
+	config         *config.Config
+	bot            *tgbotapi.BotAPI
+	repo           *storage.ReminderRepository
+	reminders      core.Reminders
+	llmClient      llm.LLMParser
+	transcriber    speech.Transcriber
+	logger         logging.Logger
+	stopChan       chan struct{}
+	pending        *pendingOperations
+	dialogs        *dialogState
+	transcriptions *transcriptions
+	ffmpegPath     string      // Resolved path to ffmpeg, empty if it isn't available
+	apiServer      *api.Server // nil unless the HTTP API is enabled
+
+	webhookServer *http.Server // nil when receiving updates with long polling
+
+	// outboxLock serializes flushOutbox, which both reminder checkers call,
+	// so a queued message isn't sent twice
+	outboxLock sync.Mutex
+
+	// sendPausedUntil is set when Telegram rate-limits reminder delivery.
+	// It is guarded by outboxLock.
+	sendPausedUntil time.Time
+
+	// chatMembers caches the member names last saved by rememberChatMember
+	chatMembers sync.Map // chatMemberKey -> storage.ChatMember
+
+	// lastActive caches when updateLastActive last recorded each user's activity
+	lastActive sync.Map // user ID -> time.Time
 }
@@ -1,6 +1,8 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"io"
@@ -8,6 +10,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // downloadTelegramFile downloads a file from Telegram
@@ -44,18 +48,76 @@ func (b *ReminderBot) downloadTelegramFile(fileID string) (string, error) {
 // extractAudioFromVideo extracts audio from video using ffmpeg
 func (b *ReminderBot) extractAudioFromVideo(videoPath string) (string, error) {
 	// Create temporary file for audio
-	tmpAudio, err := os.CreateTemp("", "audio-*.ogg")
+	tmpAudio, err := os.CreateTemp("", "audio-*."+b.config.FFmpegAudioFormat)
 	if err != nil {
 		return "", fmt.Errorf("failed to create temporary audio file: %w", err)
 	}
 	tmpAudio.Close()
 
 	// Extract audio using ffmpeg
-	cmd := exec.Command("ffmpeg", "-i", videoPath, "-vn", "-acodec", "libopus", "-f", "ogg", tmpAudio.Name())
-	if err := cmd.Run(); err != nil {
+	cmd := exec.Command(b.ffmpegPath, "-y", "-i", videoPath, "-vn",
+		"-acodec", b.config.FFmpegAudioCodec, "-f", b.config.FFmpegAudioFormat, tmpAudio.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
 		os.Remove(tmpAudio.Name())
-		return "", fmt.Errorf("failed to extract audio with ffmpeg: %w", err)
+		return "", fmt.Errorf("failed to extract audio with ffmpeg: %w: %s", err, lastLines(string(output), 3))
 	}
 
 	return tmpAudio.Name(), nil
 }
+
+// convertToWAV converts an audio file to 16 kHz mono WAV with ffmpeg, the format every
+// transcription server accepts
+func (b *ReminderBot) convertToWAV(ctx context.Context, audioPath string) (string, error) {
+	tmpAudio, err := os.CreateTemp("", "audio-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary audio file: %w", err)
+	}
+	tmpAudio.Close()
+
+	cmd := exec.CommandContext(ctx, b.ffmpegPath, "-y", "-i", audioPath, "-vn",
+		"-ac", "1", "-ar", "16000", "-f", "wav", tmpAudio.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmpAudio.Name())
+		return "", fmt.Errorf("failed to convert audio with ffmpeg: %w: %s", err, lastLines(string(output), 3))
+	}
+
+	return tmpAudio.Name(), nil
+}
+
+// lastLines returns the last n lines of s, e.g. the error summary of a command's output
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeText escapes user-provided text so Telegram shows it literally in the given
+// parse mode. Plain text (an empty parse mode) is returned unchanged.
+func escapeText(parseMode, text string) string {
+	if parseMode == "" {
+		return text
+	}
+	return tgbotapi.EscapeText(parseMode, text)
+}
+
+// retryAfter reports whether err is a Telegram rate limit error (429) and returns
+// how long Telegram asked to wait, or 0 if it didn't say
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	return time.Duration(apiErr.RetryAfter) * time.Second, true
+}
+
+// rejected reports whether Telegram refused a message for good, e.g. because the bot
+// was blocked (403) or removed from the chat, so retrying it is pointless
+func rejected(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusForbidden || apiErr.Code == http.StatusBadRequest
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// startPolling receives updates with long polling
+func (b *ReminderBot) startPolling() tgbotapi.UpdatesChannel {
+	// Telegram refuses getUpdates while a webhook is set, e.g. after switching back from webhook mode
+	if info, err := b.bot.GetWebhookInfo(); err == nil && info.IsSet() {
+		b.logger.Printf("Removing webhook %s to use long polling", info.URL)
+		if _, err := b.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			b.logger.Printf("Error removing webhook: %v", err)
+		}
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	return b.bot.GetUpdatesChan(u)
+}
+
+// startWebhook registers the webhook with Telegram and serves updates over HTTP
+func (b *ReminderBot) startWebhook() (tgbotapi.UpdatesChannel, error) {
+	webhookURL, err := url.Parse(b.config.WebhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	// tgbotapi's WebhookConfig has no secret_token, so the method is called directly
+	params := tgbotapi.Params{"url": b.config.WebhookURL, "secret_token": b.config.WebhookSecret}
+	if _, err := b.bot.MakeRequest("setWebhook", params); err != nil {
+		return nil, fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	path := webhookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	updates := make(chan tgbotapi.Update, b.bot.Buffer)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		// Only Telegram knows the secret; anyone else could forge updates from an admin
+		secret := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(b.config.WebhookSecret)) != 1 {
+			b.logger.Printf("Rejected webhook request from %s: wrong secret token", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		update, err := b.bot.HandleUpdate(r)
+		if err != nil {
+			b.logger.Printf("Error handling webhook update: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case updates <- *update:
+		case <-b.stopChan:
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		}
+	})
+
+	b.webhookServer.Handler = mux
+
+	go func() {
+		b.logger.Printf("Listening for webhook updates on :%s%s", b.config.WebhookPort, path)
+		if err := b.webhookServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.logger.Printf("Webhook server error: %v", err)
+		}
+	}()
+
+	return updates, nil
+}
+
+// stopWebhook stops the webhook HTTP server
+func (b *ReminderBot) stopWebhook() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.webhookServer.Shutdown(ctx); err != nil {
+		b.logger.Printf("Error shutting down webhook server: %v", err)
+	}
+}
@@ -2,23 +2,32 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"reminders21/storage"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"reminders21/config"
+	"reminders21/logging"
+	"reminders21/utils"
 )
 
 // Command line arguments
 var (
 	allFlag     = flag.Bool("all", false, "Send message to all chats")
 	chatIDFlag  = flag.String("chat", "", "Send message to specific chat ID")
+	forceFlag   = flag.Bool("force", false, "Send to a -chat ID the bot doesn't know")
 	messageFlag = flag.String("message", "", "Message to send (if not provided, will read from stdin)")
+
+	timezoneFlag    = flag.String("timezone", "", "With -all, only send to chats in these timezones (comma-separated)")
+	activeSinceFlag = flag.String("active-since", "", "With -all, only send to chats active since a date (2006-01-02) or for a period (30d, 12h)")
 )
 
 // RunBroadcast runs the broadcast command
@@ -39,6 +48,40 @@ func RunBroadcast() {
 
 	bot.Debug = cfg.Debug
 
+	filter, err := parseBroadcastFilter(*timezoneFlag, *activeSinceFlag, time.Now())
+	if err != nil {
+		log.Fatalf("Invalid filter: %v", err)
+	}
+
+	// Get a list of active chat IDs from the database, and those matching the filter
+	chatIDs, targetIDs, err := getActiveChatIDs(cfg.DatabasePath, cfg.DefaultTimezone, filter)
+	if err != nil {
+		log.Fatalf("Failed to get chat IDs: %v", err)
+	}
+	if *allFlag {
+		log.Printf("Broadcasting to %d of %d chats", len(targetIDs), len(chatIDs))
+		if len(targetIDs) == 0 {
+			log.Fatal("No chats match the filter")
+		}
+	}
+
+	// A mistyped chat ID would message a random chat, so unknown ones need -force.
+	// Checked before the message is typed in.
+	var chatID int64
+	if *chatIDFlag != "" {
+		chatID, err = strconv.ParseInt(*chatIDFlag, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid chat ID: %v", err)
+		}
+
+		if !slices.Contains(chatIDs, chatID) {
+			if !*forceFlag {
+				log.Fatalf("Chat %d is unknown to the bot, check the ID or add -force to send anyway", chatID)
+			}
+			log.Printf("Warning: chat %d is unknown to the bot, sending anyway because of -force", chatID)
+		}
+	}
+
 	// Get message text
 	var messageText string
 	if *messageFlag != "" {
@@ -64,19 +107,8 @@ func RunBroadcast() {
 		log.Fatal("Message cannot be empty")
 	}
 
-	// Get a list of active chat IDs from the database
-	chatIDs, err := getActiveChatIDs(cfg.DatabasePath)
-	if err != nil {
-		log.Fatalf("Failed to get chat IDs: %v", err)
-	}
-
 	// Send to specific chat if requested
 	if *chatIDFlag != "" {
-		chatID, err := strconv.ParseInt(*chatIDFlag, 10, 64)
-		if err != nil {
-			log.Fatalf("Invalid chat ID: %v", err)
-		}
-
 		msg := tgbotapi.NewMessage(chatID, messageText)
 		if _, err := bot.Send(msg); err != nil {
 			log.Printf("Failed to send message to chat %d: %v", chatID, err)
@@ -91,7 +123,7 @@ func RunBroadcast() {
 		successCount := 0
 		failCount := 0
 
-		for _, chatID := range chatIDs {
+		for _, chatID := range targetIDs {
 			msg := tgbotapi.NewMessage(chatID, messageText)
 			if _, err := bot.Send(msg); err != nil {
 				log.Printf("Failed to send message to chat %d: %v", chatID, err)
@@ -110,15 +142,65 @@ func RunBroadcast() {
 	log.Fatal("Please specify either -all or -chat flag")
 }
 
-// getActiveChatIDs retrieves a list of unique chat IDs from the database
-func getActiveChatIDs(dbPath string) ([]int64, error) {
+// getActiveChatIDs retrieves a list of unique chat IDs from the database, and the ones
+// matching the filter
+func getActiveChatIDs(dbPath, defaultTimezone string, filter storage.BroadcastFilter) ([]int64, []int64, error) {
 	// Initialize storage repository
-	repo, err := storage.NewReminderRepository(dbPath, log.New(os.Stdout, "[BroadcastCLI] ", log.LstdFlags))
+	repo, err := storage.NewReminderRepository(dbPath, defaultTimezone, logging.New(os.Stdout, "[BroadcastCLI] ", logging.FormatPlain, false))
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to initialize repository: %w", err)
 	}
 	defer repo.Close()
 
 	// Get the chat IDs
-	return repo.GetAllActiveChatIDs()
+	all, err := repo.GetAllActiveChatIDsContext(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	filtered, err := repo.GetBroadcastChatIDsContext(context.Background(), filter)
+	return all, filtered, err
+}
+
+// parseBroadcastFilter parses the -timezone and -active-since flags. Timezones are IANA
+// names or UTC offsets like "UTC+3", matched by the canonical name the bot stores;
+// activeSince is a date or a period before now such as "30d" or "12h".
+func parseBroadcastFilter(timezones, activeSince string, now time.Time) (storage.BroadcastFilter, error) {
+	var filter storage.BroadcastFilter
+	for _, timezone := range strings.Split(timezones, ",") {
+		if timezone = strings.TrimSpace(timezone); timezone == "" {
+			continue
+		}
+		loc, err := utils.LoadLocation(timezone)
+		if err != nil {
+			return filter, fmt.Errorf("unknown timezone %q", timezone)
+		}
+		filter.Timezones = append(filter.Timezones, loc.String())
+		// DEFAULT_TIMEZONE is stored as written, e.g. "UTC+3" rather than "UTC+03:00"
+		if timezone != loc.String() {
+			filter.Timezones = append(filter.Timezones, timezone)
+		}
+	}
+
+	activeSince = strings.TrimSpace(activeSince)
+	if activeSince == "" {
+		return filter, nil
+	}
+	if days, ok := strings.CutSuffix(activeSince, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return filter, fmt.Errorf("invalid -active-since %q", activeSince)
+		}
+		filter.ActiveSince = now.AddDate(0, 0, -n)
+		return filter, nil
+	}
+	if d, err := time.ParseDuration(activeSince); err == nil && d > 0 {
+		filter.ActiveSince = now.Add(-d)
+		return filter, nil
+	}
+	since, err := time.ParseInLocation("2006-01-02", activeSince, time.Local)
+	if err != nil {
+		return filter, fmt.Errorf("invalid -active-since %q, use a date (2006-01-02) or a period (30d, 12h)", activeSince)
+	}
+	filter.ActiveSince = since
+	return filter, nil
 }
@@ -1,22 +1,91 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"reminders21/logging"
+	"reminders21/utils"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	TelegramToken         string
 	OpenAIAPIKey          string
+	OpenAIBaseURL         string // OpenAI-compatible API root, e.g. an Azure OpenAI or local gateway
 	DatabasePath          string
 	ReminderCheckInterval time.Duration
+	DueBatchSize          int
+	SendInterval          time.Duration
+	SendRetryDelay        time.Duration // Delivery pause after a rate limit error without retry_after
+	OutboxRetryDelay      time.Duration // First wait before resending a message that failed, doubling up to an hour
+	AckResurfaceAfter     time.Duration // Delivered reminders not acknowledged within this are sent again, 0 to disable
+	MissedAfter           time.Duration // Reminders delivered later than this are marked overdue, 0 to disable
+	MinSlotGap            time.Duration // Least time kept between reminders when looking for a free slot
+	ConflictWindow        time.Duration // New reminders closer than this to another one get a warning, 0 to disable
 	APITimeout            time.Duration
 	LogFilePath           string
+	LogFormat             string // logging.FormatPlain or logging.FormatJSON
 	Debug                 bool
+	ConfirmDeletes        bool
+	AdminUserIDs          []int64
+	MaxMediaFileSize      int // Bytes; voice, audio and video files above this are rejected
+	MaxMediaDuration      time.Duration
+	FFmpegPath            string
+	FFmpegAudioCodec      string
+	FFmpegAudioFormat     string
+	APIListenAddr         string // Address for the HTTP API, empty to disable it
+	APIToken              string
+	WebhookURL            string // Public HTTPS URL for Telegram updates, empty to use long polling
+	WebhookPort           string
+	WebhookSecret         string // Secret Telegram sends with every webhook update, required with WebhookURL
+	// Dates ("2006-01-02", or "01-02" for every year) skipped when counting business days
+	Holidays              []string
+	DefaultTimezone       string // Timezone for users who haven't set one
+	MaxRemindersPerUser   int    // Active one-time reminders and todos per user, 0 for no limit
+	MaxRecurringPerUser   int    // Active recurring reminders per user, 0 for no limit
+	ListLabelLength       int    // Labels longer than this are truncated in /list, 0 to show them in full
+	MaxLabelLength        int    // New labels longer than this are cut and kept in full as the note, 0 for no limit
+	UnknownOperationText  string // Reply when the LLM returns an action the bot doesn't know without an answer
+	WelcomeText           string // Overrides the /start message, empty for the built-in one
+	HelpText              string // Overrides the /help message, empty for the built-in one
+	EmptyStateExamples    bool   // Suggest example phrasings when /list, /today or /tomorrow is empty
+	BackupDir             string // Directory for scheduled backups, empty to disable them
+	BackupInterval        time.Duration
+	BackupKeep            int    // Number of scheduled backups to keep, 0 to keep all
+	TranscriptionProvider string // TranscriptionOpenAI or TranscriptionWhisperCpp
+	WhisperCppURL         string // Inference endpoint of the whisper.cpp server
+	WhisperCppLanguage    string
+}
+
+// defaultUnknownOperationText explains what the bot understands when a request can't be mapped
+// to an operation
+const defaultUnknownOperationText = `Не понял, что нужно сделать 🤔
+Напишите, что и когда напомнить, например:
+• «Напомни позвонить маме завтра в 18:00»
+• «Каждый понедельник в 10:00 планёрка»
+• «Что у меня на сегодня?»
+Все возможности: /help`
+
+// Transcription providers for voice, audio and video messages
+const (
+	TranscriptionOpenAI     = "openai"
+	TranscriptionWhisperCpp = "whispercpp"
+)
+
+// IsAdmin reports whether the user is allowed to run admin commands
+func (c *Config) IsAdmin(userID int64) bool {
+	for _, id := range c.AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
 }
 
 // Load loads configuration from environment variables
@@ -27,11 +96,55 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		TelegramToken:         getEnv("TELEGRAM_BOT_TOKEN", ""),
 		OpenAIAPIKey:          getEnv("OPENAI_API_KEY", ""),
+		OpenAIBaseURL:         getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
 		DatabasePath:          getEnv("DATABASE_PATH", "reminders.db"),
 		ReminderCheckInterval: getDurationEnv("REMINDER_CHECK_INTERVAL", 10*time.Second),
+		DueBatchSize:          getIntEnv("DUE_BATCH_SIZE", 100),
+		SendInterval:          getDurationEnv("SEND_INTERVAL", 40*time.Millisecond),
+		SendRetryDelay:        getDurationEnv("SEND_RETRY_DELAY", 30*time.Second),
+		OutboxRetryDelay:      getDurationEnv("OUTBOX_RETRY_DELAY", time.Minute),
+		AckResurfaceAfter:     getDurationEnv("ACK_RESURFACE_AFTER", 24*time.Hour),
+		MissedAfter:           getDurationEnv("MISSED_AFTER", 15*time.Minute),
+		MinSlotGap:            getDurationEnv("MIN_SLOT_GAP", 30*time.Minute),
+		ConflictWindow:        getDurationEnv("CONFLICT_WINDOW", 15*time.Minute),
 		APITimeout:            getDurationEnv("API_TIMEOUT", 15*time.Second),
 		LogFilePath:           getEnv("LOG_FILE_PATH", ""),
+		LogFormat:             getEnv("LOG_FORMAT", logging.FormatPlain),
 		Debug:                 getBoolEnv("DEBUG", false),
+		ConfirmDeletes:        getBoolEnv("CONFIRM_DELETES", true),
+		AdminUserIDs:          getInt64ListEnv("ADMIN_USER_IDS"),
+		MaxMediaFileSize:      getIntEnv("MAX_MEDIA_FILE_SIZE", 20*1024*1024),
+		MaxMediaDuration:      getDurationEnv("MAX_MEDIA_DURATION", 5*time.Minute),
+		FFmpegPath:            getEnv("FFMPEG_PATH", "ffmpeg"),
+		FFmpegAudioCodec:      getEnv("FFMPEG_AUDIO_CODEC", "libopus"),
+		FFmpegAudioFormat:     getEnv("FFMPEG_AUDIO_FORMAT", "ogg"),
+		APIListenAddr:         getEnv("API_LISTEN_ADDR", ""),
+		APIToken:              getEnv("API_TOKEN", ""),
+		WebhookURL:            getEnv("WEBHOOK_URL", ""),
+		WebhookPort:           getEnv("WEBHOOK_PORT", "8443"),
+		WebhookSecret:         getEnv("WEBHOOK_SECRET", ""),
+		DefaultTimezone:       getEnv("DEFAULT_TIMEZONE", "Europe/Moscow"),
+		MaxRemindersPerUser:   getIntEnv("MAX_REMINDERS_PER_USER", 0),
+		MaxRecurringPerUser:   getIntEnv("MAX_RECURRING_PER_USER", 0),
+		ListLabelLength:       getIntEnv("LIST_LABEL_LENGTH", 80),
+		MaxLabelLength:        getIntEnv("MAX_LABEL_LENGTH", 200),
+		UnknownOperationText:  getEnv("UNKNOWN_OPERATION_TEXT", defaultUnknownOperationText),
+		Holidays:              getListEnv("HOLIDAYS"),
+		EmptyStateExamples:    getBoolEnv("EMPTY_STATE_EXAMPLES", true),
+		BackupDir:             getEnv("BACKUP_DIR", ""),
+		BackupInterval:        getDurationEnv("BACKUP_INTERVAL", 24*time.Hour),
+		BackupKeep:            getIntEnv("BACKUP_KEEP", 7),
+		TranscriptionProvider: getEnv("TRANSCRIPTION_PROVIDER", TranscriptionOpenAI),
+		WhisperCppURL:         getEnv("WHISPER_CPP_URL", "http://127.0.0.1:8080/inference"),
+		WhisperCppLanguage:    getEnv("WHISPER_CPP_LANGUAGE", "ru"),
+	}
+
+	var err error
+	if cfg.WelcomeText, err = getTextEnv("WELCOME_TEXT", ErrEmptyWelcomeText); err != nil {
+		return nil, err
+	}
+	if cfg.HelpText, err = getTextEnv("HELP_TEXT", ErrEmptyHelpText); err != nil {
+		return nil, err
 	}
 
 	// Validate required configs
@@ -43,6 +156,35 @@ func Load() (*Config, error) {
 		return nil, ErrMissingOpenAIAPIKey
 	}
 
+	if cfg.APIListenAddr != "" && cfg.APIToken == "" {
+		return nil, ErrMissingAPIToken
+	}
+
+	if cfg.WebhookURL != "" && !validWebhookSecret(cfg.WebhookSecret) {
+		return nil, ErrInvalidWebhookSecret
+	}
+
+	if _, err := utils.LoadLocation(cfg.DefaultTimezone); err != nil {
+		return nil, ErrInvalidDefaultTimezone
+	}
+
+	for _, holiday := range cfg.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday); err == nil {
+			continue
+		}
+		if _, err := time.Parse("01-02", holiday); err != nil {
+			return nil, ErrInvalidHolidays
+		}
+	}
+
+	if cfg.TranscriptionProvider != TranscriptionOpenAI && cfg.TranscriptionProvider != TranscriptionWhisperCpp {
+		return nil, ErrInvalidTranscriptionProvider
+	}
+
+	if cfg.LogFormat != logging.FormatPlain && cfg.LogFormat != logging.FormatJSON {
+		return nil, ErrInvalidLogFormat
+	}
+
 	return cfg, nil
 }
 
@@ -53,6 +195,24 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getTextEnv reads a message text from the file named in key+"_FILE" or from key itself.
+// It returns "" if neither is set and errEmpty if the text is blank.
+func getTextEnv(key string, errEmpty error) (string, error) {
+	text, exists := os.LookupEnv(key)
+	if path, ok := os.LookupEnv(key + "_FILE"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", ErrConfig(fmt.Sprintf("can't read %s_FILE: %v", key, err))
+		}
+		text, exists = string(data), true
+	}
+
+	if exists && strings.TrimSpace(text) == "" {
+		return "", errEmpty
+	}
+	return strings.TrimSpace(text), nil
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
 	if value, exists := os.LookupEnv(key); exists {
 		boolValue, err := strconv.ParseBool(value)
@@ -64,6 +224,45 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getIntEnv(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return defaultValue
+		}
+		return intValue
+	}
+	return defaultValue
+}
+
+// getListEnv parses a comma-separated list, skipping empty entries
+func getListEnv(key string) []string {
+	var values []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getInt64ListEnv parses a comma-separated list of IDs, skipping invalid entries
+func getInt64ListEnv(key string) []int64 {
+	var values []int64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
 		duration, err := time.ParseDuration(value)
@@ -75,10 +274,35 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// validWebhookSecret reports whether s is a secret token Telegram accepts for a webhook
+func validWebhookSecret(s string) bool {
+	if len(s) == 0 || len(s) > 256 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
 // Errors
 var (
-	ErrMissingTelegramToken = ErrConfig("missing TELEGRAM_BOT_TOKEN")
-	ErrMissingOpenAIAPIKey  = ErrConfig("missing OPENAI_API_KEY")
+	ErrMissingTelegramToken   = ErrConfig("missing TELEGRAM_BOT_TOKEN")
+	ErrMissingOpenAIAPIKey    = ErrConfig("missing OPENAI_API_KEY")
+	ErrMissingAPIToken        = ErrConfig("missing API_TOKEN (required when API_LISTEN_ADDR is set)")
+	ErrInvalidWebhookSecret   = ErrConfig("missing or invalid WEBHOOK_SECRET (required when WEBHOOK_URL is set; 1-256 characters A-Z, a-z, 0-9, _ and -)")
+	ErrInvalidDefaultTimezone = ErrConfig("invalid DEFAULT_TIMEZONE (use an IANA name like Europe/Moscow or an offset like UTC+3)")
+
+	ErrInvalidTranscriptionProvider = ErrConfig("invalid TRANSCRIPTION_PROVIDER (use openai or whispercpp)")
+
+	ErrInvalidLogFormat = ErrConfig("invalid LOG_FORMAT (use plain or json)")
+
+	ErrInvalidHolidays = ErrConfig("invalid HOLIDAYS (use comma-separated dates like 2025-05-09, or 01-01 for every year)")
+
+	ErrEmptyWelcomeText = ErrConfig("WELCOME_TEXT or the file in WELCOME_TEXT_FILE is empty")
+	ErrEmptyHelpText    = ErrConfig("HELP_TEXT or the file in HELP_TEXT_FILE is empty")
 )
 
 // ErrConfig represents a configuration error
@@ -0,0 +1,427 @@
+// Package core implements the reminder engine independently of Telegram.
+// Times passed in are interpreted in the user's timezone and reminders are
+// returned converted to it, while storage always works in UTC.
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"reminders21/logging"
+	"reminders21/storage"
+	"reminders21/utils"
+)
+
+// Reminders is the reminder engine API
+type Reminders interface {
+	// Location returns the user's preferred timezone
+	Location(ctx context.Context, userID int64) *time.Location
+	// Now returns the current time in the user's timezone
+	Now(ctx context.Context, userID int64) time.Time
+	// ParseLocalTime parses a time in the user's timezone
+	ParseLocalTime(ctx context.Context, userID int64, layout, value string) (time.Time, error)
+
+	// Create adds a one-time reminder or todo and returns its ID
+	Create(ctx context.Context, item storage.ReminderItem) (int64, error)
+	// CreateSeries adds reminders that can be deleted together with DeleteSeries and returns
+	// their IDs; the first one is the series ID
+	CreateSeries(ctx context.Context, items []storage.ReminderItem) ([]int64, error)
+	// Get returns a reminder by ID, or storage.ErrReminderNotFound
+	Get(ctx context.Context, id int64) (*storage.ReminderItem, error)
+	// List returns the user's active reminders
+	List(ctx context.Context, userID int64) ([]storage.ReminderItem, error)
+	// ListBetween returns the user's active reminders in [start, end)
+	ListBetween(ctx context.Context, userID int64, start, end time.Time) ([]storage.ReminderItem, error)
+	// Past returns the user's last limit delivered reminders and completed todos, most recent first
+	Past(ctx context.Context, userID int64, limit int) ([]storage.ReminderItem, error)
+	// Update changes a reminder's time and/or label; a zero time or empty label is left unchanged.
+	// Update, Move and Delete return storage.ErrReminderNotFound, storage.ErrNotOwned or
+	// storage.ErrReminderDelivered if the reminder can't be changed.
+	Update(ctx context.Context, id, userID int64, reminderTime time.Time, label string) (bool, error)
+	// Move changes the chat a pending reminder owned by the user is delivered to
+	Move(ctx context.Context, id, userID, chatID int64) (bool, error)
+	// Delete deletes a reminder owned by the user
+	Delete(ctx context.Context, id, userID int64) (bool, error)
+	// DeleteSeries deletes the pending reminders of a series owned by the user and returns how many were deleted
+	DeleteSeries(ctx context.Context, seriesID, userID int64) (int64, error)
+	// Due returns up to limit reminders due at the given time, oldest first
+	Due(ctx context.Context, at time.Time, limit int) ([]storage.ReminderItem, error)
+	// MarkDelivered marks reminders as delivered
+	MarkDelivered(ctx context.Context, ids []int64) error
+	// Snooze re-schedules a reminder owned by the user to be delivered again after d.
+	// It returns the new time in the user's timezone, or the zero time if the reminder wasn't found.
+	Snooze(ctx context.Context, id, userID int64, d time.Duration) (time.Time, error)
+	// CompleteTodos marks all of the user's one-time todos as done and returns how many were completed
+	CompleteTodos(ctx context.Context, userID int64) (int64, error)
+	// Todos returns the user's active one-time todos in the user's timezone, earliest first
+	Todos(ctx context.Context, userID int64) ([]storage.ReminderItem, error)
+	// CompleteTodo marks a todo owned by the user as done. It returns false if there is no such active todo.
+	CompleteTodo(ctx context.Context, id, userID int64) (bool, error)
+	// TransferReminders hands all of a user's pending reminders and recurring reminders over to
+	// another user and returns how many of each were transferred
+	TransferReminders(ctx context.Context, fromUserID, toUserID int64) (int64, int64, error)
+
+	// CreateRecurring adds a recurring reminder and returns its ID
+	CreateRecurring(ctx context.Context, item storage.RecurringReminder) (int64, error)
+	// GetRecurring returns a recurring reminder owned by the user, or nil
+	GetRecurring(ctx context.Context, id, userID int64) (*storage.RecurringReminder, error)
+	// ListRecurring returns the user's recurring reminders
+	ListRecurring(ctx context.Context, userID int64) ([]storage.RecurringReminder, error)
+	// FindRecurring returns the user's recurring reminders matching the filter
+	FindRecurring(ctx context.Context, userID int64, filter storage.RecurringFilter) ([]storage.RecurringReminder, error)
+	// UpdateRecurring replaces the schedule and label of a recurring reminder
+	UpdateRecurring(ctx context.Context, item storage.RecurringReminder) (bool, error)
+	// DeleteRecurring deletes a recurring reminder owned by the user
+	DeleteRecurring(ctx context.Context, id, userID int64) (bool, error)
+	// SetRecurringActive disables or re-enables a recurring reminder owned by the user
+	SetRecurringActive(ctx context.Context, id, userID int64, active bool) (bool, error)
+	// SkipNextRecurring skips the next occurrence (after today) of a recurring reminder
+	// and returns its date in the user's timezone, or the zero time if the reminder wasn't found
+	SkipNextRecurring(ctx context.Context, id, userID int64) (time.Time, error)
+	// SkipRecurringOn skips the occurrence of a recurring reminder on date's local day.
+	// It returns false if the user has no such reminder.
+	SkipRecurringOn(ctx context.Context, id, userID int64, date time.Time) (bool, error)
+	// DueRecurring returns recurring reminders due at the given time
+	DueRecurring(ctx context.Context, at time.Time) ([]storage.RecurringReminder, error)
+	// ClaimRecurring claims the occurrence of a recurring reminder due at the given time
+	// before it is delivered. It returns false if that occurrence was already claimed.
+	ClaimRecurring(ctx context.Context, item storage.RecurringReminder, at time.Time) (bool, error)
+}
+
+// ErrEmptyLabel is returned when creating a reminder without a label
+var ErrEmptyLabel = errors.New("reminder label is empty")
+
+// Service implements Reminders on top of the SQLite repository
+type Service struct {
+	repo   *storage.ReminderRepository
+	logger logging.Logger
+}
+
+var _ Reminders = (*Service)(nil)
+
+// NewService creates a new Service
+func NewService(repo *storage.ReminderRepository, logger logging.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// StartOfDay returns midnight of t's day in t's location
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Location returns the user's preferred timezone, falling back to UTC
+func (s *Service) Location(ctx context.Context, userID int64) *time.Location {
+	timezone, err := s.repo.GetUserTimezoneContext(ctx, userID)
+	if err != nil {
+		s.logger.Printf("Error getting user timezone: %v", err)
+	}
+
+	location, err := utils.LoadLocation(timezone)
+	if err != nil {
+		s.logger.Printf("Error loading timezone %s: %v", timezone, err)
+		return time.UTC
+	}
+	return location
+}
+
+// Now returns the current time in the user's timezone
+func (s *Service) Now(ctx context.Context, userID int64) time.Time {
+	return time.Now().In(s.Location(ctx, userID))
+}
+
+// ParseLocalTime parses a time in the user's timezone
+func (s *Service) ParseLocalTime(ctx context.Context, userID int64, layout, value string) (time.Time, error) {
+	return time.ParseInLocation(layout, value, s.Location(ctx, userID))
+}
+
+// Create adds a one-time reminder or todo and returns its ID
+func (s *Service) Create(ctx context.Context, item storage.ReminderItem) (int64, error) {
+	item.Label = utils.SanitizeText(item.Label)
+	item.Note = utils.SanitizeText(item.Note)
+	if strings.TrimSpace(item.Label) == "" {
+		return 0, ErrEmptyLabel
+	}
+
+	item.ReminderTime = item.ReminderTime.UTC()
+	if !item.EndTime.IsZero() {
+		item.EndTime = item.EndTime.UTC()
+	}
+	return s.repo.AddReminderContext(ctx, item)
+}
+
+// CreateSeries adds reminders that can be deleted together
+func (s *Service) CreateSeries(ctx context.Context, items []storage.ReminderItem) ([]int64, error) {
+	for i := range items {
+		items[i].Label = utils.SanitizeText(items[i].Label)
+		items[i].Note = utils.SanitizeText(items[i].Note)
+		if strings.TrimSpace(items[i].Label) == "" {
+			return nil, ErrEmptyLabel
+		}
+		items[i].ReminderTime = items[i].ReminderTime.UTC()
+		if !items[i].EndTime.IsZero() {
+			items[i].EndTime = items[i].EndTime.UTC()
+		}
+	}
+	return s.repo.AddReminderSeriesContext(ctx, items)
+}
+
+// Get returns a reminder by ID with its time in the owner's timezone
+func (s *Service) Get(ctx context.Context, id int64) (*storage.ReminderItem, error) {
+	reminder, err := s.repo.GetReminderByIDContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	*reminder = s.toLocal(ctx, reminder.UserID, []storage.ReminderItem{*reminder})[0]
+	return reminder, nil
+}
+
+// List returns the user's active reminders in the user's timezone
+func (s *Service) List(ctx context.Context, userID int64) ([]storage.ReminderItem, error) {
+	reminders, err := s.repo.GetUserRemindersContext(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLocal(ctx, userID, reminders), nil
+}
+
+// ListBetween returns the user's active reminders in [start, end) in the user's timezone
+func (s *Service) ListBetween(ctx context.Context, userID int64, start, end time.Time) ([]storage.ReminderItem, error) {
+	reminders, err := s.repo.GetUserRemindersByPeriodContext(ctx, userID, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLocal(ctx, userID, reminders), nil
+}
+
+// Past returns the user's last limit delivered reminders and completed todos, most recent first,
+// in the user's timezone
+func (s *Service) Past(ctx context.Context, userID int64, limit int) ([]storage.ReminderItem, error) {
+	reminders, err := s.repo.GetNotifiedRemindersContext(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLocal(ctx, userID, reminders), nil
+}
+
+// Update changes a reminder's time and/or label; a zero time or empty label is left unchanged.
+// When the time changes, an event window keeps its duration.
+func (s *Service) Update(ctx context.Context, id, userID int64, reminderTime time.Time, label string) (bool, error) {
+	if !reminderTime.IsZero() {
+		if existing, err := s.repo.GetReminderByIDContext(ctx, id); err == nil && !existing.EndTime.IsZero() {
+			duration := existing.EndTime.Sub(existing.ReminderTime)
+			if _, err := s.repo.UpdateReminderEndTimeContext(ctx, id, userID, reminderTime.Add(duration).UTC()); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	label = utils.SanitizeText(label)
+
+	switch {
+	case !reminderTime.IsZero() && label != "":
+		return s.repo.UpdateReminderContext(ctx, id, userID, reminderTime.UTC(), label)
+	case !reminderTime.IsZero():
+		return s.repo.UpdateReminderTimeContext(ctx, id, userID, reminderTime.UTC())
+	case label != "":
+		return s.repo.UpdateReminderLabelContext(ctx, id, userID, label)
+	default:
+		return false, nil
+	}
+}
+
+// Move changes the chat a pending reminder owned by the user is delivered to
+func (s *Service) Move(ctx context.Context, id, userID, chatID int64) (bool, error) {
+	return s.repo.UpdateReminderChatContext(ctx, id, userID, chatID)
+}
+
+// Delete deletes a reminder owned by the user
+func (s *Service) Delete(ctx context.Context, id, userID int64) (bool, error) {
+	return s.repo.DeleteReminderContext(ctx, id, userID)
+}
+
+// DeleteSeries deletes the pending reminders of a series owned by the user
+func (s *Service) DeleteSeries(ctx context.Context, seriesID, userID int64) (int64, error) {
+	return s.repo.DeleteReminderSeriesContext(ctx, seriesID, userID)
+}
+
+// Due returns up to limit reminders due at the given time, oldest first
+func (s *Service) Due(ctx context.Context, at time.Time, limit int) ([]storage.ReminderItem, error) {
+	return s.repo.GetDueRemindersContext(ctx, at.UTC(), limit)
+}
+
+// MarkDelivered marks reminders as delivered
+func (s *Service) MarkDelivered(ctx context.Context, ids []int64) error {
+	return s.repo.MarkMultipleAsNotifiedContext(ctx, ids)
+}
+
+// Snooze re-schedules a reminder to be delivered again after d
+func (s *Service) Snooze(ctx context.Context, id, userID int64, d time.Duration) (time.Time, error) {
+	until := s.Now(ctx, userID).Add(d)
+
+	ok, err := s.repo.SnoozeReminderContext(ctx, id, userID, until)
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+
+	return until, nil
+}
+
+// CompleteTodos marks all of the user's one-time todos as done
+func (s *Service) CompleteTodos(ctx context.Context, userID int64) (int64, error) {
+	return s.repo.CompleteUserTodosContext(ctx, userID)
+}
+
+// Todos returns the user's active one-time todos in the user's timezone
+func (s *Service) Todos(ctx context.Context, userID int64) ([]storage.ReminderItem, error) {
+	todos, err := s.repo.GetUserTodosContext(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLocal(ctx, userID, todos), nil
+}
+
+// CompleteTodo marks a todo owned by the user as done
+func (s *Service) CompleteTodo(ctx context.Context, id, userID int64) (bool, error) {
+	return s.repo.CompleteTodoContext(ctx, id, userID)
+}
+
+// TransferReminders hands all of a user's pending reminders and recurring reminders over to another user
+func (s *Service) TransferReminders(ctx context.Context, fromUserID, toUserID int64) (int64, int64, error) {
+	return s.repo.TransferRemindersContext(ctx, fromUserID, toUserID)
+}
+
+// CreateRecurring adds a recurring reminder and returns its ID
+func (s *Service) CreateRecurring(ctx context.Context, item storage.RecurringReminder) (int64, error) {
+	item.Label = utils.SanitizeText(item.Label)
+	if strings.TrimSpace(item.Label) == "" {
+		return 0, ErrEmptyLabel
+	}
+
+	id, err := s.repo.AddRecurringReminderContext(ctx, item.ChatID, item.UserID, item.Label, item.RecurringType,
+		item.Time, item.EndTime, item.DayOfWeek, item.DayOfMonth, item.WeekOfMonth, item.IsTodo, item.Silent)
+	if err != nil {
+		return id, err
+	}
+
+	if item.RecurringType == storage.RecurringHourly {
+		if _, err = s.repo.SetRecurringIntervalContext(ctx, id, item.UserID, item.IntervalHours); err != nil {
+			return id, err
+		}
+	}
+	if item.Category != "" {
+		if _, err = s.repo.SetRecurringCategoryContext(ctx, id, item.UserID, item.Category); err != nil {
+			return id, err
+		}
+	}
+	if item.SolarEvent != "" {
+		_, err = s.repo.SetRecurringSolarContext(ctx, id, item.UserID, item.SolarEvent, item.SolarOffset, item.Latitude, item.Longitude)
+	}
+	return id, err
+}
+
+// GetRecurring returns a recurring reminder owned by the user, or nil
+func (s *Service) GetRecurring(ctx context.Context, id, userID int64) (*storage.RecurringReminder, error) {
+	return s.repo.GetRecurringReminderByIDContext(ctx, id, userID)
+}
+
+// ListRecurring returns the user's recurring reminders
+func (s *Service) ListRecurring(ctx context.Context, userID int64) ([]storage.RecurringReminder, error) {
+	return s.repo.GetUserRecurringRemindersContext(ctx, userID)
+}
+
+// FindRecurring returns the user's recurring reminders matching the filter
+func (s *Service) FindRecurring(ctx context.Context, userID int64, filter storage.RecurringFilter) ([]storage.RecurringReminder, error) {
+	return s.repo.GetUserRecurringRemindersFilteredContext(ctx, userID, filter)
+}
+
+// UpdateRecurring replaces the schedule, including any solar schedule or hourly interval, and label
+// of a recurring reminder
+func (s *Service) UpdateRecurring(ctx context.Context, item storage.RecurringReminder) (bool, error) {
+	item.Label = utils.SanitizeText(item.Label)
+	ok, err := s.repo.UpdateRecurringReminderContext(ctx, item.ID, item.UserID, item.Label, item.RecurringType,
+		item.Time, item.EndTime, item.DayOfWeek, item.DayOfMonth, item.WeekOfMonth)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	if item.RecurringType == storage.RecurringHourly {
+		if ok, err = s.repo.SetRecurringIntervalContext(ctx, item.ID, item.UserID, item.IntervalHours); err != nil || !ok {
+			return ok, err
+		}
+	}
+	return s.repo.SetRecurringSolarContext(ctx, item.ID, item.UserID, item.SolarEvent, item.SolarOffset, item.Latitude, item.Longitude)
+}
+
+// DeleteRecurring deletes a recurring reminder owned by the user
+func (s *Service) DeleteRecurring(ctx context.Context, id, userID int64) (bool, error) {
+	return s.repo.DeleteRecurringReminderContext(ctx, id, userID)
+}
+
+// SetRecurringActive disables or re-enables a recurring reminder owned by the user
+func (s *Service) SetRecurringActive(ctx context.Context, id, userID int64, active bool) (bool, error) {
+	return s.repo.SetRecurringActiveContext(ctx, id, userID, active)
+}
+
+// SkipNextRecurring skips the next occurrence (after today) of a recurring reminder
+func (s *Service) SkipNextRecurring(ctx context.Context, id, userID int64) (time.Time, error) {
+	reminder, err := s.repo.GetRecurringReminderByIDContext(ctx, id, userID)
+	if err != nil || reminder == nil {
+		return time.Time{}, err
+	}
+
+	next := reminder.NextOccurrence(StartOfDay(s.Now(ctx, userID)).AddDate(0, 0, 1))
+	if next.IsZero() {
+		return time.Time{}, nil
+	}
+
+	ok, err := s.repo.SkipRecurringOccurrenceContext(ctx, id, userID, next.Format("2006-01-02"))
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+
+	return next, nil
+}
+
+// SkipRecurringOn skips the occurrence of a recurring reminder on date's local day
+func (s *Service) SkipRecurringOn(ctx context.Context, id, userID int64, date time.Time) (bool, error) {
+	return s.repo.SkipRecurringOccurrenceContext(ctx, id, userID, date.Format("2006-01-02"))
+}
+
+// DueRecurring returns recurring reminders due at the given time
+func (s *Service) DueRecurring(ctx context.Context, at time.Time) ([]storage.RecurringReminder, error) {
+	return s.repo.GetDueRecurringRemindersContext(ctx, at)
+}
+
+// ClaimRecurring claims the occurrence of a recurring reminder due at the given time.
+// Occurrences are keyed by the local date in the owner's timezone, and those of hourly
+// reminders also by the local time.
+func (s *Service) ClaimRecurring(ctx context.Context, item storage.RecurringReminder, at time.Time) (bool, error) {
+	layout := "2006-01-02"
+	if item.RecurringType == storage.RecurringHourly {
+		layout = "2006-01-02 15:04"
+	}
+	occurrenceDate := at.In(s.Location(ctx, item.UserID)).Format(layout)
+	return s.repo.ClaimRecurringOccurrenceContext(ctx, item.ID, occurrenceDate, at)
+}
+
+// toLocal converts reminder times to the user's timezone
+func (s *Service) toLocal(ctx context.Context, userID int64, reminders []storage.ReminderItem) []storage.ReminderItem {
+	location := s.Location(ctx, userID)
+	for i := range reminders {
+		reminders[i].ReminderTime = reminders[i].ReminderTime.In(location)
+		if !reminders[i].EndTime.IsZero() {
+			reminders[i].EndTime = reminders[i].EndTime.In(location)
+		}
+	}
+	return reminders
+}
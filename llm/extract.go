@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// errNoJSON is returned when the model output contains no usable operations
+var errNoJSON = errors.New("failed to extract JSON from model output")
+
+// fencedBlockPattern matches Markdown code blocks, with or without a language tag
+var fencedBlockPattern = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n?(.*?)```")
+
+// extractOutput finds the operations in a model's text response. It prefers fenced
+// code blocks and otherwise scans the text for JSON values, so prose around the JSON,
+// several JSON objects or braces inside strings don't break parsing. Operations may
+// come as the documented {"operations": [...]} object, a bare operation object or an
+// array of operations.
+func extractOutput(text string) (LLMOutputMulti, error) {
+	var candidates []string
+	for _, m := range fencedBlockPattern.FindAllStringSubmatch(text, -1) {
+		candidates = append(candidates, m[1])
+	}
+	candidates = append(candidates, text)
+
+	for _, candidate := range candidates {
+		if output, ok := decodeOutput(candidate); ok {
+			return output, nil
+		}
+	}
+
+	return LLMOutputMulti{}, errNoJSON
+}
+
+// decodeOutput decodes every top-level JSON value in s and collects the operations
+func decodeOutput(s string) (LLMOutputMulti, bool) {
+	var output LLMOutputMulti
+	found := false
+
+	for i := 0; i < len(s); {
+		start := strings.IndexAny(s[i:], "{[")
+		if start < 0 {
+			break
+		}
+		i += start
+
+		decoder := json.NewDecoder(strings.NewReader(s[i:]))
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			// Not JSON at this brace, try the next one
+			i++
+			continue
+		}
+		i += int(decoder.InputOffset())
+
+		if multi, ok := decodeMulti(raw); ok {
+			output.Operations = append(output.Operations, multi.Operations...)
+			if multi.UserReminders != nil {
+				output.UserReminders = multi.UserReminders
+			}
+			found = true
+		} else if ops, ok := decodeOperations(raw); ok {
+			output.Operations = append(output.Operations, ops...)
+			found = true
+		}
+	}
+
+	return output, found
+}
+
+// decodeMulti decodes the documented {"operations": [...]} object
+func decodeMulti(raw json.RawMessage) (LLMOutputMulti, bool) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return LLMOutputMulti{}, false
+	}
+	if _, ok := probe["operations"]; !ok {
+		return LLMOutputMulti{}, false
+	}
+
+	var multi LLMOutputMulti
+	if err := json.Unmarshal(raw, &multi); err != nil {
+		return LLMOutputMulti{}, false
+	}
+	return multi, true
+}
+
+// decodeOperations decodes a bare operation object or an array of them
+func decodeOperations(raw json.RawMessage) ([]Operation, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var ops []Operation
+		if err := json.Unmarshal(raw, &ops); err != nil || len(ops) == 0 {
+			return nil, false
+		}
+		for _, op := range ops {
+			if op.Action == "" {
+				return nil, false
+			}
+		}
+		return ops, true
+	}
+
+	var op Operation
+	if err := json.Unmarshal(raw, &op); err != nil || op.Action == "" {
+		return nil, false
+	}
+	return []Operation{op}, true
+}
+
+// flexString accepts a JSON string, number or boolean, since models don't always
+// quote IDs and day numbers as the schema asks
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v.(type) {
+	case float64, bool:
+		*f = flexString(data)
+		return nil
+	}
+	return errors.New("expected a string, number or boolean")
+}
+
+// flexBool accepts a JSON boolean or its string form ("true")
+type flexBool bool
+
+func (f *flexBool) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = false
+		return nil
+	}
+
+	s := strings.Trim(string(data), `"`)
+	if s == "" {
+		*f = false
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*f = flexBool(b)
+	return nil
+}
+
+// UnmarshalJSON decodes an operation, tolerating unquoted IDs and day numbers
+// and string booleans
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+	aux := struct {
+		*operation
+		ReminderID           flexString   `json:"reminder_id"`
+		DayOfWeek            flexString   `json:"day_of_week"`
+		DayOfMonth           flexString   `json:"day_of_month"`
+		WeekOfMonth          flexString   `json:"week_of_month"`
+		IsTodo               flexBool     `json:"is_todo"`
+		Silent               flexBool     `json:"silent"`
+		CandidateIDs         []flexString `json:"candidate_ids"`
+		RelativeToReminderID flexString   `json:"relative_to_reminder_id"`
+	}{operation: (*operation)(op)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	op.ReminderID = string(aux.ReminderID)
+	op.DayOfWeek = string(aux.DayOfWeek)
+	op.DayOfMonth = string(aux.DayOfMonth)
+	op.WeekOfMonth = string(aux.WeekOfMonth)
+	op.IsTodo = bool(aux.IsTodo)
+	op.Silent = bool(aux.Silent)
+	op.RelativeToReminderID = string(aux.RelativeToReminderID)
+	op.CandidateIDs = nil
+	for _, id := range aux.CandidateIDs {
+		op.CandidateIDs = append(op.CandidateIDs, string(id))
+	}
+
+	return nil
+}
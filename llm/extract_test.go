@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Operation
+	}{
+		{
+			name: "fenced",
+			text: "Вот результат:\n```json\n{\"operations\": [{\"action\": \"create\", \"label\": \"позвонить маме\"}]}\n```",
+			want: []Operation{{Action: "create", Label: "позвонить маме"}},
+		},
+		{
+			name: "fenced without language tag",
+			text: "```\n{\"operations\": [{\"action\": \"list\"}]}\n```",
+			want: []Operation{{Action: "list"}},
+		},
+		{
+			name: "unfenced",
+			text: `{"operations": [{"action": "delete", "reminder_id": "12"}]}`,
+			want: []Operation{{Action: "delete", ReminderID: "12"}},
+		},
+		{
+			name: "prose with braces",
+			text: `Конечно {не вопрос}! Вот {"operations": [{"action": "create", "label": "купить {хлеб}"}]} – готово.`,
+			want: []Operation{{Action: "create", Label: "купить {хлеб}"}},
+		},
+		{
+			name: "several objects",
+			text: "{\"operations\": [{\"action\": \"create\", \"label\": \"a\"}]}\n{\"operations\": [{\"action\": \"create\", \"label\": \"b\"}]}",
+			want: []Operation{{Action: "create", Label: "a"}, {Action: "create", Label: "b"}},
+		},
+		{
+			name: "bare operation",
+			text: `Результат: {"action": "create", "label": "спорт"}`,
+			want: []Operation{{Action: "create", Label: "спорт"}},
+		},
+		{
+			name: "array of operations",
+			text: `[{"action": "delete", "reminder_id": "1"}, {"action": "delete", "reminder_id": "2"}]`,
+			want: []Operation{{Action: "delete", ReminderID: "1"}, {Action: "delete", ReminderID: "2"}},
+		},
+		{
+			name: "unquoted ids",
+			text: `{"operations": [{"action": "clarify", "reminder_id": 7, "candidate_ids": [3, "4"], "day_of_week": 1}]}`,
+			want: []Operation{{Action: "clarify", ReminderID: "7", CandidateIDs: []string{"3", "4"}, DayOfWeek: "1"}},
+		},
+		{
+			name: "string booleans",
+			text: `{"operations": [{"action": "create", "label": "x", "is_todo": "true", "silent": "false"}]}`,
+			want: []Operation{{Action: "create", Label: "x", IsTodo: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := extractOutput(tt.text)
+			if err != nil {
+				t.Fatalf("extractOutput() error = %v", err)
+			}
+			if !reflect.DeepEqual(output.Operations, tt.want) {
+				t.Errorf("extractOutput() operations = %+v, want %+v", output.Operations, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractOutputNoJSON(t *testing.T) {
+	for _, text := range []string{"", "Не понял, уточните время {пожалуйста}.", `{"foo": "bar"}`} {
+		if _, err := extractOutput(text); err != errNoJSON {
+			t.Errorf("extractOutput(%q) error = %v, want errNoJSON", text, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// functionActions maps the functions offered to the model to operation actions
+var functionActions = map[string]string{
+	"create_reminder":  "create",
+	"create_recurring": "create_recurring",
+	"adjust_reminder":  "adjust",
+	"delete_reminder":  "delete",
+	"show_list":        "show_list",
+}
+
+// stringParam describes a string function parameter
+func stringParam(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+// categoryParam describes the category of a created reminder, shown as an icon in lists
+var categoryParam = map[string]interface{}{
+	"type":        "string",
+	"enum":        []string{"work", "health", "shopping", "home", "family", "finance", "study", "sport", "travel"},
+	"description": "Категория напоминания для значка в списке (необязательно; не указывай, если ни одна не подходит)",
+}
+
+// functions are the functions available to the model. Their arguments are operation
+// fields, so a call decodes into a single Operation.
+var functions = []map[string]interface{}{
+	{
+		"name":        "create_reminder",
+		"description": "Создать одноразовое напоминание или задачу.",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"datetime":                stringParam("Дата и время в формате '2006-01-02 15:04:05'"),
+				"end_datetime":            stringParam("Время окончания события в формате '2006-01-02 15:04:05' (необязательно)"),
+				"label":                   stringParam("Текст напоминания"),
+				"relative_to_reminder_id": stringParam("ID напоминания, от которого отсчитывается время (необязательно, вместо datetime)"),
+				"offset":                  stringParam("Смещение от времени того напоминания (или от восхода/заката), например '3d', '-1h' (необязательно)"),
+				"repeat_interval":         stringParam("Интервал между повторами, например '3m', '1h' (вместе с repeat)"),
+				"candidate_datetimes": map[string]interface{}{
+					"type":        "array",
+					"items":       stringParam("Дата и время в формате '2006-01-02 15:04:05'"),
+					"description": "Варианты времени, если оно неоднозначно, например 'в 8' – 08:00 или 20:00 (необязательно, вместо datetime)",
+				},
+				"solar_event": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"sunrise", "sunset"},
+					"description": "Время относительно восхода или заката вместо точного времени (необязательно)",
+				},
+				"category": categoryParam,
+				"is_todo": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если это задача без уведомления",
+				},
+				"date_only": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если указана только дата без времени",
+				},
+				"silent": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если напомнить тихо, без звука уведомления",
+				},
+				"business_days": map[string]interface{}{
+					"type":        "integer",
+					"description": "Через сколько рабочих дней напомнить (необязательно; дату вычислит бот)",
+				},
+				"months": map[string]interface{}{
+					"type":        "integer",
+					"description": "Через сколько календарных месяцев напомнить, год – 12 (необязательно; дату вычислит бот)",
+				},
+				"free_slot": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если нужно ближайшее свободное время начиная с datetime (время подберёт бот)",
+				},
+				"repeat": map[string]interface{}{
+					"type":        "integer",
+					"description": "Сколько раз напомнить подряд, начиная с datetime (необязательно)",
+				},
+				"answer": stringParam("Ответ пользователю"),
+			},
+			"required": []string{"label"},
+		},
+	},
+	{
+		"name":        "create_recurring",
+		"description": "Создать повторяющееся напоминание или задачу.",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"recurring_type": map[string]interface{}{
+					"type": "string",
+					"enum": []string{"hourly", "daily", "weekly", "monthly", "monthly_weekday"},
+				},
+				"time":          stringParam("Время в формате '15:04'"),
+				"end_time":      stringParam("Время окончания события в формате '15:04', если указан промежуток; для hourly – до какого времени повторять (необязательно)"),
+				"day_of_week":   stringParam("День недели для weekly и monthly_weekday: 0-6, где 0=воскресенье; 7 – рабочий день (для weekly – по будням); 8 – по выходным (только weekly)"),
+				"day_of_month":  stringParam("День месяца для monthly: 1-31"),
+				"week_of_month": stringParam("Номер дня недели в месяце для monthly_weekday: 1-5 или -1 для последнего"),
+				"label":         stringParam("Текст напоминания"),
+				"offset":        stringParam("Смещение от восхода или заката, например '-1h', '30m' (необязательно)"),
+				"solar_event": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"sunrise", "sunset"},
+					"description": "Время относительно восхода или заката вместо точного времени (необязательно)",
+				},
+				"category": categoryParam,
+				"interval_hours": map[string]interface{}{
+					"type":        "integer",
+					"description": "Для hourly: через сколько часов повторять, 1-12 (по умолчанию 1)",
+				},
+				"is_todo": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если это задача без уведомления",
+				},
+				"silent": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если напомнить тихо, без звука уведомления",
+				},
+				"answer": stringParam("Ответ пользователю"),
+			},
+			"required": []string{"recurring_type", "label"},
+		},
+	},
+	{
+		"name":        "adjust_reminder",
+		"description": "Изменить существующее напоминание: изменить текст и/или время.",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"reminder_id": stringParam("ID напоминания для изменения"),
+				"datetime":    stringParam("Новая дата и время в формате '2006-01-02 15:04:05' (необязательно)"),
+				"time_delta":  stringParam("Сдвиг относительно текущего времени напоминания, например '-1h' (на час раньше), '30m', '1d' (необязательно, вместо datetime)"),
+				"label":       stringParam("Новый текст напоминания (необязательно)"),
+				"free_slot": map[string]interface{}{
+					"type":        "boolean",
+					"description": "true, если перенести на ближайшее свободное время начиная с datetime (время подберёт бот)",
+				},
+			},
+			"required": []string{"reminder_id"},
+		},
+	},
+	{
+		"name":        "delete_reminder",
+		"description": "Удалить существующее напоминание.",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"reminder_id": stringParam("ID напоминания для удаления"),
+			},
+			"required": []string{"reminder_id"},
+		},
+	},
+	{
+		"name":        "show_list",
+		"description": "Показать список напоминаний, при необходимости за период.",
+		"parameters": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"start_date": stringParam("Начало периода в формате '2006-01-02' (необязательно)"),
+				"end_date":   stringParam("Конец периода в формате '2006-01-02' (необязательно)"),
+				"created_by": stringParam("Имя участника группы, чьи напоминания показать, например 'Иван' или '@ivan' (необязательно)"),
+				"answer":     stringParam("Ответ пользователю"),
+			},
+		},
+	},
+}
+
+// parseFunctionCall converts a function call from the model into an operation
+func parseFunctionCall(fc *FunctionCall) (Operation, error) {
+	action, ok := functionActions[fc.Name]
+	if !ok {
+		return Operation{}, fmt.Errorf("unknown function %q", fc.Name)
+	}
+
+	var op Operation
+	if err := json.Unmarshal([]byte(fc.Arguments), &op); err != nil {
+		return Operation{}, fmt.Errorf("error parsing function call arguments: %v", err)
+	}
+	op.Action = action
+
+	return op, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,20 +12,41 @@ import (
 	"time"
 )
 
-// OpenAIClient is a client for OpenAI API
+// LLMParser turns a user's message into reminder operations
+type LLMParser interface {
+	// ParseMessage parses a message. now is the user's current local time; relative
+	// dates in the input are resolved against it.
+	ParseMessage(ctx context.Context, now time.Time, prompt string, input string, userReminders []map[string]string) (LLMOutputMulti, error)
+	// Close releases idle connections
+	Close()
+}
+
+// OpenAIClient is a client for OpenAI API or any OpenAI-compatible endpoint
 type OpenAIClient struct {
 	APIKey  string
-	Timeout time.Duration
+	BaseURL string // e.g. "https://api.openai.com/v1"
+	client  *http.Client
 }
 
-// NewOpenAIClient creates a new OpenAI client
-func NewOpenAIClient(apiKey string, timeout time.Duration) *OpenAIClient {
+var _ LLMParser = (*OpenAIClient)(nil)
+
+// NewOpenAIClient creates a new OpenAI client. Requests share one HTTP client, so
+// connections to the API are kept alive and reused.
+func NewOpenAIClient(apiKey, baseURL string, timeout time.Duration) *OpenAIClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
 	return &OpenAIClient{
 		APIKey:  apiKey,
-		Timeout: timeout,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout, Transport: transport},
 	}
 }
 
+// Close releases the client's idle connections
+func (c *OpenAIClient) Close() {
+	c.client.CloseIdleConnections()
+}
+
 // FunctionCall describes a function call
 type FunctionCall struct {
 	Name      string `json:"name"`
@@ -50,19 +72,76 @@ type OpenAIChatResponse struct {
 
 // Operation represents a reminder operation
 type Operation struct {
-	Action        string `json:"action"`
-	Datetime      string `json:"datetime"`
-	Label         string `json:"label"`
-	ReminderID    string `json:"reminder_id"`
-	Answer        string `json:"answer"`
-	StartDate     string `json:"start_date"`
-	EndDate       string `json:"end_date"`
-	RecurringType string `json:"recurring_type"`
-	Time          string `json:"time"`
-	DayOfWeek     string `json:"day_of_week"`
-	DayOfMonth    string `json:"day_of_month"`
-	Timezone      string `json:"timezone"`
-	IsTodo        bool   `json:"is_todo"`
+	Action        string   `json:"action"`
+	Datetime      string   `json:"datetime"`
+	EndDatetime   string   `json:"end_datetime"`
+	Label         string   `json:"label"`
+	ReminderID    string   `json:"reminder_id"`
+	Answer        string   `json:"answer"`
+	StartDate     string   `json:"start_date"`
+	EndDate       string   `json:"end_date"`
+	RecurringType string   `json:"recurring_type"`
+	Time          string   `json:"time"`
+	EndTime       string   `json:"end_time"`
+	DayOfWeek     string   `json:"day_of_week"`
+	DayOfMonth    string   `json:"day_of_month"`
+	WeekOfMonth   string   `json:"week_of_month"`
+	Timezone      string   `json:"timezone"`
+	IsTodo        bool     `json:"is_todo"`
+	CandidateIDs  []string `json:"candidate_ids"`
+	CreatedBy     string   `json:"created_by"` // Name of the group member whose reminders show_list shows
+
+	// CandidateDatetimes lists the possible times of a create operation whose time is
+	// ambiguous ("в 8" is 08:00 or 20:00); the user picks one before the reminder is created
+	CandidateDatetimes []string `json:"candidate_datetimes"`
+
+	// DateOnly is set on create operations where the user gave a date but no time
+	DateOnly bool `json:"date_only"`
+
+	// RelativeToReminderID and Offset describe a create operation timed relative to
+	// an existing reminder ("3 days after the meeting"), used instead of Datetime
+	RelativeToReminderID string `json:"relative_to_reminder_id"`
+	Offset               string `json:"offset"`
+
+	// Silent delivers the reminder of a create or create_recurring operation without
+	// a notification sound ("тихо", "без звука")
+	Silent bool `json:"silent"`
+
+	// BusinessDays counts the date of a create operation in working days from today
+	// ("через 3 рабочих дня"); Datetime then only gives the time of day
+	BusinessDays int `json:"business_days"`
+
+	// Months counts the date of a create operation in calendar months from today
+	// ("через месяц", "через год" is 12); Datetime then only gives the time of day
+	Months int `json:"months"`
+
+	// FreeSlot moves the time of a create or adjust operation to the nearest time at or
+	// after it that's free of the user's other reminders ("на ближайшее свободное время после 15:00")
+	FreeSlot bool `json:"free_slot"`
+
+	// Repeat and RepeatInterval turn a create operation into a series of Repeat reminders
+	// RepeatInterval apart, e.g. 5 and "3m" for "5 раз каждые 3 минуты"
+	Repeat         int    `json:"repeat"`
+	RepeatInterval string `json:"repeat_interval"`
+
+	// Category of a create or create_recurring operation, e.g. "work", shown as an icon in lists
+	Category string `json:"category"`
+
+	// IntervalHours is the hours between occurrences of an hourly create_recurring
+	// operation, e.g. 2 for "каждые 2 часа с 9 до 18"
+	IntervalHours int `json:"interval_hours"`
+
+	// SkipDate ("2006-01-02") is the date of the single occurrence a skip operation
+	// leaves out of a recurring reminder
+	SkipDate string `json:"skip_date"`
+
+	// TimeDelta moves the reminder of an adjust operation relative to its current time,
+	// e.g. "-1h" for "на час раньше", used instead of Datetime or Time
+	TimeDelta string `json:"time_delta"`
+
+	// SolarEvent ("sunrise" or "sunset") times a create or create_recurring operation
+	// relative to the sun, shifted by Offset, instead of at a fixed time
+	SolarEvent string `json:"solar_event"`
 }
 
 // LLMOutputMulti represents the output JSON from LLM
@@ -71,57 +150,18 @@ type LLMOutputMulti struct {
 	UserReminders []map[string]string `json:"user_reminders"`
 }
 
-// ParseMessage parses a message using OpenAI API
-func (c *OpenAIClient) ParseMessage(ctx context.Context, prompt string, input string, userReminders []map[string]string) (LLMOutputMulti, error) {
+// ParseMessage parses a message using OpenAI API.
+// now is the user's current local time; relative dates in the input are resolved against it.
+func (c *OpenAIClient) ParseMessage(ctx context.Context, now time.Time, prompt string, input string, userReminders []map[string]string) (LLMOutputMulti, error) {
 	var result LLMOutputMulti
 
 	// Add current time to the prompt
-	fullPrompt := fmt.Sprintf(prompt, time.Now().Format("2006-01-02 15:04:05"))
+	fullPrompt := fmt.Sprintf(prompt, now.Format("2006-01-02 15:04:05"))
 
 	// Add user reminders as JSON to the prompt
 	reminderJSON, _ := json.Marshal(userReminders)
 	fullPrompt += "\n" + string(reminderJSON)
 
-	// Define the functions available to the model
-	functions := []map[string]interface{}{
-		{
-			"name":        "adjust_reminder",
-			"description": "Изменить существующее напоминание: изменить текст и/или время.",
-			"parameters": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"reminder_id": map[string]interface{}{
-						"type":        "string",
-						"description": "ID напоминания для изменения",
-					},
-					"datetime": map[string]interface{}{
-						"type":        "string",
-						"description": "Новая дата и время в формате '2006-01-02 15:04:05' (необязательно)",
-					},
-					"label": map[string]interface{}{
-						"type":        "string",
-						"description": "Новый текст напоминания (необязательно)",
-					},
-				},
-				"required": []string{"reminder_id"},
-			},
-		},
-		{
-			"name":        "delete_reminder",
-			"description": "Удалить существующее напоминание.",
-			"parameters": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"reminder_id": map[string]interface{}{
-						"type":        "string",
-						"description": "ID напоминания для удаления",
-					},
-				},
-				"required": []string{"reminder_id"},
-			},
-		},
-	}
-
 	// Create request body
 	reqBodyMap := map[string]interface{}{
 		"model": "gpt-4o",
@@ -139,7 +179,7 @@ func (c *OpenAIClient) ParseMessage(ctx context.Context, prompt string, input st
 	}
 
 	// Create HTTP request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(reqBody))
 	if err != nil {
 		return result, err
 	}
@@ -149,8 +189,7 @@ func (c *OpenAIClient) ParseMessage(ctx context.Context, prompt string, input st
 	req.Header.Set("Authorization", "Bearer "+c.APIKey)
 
 	// Make request
-	client := &http.Client{Timeout: c.Timeout}
-	resp, err := client.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return result, err
 	}
@@ -180,47 +219,42 @@ func (c *OpenAIClient) ParseMessage(ctx context.Context, prompt string, input st
 	// Process response
 	choice := openaiResp.Choices[0].Message
 	if choice.FunctionCall != nil {
-		fc := choice.FunctionCall
-		if err := json.Unmarshal([]byte(fc.Arguments), &result); err != nil {
-			return result, fmt.Errorf("error parsing function call arguments: %v", err)
+		op, err := parseFunctionCall(choice.FunctionCall)
+		if err != nil {
+			return result, err
 		}
+		result.Operations = []Operation{op}
 	} else {
-		// Extract JSON from model output
-		outputText := choice.Content
-
-		// Try to find code block markers and trim them
-		outputText = strings.Trim(outputText, "\r\n```json")
-
-		// Find the JSON part
-		startIdx := strings.Index(outputText, "{")
-		endIdx := strings.LastIndex(outputText, "}")
-
-		if startIdx >= 0 && endIdx > startIdx {
-			jsonStr := outputText[startIdx : endIdx+1]
-			if err = json.Unmarshal([]byte(jsonStr), &result); err != nil {
-				return result, fmt.Errorf("error parsing JSON from model response: %v", err)
-			}
-		} else {
-			return result, fmt.Errorf("failed to extract JSON from model output")
+		result, err = extractOutput(choice.Content)
+		if errors.Is(err, errNoJSON) && strings.TrimSpace(choice.Content) != "" {
+			// A reply in prose is usually a question back to the user
+			result.Operations = []Operation{{Action: "clarify", Answer: strings.TrimSpace(choice.Content)}}
+		} else if err != nil {
+			return result, err
 		}
 	}
 
 	// Validate operations
 	for i, op := range result.Operations {
 		if op.Action == "create" {
-			if strings.TrimSpace(op.Label) == "" || strings.TrimSpace(op.Datetime) == "" {
-				return result, fmt.Errorf("for 'create' operation, 'label' and 'datetime' are required")
+			if strings.TrimSpace(op.Label) == "" ||
+				(strings.TrimSpace(op.Datetime) == "" && strings.TrimSpace(op.RelativeToReminderID) == "" && len(op.CandidateDatetimes) == 0) {
+				return result, fmt.Errorf("for 'create' operation, 'label' and 'datetime' (or 'relative_to_reminder_id' or 'candidate_datetimes') are required")
 			}
 		} else if op.Action == "create_recurring" {
 			if strings.TrimSpace(op.Label) == "" ||
-				(strings.TrimSpace(op.Time) == "" && strings.TrimSpace(op.Datetime) == "") {
+				(strings.TrimSpace(op.Time) == "" && strings.TrimSpace(op.Datetime) == "" && strings.TrimSpace(op.SolarEvent) == "") {
 				return result, fmt.Errorf("for 'create_recurring' operation, 'label' and 'time' are required")
 			}
 			if strings.TrimSpace(op.RecurringType) == "" {
 				return result, fmt.Errorf("for 'create_recurring' operation, 'recurring_type' is required")
 			}
+		} else if op.Action == "skip" {
+			if strings.TrimSpace(op.ReminderID) == "" || strings.TrimSpace(op.SkipDate) == "" {
+				return result, fmt.Errorf("for 'skip' operation, 'reminder_id' and 'skip_date' are required")
+			}
 		} else if op.Action == "adjust" || op.Action == "delete" {
-			if strings.TrimSpace(op.ReminderID) == "" {
+			if strings.TrimSpace(op.ReminderID) == "" && len(op.CandidateIDs) == 0 {
 				return result, fmt.Errorf("for '%s' operation, 'reminder_id' is required", op.Action)
 			}
 		}
@@ -245,11 +279,16 @@ func getDefaultAnswer(action string) string {
 		return "Напоминание изменено."
 	case "delete":
 		return "Напоминание удалено."
+	case "skip":
+		return "Напоминание пропущено."
 	case "show_list":
 		return "Вот список напоминаний."
 	case "show_recurring":
 		return "Вот список регулярных напоминаний."
-	default:
+	case "set_timezone":
 		return "Операция выполнена."
+	default:
+		// Unknown actions get the bot's fallback reply instead
+		return ""
 	}
 }
@@ -0,0 +1,132 @@
+// Package logging provides the leveled logger shared by the bot's packages. It writes
+// either the classic "[RemindersBot] 2006/01/02 15:04:05 message" lines or one JSON object
+// per line for log aggregators.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Log formats
+const (
+	FormatPlain = "plain"
+	FormatJSON  = "json"
+)
+
+// Logger is a leveled logger. Debug, Info, Warn and Error log an event with key-value
+// pairs, e.g. Info("reminder_sent", "reminder_id", 5, "user_id", 42). Printf and Println
+// log free text at the info level, or at the error or warning level if it starts with
+// "Error"/"Failed" or "Warning".
+type Logger interface {
+	Debug(event string, args ...any)
+	Info(event string, args ...any)
+	Warn(event string, args ...any)
+	Error(event string, args ...any)
+	Printf(format string, v ...any)
+	Println(v ...any)
+	// Fatalf logs at the error level and exits
+	Fatalf(format string, v ...any)
+}
+
+// logger writes plain lines with plain, or JSON with json if plain is nil
+type logger struct {
+	plain    *log.Logger
+	json     *slog.Logger
+	minLevel slog.Level
+}
+
+// New creates a logger writing to w in the given format, FormatPlain or FormatJSON.
+// Plain lines start with prefix; debug enables the debug level.
+func New(w io.Writer, prefix, format string, debug bool) Logger {
+	l := &logger{minLevel: slog.LevelInfo}
+	if debug {
+		l.minLevel = slog.LevelDebug
+	}
+
+	if format != FormatJSON {
+		l.plain = log.New(w, prefix, log.LstdFlags)
+		return l
+	}
+
+	l.json = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: l.minLevel,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// Aggregators get the event name under "event" rather than slog's "msg"
+			if len(groups) == 0 && a.Key == slog.MessageKey {
+				a.Key = "event"
+			}
+			return a
+		},
+	}))
+	return l
+}
+
+func (l *logger) Debug(event string, args ...any) { l.log(slog.LevelDebug, event, args) }
+func (l *logger) Info(event string, args ...any)  { l.log(slog.LevelInfo, event, args) }
+func (l *logger) Warn(event string, args ...any)  { l.log(slog.LevelWarn, event, args) }
+func (l *logger) Error(event string, args ...any) { l.log(slog.LevelError, event, args) }
+
+func (l *logger) Printf(format string, v ...any) {
+	l.text(fmt.Sprintf(format, v...))
+}
+
+func (l *logger) Println(v ...any) {
+	l.text(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func (l *logger) Fatalf(format string, v ...any) {
+	message := fmt.Sprintf(format, v...)
+	if l.plain != nil {
+		l.plain.Output(2, message)
+	} else {
+		l.json.Log(context.Background(), slog.LevelError, "fatal", "message", message)
+	}
+	os.Exit(1)
+}
+
+// text logs a free-text message at the level its wording suggests. Plain lines look
+// exactly as with the standard logger.
+func (l *logger) text(message string) {
+	level := slog.LevelInfo
+	switch {
+	case strings.HasPrefix(message, "Error"), strings.HasPrefix(message, "Failed"):
+		level = slog.LevelError
+	case strings.HasPrefix(message, "Warning"):
+		level = slog.LevelWarn
+	}
+
+	if l.plain != nil {
+		if level >= l.minLevel {
+			l.plain.Output(3, message)
+		}
+		return
+	}
+	l.json.Log(context.Background(), level, "log", "message", message)
+}
+
+// log logs an event with key-value pairs; plain lines read "event key=value ..."
+func (l *logger) log(level slog.Level, event string, args []any) {
+	if l.plain == nil {
+		l.json.Log(context.Background(), level, event, args...)
+		return
+	}
+	if level < l.minLevel {
+		return
+	}
+
+	var line strings.Builder
+	line.WriteString(event)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&line, " %v=%v", args[i], args[i+1])
+	}
+	if len(args)%2 == 1 {
+		fmt.Fprintf(&line, " %v", args[len(args)-1])
+	}
+	l.plain.Output(3, line.String())
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"flag"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,6 +9,7 @@ import (
 	"reminders21/bot"
 	"reminders21/cli"
 	"reminders21/config"
+	"reminders21/logging"
 )
 
 var (
@@ -19,8 +19,8 @@ var (
 func main() {
 	flag.Parse()
 
-	// Set up logger
-	logger := log.New(os.Stdout, "[RemindersBot] ", log.LstdFlags)
+	// Set up logger; the format from the config applies once it's loaded
+	logger := logging.New(os.Stdout, "[RemindersBot] ", logging.FormatPlain, false)
 
 	// Check if we're running in broadcast mode
 	if *broadcastMode {
@@ -37,6 +37,7 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load config: %v", err)
 	}
+	logger = logging.New(os.Stdout, "[RemindersBot] ", cfg.LogFormat, cfg.Debug)
 
 	// Create bot
 	reminderBot, err := bot.NewReminderBot(cfg, logger)
@@ -0,0 +1,37 @@
+package speech
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// OpenAITranscriber transcribes audio with the OpenAI Whisper API
+type OpenAITranscriber struct {
+	APIKey string
+	client *http.Client
+}
+
+var _ Transcriber = (*OpenAITranscriber)(nil)
+
+// NewOpenAITranscriber creates a new OpenAITranscriber
+func NewOpenAITranscriber(apiKey string, timeout time.Duration) *OpenAITranscriber {
+	return &OpenAITranscriber{
+		APIKey: apiKey,
+		client: newHTTPClient(timeout),
+	}
+}
+
+// TranscribeFile transcribes an audio file
+func (t *OpenAITranscriber) TranscribeFile(ctx context.Context, filePath string) (string, error) {
+	return postAudio(ctx, t.client, "https://api.openai.com/v1/audio/transcriptions", filePath,
+		map[string]string{"model": "whisper-1"},
+		func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+t.APIKey)
+		})
+}
+
+// Close releases the transcriber's idle connections
+func (t *OpenAITranscriber) Close() {
+	t.client.CloseIdleConnections()
+}
@@ -4,31 +4,43 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
-// Transcriber handles audio transcription
-type Transcriber struct {
-	APIKey  string
-	Timeout time.Duration
+// ErrUnsupportedFormat is wrapped by TranscribeFile errors when the server rejects the
+// audio encoding; the same recording converted to another format, e.g. WAV, may work
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Transcriber converts audio files to text
+type Transcriber interface {
+	// TranscribeFile transcribes an audio file
+	TranscribeFile(ctx context.Context, filePath string) (string, error)
+	// Close releases idle connections
+	Close()
 }
 
-// NewTranscriber creates a new Transcriber
-func NewTranscriber(apiKey string, timeout time.Duration) *Transcriber {
-	return &Transcriber{
-		APIKey:  apiKey,
-		Timeout: timeout,
-	}
+// newHTTPClient creates the HTTP client a transcriber reuses for all its requests,
+// keeping connections to the server alive. A zero timeout leaves it to the context.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
+	return &http.Client{Timeout: timeout, Transport: transport}
 }
 
-// TranscribeFile transcribes an audio file
-func (t *Transcriber) TranscribeFile(ctx context.Context, filePath string) (string, error) {
+// postAudio uploads an audio file as the "file" field of a multipart form along with
+// the given fields and returns the "text" field of the JSON response.
+// setHeaders, if not nil, adds headers such as authorization to the request.
+func postAudio(ctx context.Context, client *http.Client, url, filePath string, fields map[string]string,
+	setHeaders func(*http.Request)) (string, error) {
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
@@ -49,9 +61,10 @@ func (t *Transcriber) TranscribeFile(ctx context.Context, filePath string) (stri
 		return "", fmt.Errorf("failed to copy file to form: %w", err)
 	}
 
-	// Add model to form
-	if err = writer.WriteField("model", "whisper-1"); err != nil {
-		return "", fmt.Errorf("failed to write model field: %w", err)
+	for name, value := range fields {
+		if err = writer.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("failed to write %s field: %w", name, err)
+		}
 	}
 
 	// Close writer
@@ -60,17 +73,18 @@ func (t *Transcriber) TranscribeFile(ctx context.Context, filePath string) (stri
 	}
 
 	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/audio/transcriptions", &requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &requestBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	if setHeaders != nil {
+		setHeaders(req)
+	}
 
 	// Make request
-	client := &http.Client{Timeout: t.Timeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to make request: %w", err)
@@ -84,18 +98,38 @@ func (t *Transcriber) TranscribeFile(ctx context.Context, filePath string) (stri
 	}
 
 	// Check response status
+	if isFormatError(resp.StatusCode, string(respBody)) {
+		return "", fmt.Errorf("%w: API returned status: %d, body: %s", ErrUnsupportedFormat, resp.StatusCode, string(respBody))
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("API returned status: %d, body: %s", resp.StatusCode, string(respBody))
 	}
 
 	// Parse response
-	var whisperResp struct {
+	var transcription struct {
 		Text string `json:"text"`
 	}
 
-	if err = json.Unmarshal(respBody, &whisperResp); err != nil {
+	if err = json.Unmarshal(respBody, &transcription); err != nil {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return whisperResp.Text, nil
+	return transcription.Text, nil
+}
+
+// isFormatError reports whether a response rejects the uploaded file's encoding, e.g.
+// OpenAI's "Invalid file format" or whisper.cpp's "failed to read audio data"
+func isFormatError(status int, body string) bool {
+	if status != http.StatusBadRequest && status != http.StatusUnsupportedMediaType &&
+		status != http.StatusInternalServerError {
+		return false
+	}
+
+	body = strings.ToLower(body)
+	for _, hint := range []string{"format", "decode", "unsupported", "corrupt", "failed to read"} {
+		if strings.Contains(body, hint) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,41 @@
+package speech
+
+import (
+	"context"
+	"net/http"
+)
+
+// WhisperCppTranscriber transcribes audio with a local whisper.cpp server, so audio
+// never leaves the machine. The server must be started with --convert to accept
+// formats other than 16 kHz WAV, such as Telegram's OGG voice messages.
+type WhisperCppTranscriber struct {
+	URL      string // Inference endpoint, e.g. "http://127.0.0.1:8080/inference"
+	Language string // Spoken language code, or "auto" to detect it
+	client   *http.Client
+}
+
+var _ Transcriber = (*WhisperCppTranscriber)(nil)
+
+// NewWhisperCppTranscriber creates a new WhisperCppTranscriber
+func NewWhisperCppTranscriber(url, language string) *WhisperCppTranscriber {
+	return &WhisperCppTranscriber{
+		URL:      url,
+		Language: language,
+		client:   newHTTPClient(0),
+	}
+}
+
+// TranscribeFile transcribes an audio file. Local models can be slow, so only the
+// context limits how long it takes.
+func (t *WhisperCppTranscriber) TranscribeFile(ctx context.Context, filePath string) (string, error) {
+	fields := map[string]string{"response_format": "json"}
+	if t.Language != "" {
+		fields["language"] = t.Language
+	}
+	return postAudio(ctx, t.client, t.URL, filePath, fields, nil)
+}
+
+// Close releases the transcriber's idle connections
+func (t *WhisperCppTranscriber) Close() {
+	t.client.CloseIdleConnections()
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"reminders21/utils"
+)
+
+// AgendaSubscription is a user's daily agenda setting
+type AgendaSubscription struct {
+	UserID    int64
+	Time      string // Local time of day in format "15:04"
+	SkipEmpty bool   // Don't send the agenda on days without reminders
+	Date      string // Local date ("2006-01-02") the agenda is due for, set by GetDueAgendasContext
+}
+
+// SetUserAgendaContext sets the local time at which the user gets their daily agenda.
+// An empty time turns the agenda off.
+func (r *ReminderRepository) SetUserAgendaContext(ctx context.Context, userID int64, agendaTime string, skipEmpty bool) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	value := sql.NullString{String: agendaTime, Valid: agendaTime != ""}
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, agenda_time, agenda_skip_empty) 
+         VALUES (?, ?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         agenda_time = ?, agenda_skip_empty = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, value, skipEmpty,
+		value, skipEmpty, now,
+	)
+	return err
+}
+
+// GetUserAgendaContext returns the user's daily agenda setting.
+// Its Time is empty if the user hasn't turned the agenda on.
+func (r *ReminderRepository) GetUserAgendaContext(ctx context.Context, userID int64) (AgendaSubscription, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	subscription := AgendaSubscription{UserID: userID, SkipEmpty: true}
+	var agendaTime sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		"SELECT agenda_time, agenda_skip_empty FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&agendaTime, &subscription.SkipEmpty)
+
+	if err == sql.ErrNoRows {
+		return subscription, nil
+	}
+	subscription.Time = agendaTime.String
+	return subscription, err
+}
+
+// GetDueAgendasContext gets the agendas due at the given time in their owners' timezones
+// that haven't been sent on that local day. Paused users are left out.
+func (r *ReminderRepository) GetDueAgendasContext(ctx context.Context, now time.Time) ([]AgendaSubscription, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx, `
+    SELECT user_id, agenda_time, agenda_skip_empty, timezone, IFNULL(agenda_sent_on, '')
+    FROM user_preferences
+    WHERE agenda_time IS NOT NULL
+      AND (paused_until IS NULL OR paused_until <= ?)`,
+		now.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []AgendaSubscription
+	for rows.Next() {
+		var subscription AgendaSubscription
+		var timezone, sentOn string
+		if err := rows.Scan(&subscription.UserID, &subscription.Time, &subscription.SkipEmpty, &timezone, &sentOn); err != nil {
+			return nil, err
+		}
+
+		location, err := utils.LoadLocation(timezone)
+		if err != nil {
+			r.logger.Printf("Invalid timezone %s for user %d: %v", timezone, subscription.UserID, err)
+			location = time.UTC
+		}
+
+		localNow := now.In(location)
+		subscription.Date = localNow.Format("2006-01-02")
+		if subscription.Time != localNow.Format("15:04") || sentOn == subscription.Date {
+			continue
+		}
+
+		due = append(due, subscription)
+	}
+
+	return due, rows.Err()
+}
+
+// ClaimAgendaContext records that the user's agenda for the given local date is being sent.
+// It returns false if it was already sent that day.
+func (r *ReminderRepository) ClaimAgendaContext(ctx context.Context, userID int64, date string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE user_preferences SET agenda_sent_on = ? WHERE user_id = ? AND IFNULL(agenda_sent_on, '') <> ?",
+		date, userID, date,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
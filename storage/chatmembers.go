@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// ChatMember is a user who has written in a group chat, as last seen by the bot
+type ChatMember struct {
+	UserID   int64
+	Name     string // First and last name
+	Username string // Telegram username without "@", may be empty
+}
+
+// SaveChatMemberContext records or updates the name of a user who wrote in a chat
+func (r *ReminderRepository) SaveChatMemberContext(ctx context.Context, chatID int64, member ChatMember) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO chat_members (chat_id, user_id, name, username, updated_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT(chat_id, user_id) DO UPDATE SET
+            name = excluded.name,
+            username = excluded.username,
+            updated_at = excluded.updated_at`,
+		chatID, member.UserID, member.Name, member.Username, time.Now().UTC(),
+	)
+	return err
+}
+
+// GetChatMembersContext returns the users seen in a chat
+func (r *ReminderRepository) GetChatMembersContext(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT user_id, name, username FROM chat_members WHERE chat_id = ? ORDER BY name", chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []ChatMember
+	for rows.Next() {
+		var member ChatMember
+		if err := rows.Scan(&member.UserID, &member.Name, &member.Username); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetChatRemindersContext gets the pending reminders delivered to a chat, optionally
+// only those created by one of the given users
+func (r *ReminderRepository) GetChatRemindersContext(ctx context.Context, chatID int64, creatorIDs []int64) ([]ReminderItem, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	query := `
+        SELECT ` + reminderColumns + `
+        FROM reminders 
+        WHERE chat_id = ? AND notified = 0 AND is_todo = 0`
+	args := []interface{}{chatID}
+	if len(creatorIDs) > 0 {
+		query += " AND user_id IN (?" + strings.Repeat(", ?", len(creatorIDs)-1) + ")"
+		for _, id := range creatorIDs {
+			args = append(args, id)
+		}
+	}
+	query += " ORDER BY reminder_time"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanReminders(rows)
+}
+
+// FindUserByUsernameContext returns the ID of the user last seen in a group chat with the
+// given username (without "@", case-insensitive). It reports false if there is none.
+func (r *ReminderRepository) FindUserByUsernameContext(ctx context.Context, username string) (int64, bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var userID int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT user_id FROM chat_members WHERE username = ? COLLATE NOCASE ORDER BY updated_at DESC LIMIT 1",
+		username,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return userID, err == nil, err
+}
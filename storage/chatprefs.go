@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"reminders21/utils"
+)
+
+// Who may delete a group chat's one-time reminders
+const (
+	DeleteByCreator = "creator" // Only the member who created the reminder
+	DeleteByAnyone  = "anyone"  // Any member of the chat
+)
+
+// ChatPreferences are the settings of a group chat, as opposed to those of its members
+type ChatPreferences struct {
+	ChatID          int64
+	Timezone        string // Timezone of the chat's agenda, set by whoever turned it on
+	DeletePolicy    string // DeleteByCreator or DeleteByAnyone
+	AgendaTime      string // Local time of day in format "15:04", empty if the agenda is off
+	AgendaSkipEmpty bool
+}
+
+// ChatAgenda is a group chat's daily agenda due to be sent
+type ChatAgenda struct {
+	ChatID    int64
+	SkipEmpty bool
+	Location  *time.Location
+	Date      string // Local date ("2006-01-02") the agenda is due for
+}
+
+// GetChatPreferencesContext returns a chat's settings, or the defaults if none were set
+func (r *ReminderRepository) GetChatPreferencesContext(ctx context.Context, chatID int64) (ChatPreferences, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	prefs := ChatPreferences{ChatID: chatID, DeletePolicy: DeleteByCreator, AgendaSkipEmpty: true}
+	var agendaTime sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		"SELECT timezone, delete_policy, agenda_time, agenda_skip_empty FROM chat_preferences WHERE chat_id = ?",
+		chatID,
+	).Scan(&prefs.Timezone, &prefs.DeletePolicy, &agendaTime, &prefs.AgendaSkipEmpty)
+
+	if err == sql.ErrNoRows {
+		return prefs, nil
+	}
+	prefs.AgendaTime = agendaTime.String
+	return prefs, err
+}
+
+// SetChatDeletePolicyContext sets who may delete the chat's one-time reminders
+func (r *ReminderRepository) SetChatDeletePolicyContext(ctx context.Context, chatID int64, policy string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO chat_preferences (chat_id, delete_policy, created_at, updated_at) 
+         VALUES (?, ?, ?, ?)
+         ON CONFLICT(chat_id) DO UPDATE SET
+         delete_policy = ?, updated_at = ?`,
+		chatID, policy, now, now,
+		policy, now,
+	)
+	return err
+}
+
+// SetChatAgendaContext sets the local time in the given timezone at which the chat gets
+// its daily agenda. An empty time turns the agenda off.
+func (r *ReminderRepository) SetChatAgendaContext(ctx context.Context, chatID int64, agendaTime string, skipEmpty bool, timezone string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	value := sql.NullString{String: agendaTime, Valid: agendaTime != ""}
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO chat_preferences (chat_id, timezone, agenda_time, agenda_skip_empty, created_at, updated_at) 
+         VALUES (?, ?, ?, ?, ?, ?)
+         ON CONFLICT(chat_id) DO UPDATE SET
+         timezone = ?, agenda_time = ?, agenda_skip_empty = ?, updated_at = ?`,
+		chatID, timezone, value, skipEmpty, now, now,
+		timezone, value, skipEmpty, now,
+	)
+	return err
+}
+
+// GetDueChatAgendasContext gets the chat agendas due at the given time in the chats'
+// timezones that haven't been sent on that local day
+func (r *ReminderRepository) GetDueChatAgendasContext(ctx context.Context, now time.Time) ([]ChatAgenda, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx, `
+    SELECT chat_id, agenda_time, agenda_skip_empty, timezone, IFNULL(agenda_sent_on, '')
+    FROM chat_preferences
+    WHERE agenda_time IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []ChatAgenda
+	for rows.Next() {
+		var agenda ChatAgenda
+		var agendaTime, timezone, sentOn string
+		if err := rows.Scan(&agenda.ChatID, &agendaTime, &agenda.SkipEmpty, &timezone, &sentOn); err != nil {
+			return nil, err
+		}
+
+		if timezone == "" {
+			timezone = r.defaultTimezone
+		}
+		agenda.Location, err = utils.LoadLocation(timezone)
+		if err != nil {
+			r.logger.Printf("Invalid timezone %s for chat %d: %v", timezone, agenda.ChatID, err)
+			agenda.Location = time.UTC
+		}
+
+		localNow := now.In(agenda.Location)
+		agenda.Date = localNow.Format("2006-01-02")
+		if agendaTime != localNow.Format("15:04") || sentOn == agenda.Date {
+			continue
+		}
+
+		due = append(due, agenda)
+	}
+
+	return due, rows.Err()
+}
+
+// ClaimChatAgendaContext records that the chat's agenda for the given local date is being
+// sent. It returns false if it was already sent that day.
+func (r *ReminderRepository) ClaimChatAgendaContext(ctx context.Context, chatID int64, date string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE chat_preferences SET agenda_sent_on = ? WHERE chat_id = ? AND IFNULL(agenda_sent_on, '') <> ?",
+		date, chatID, date,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -1,23 +1,48 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"reminders21/logging"
+	"reminders21/utils"
 )
 
 // ReminderRepository handles database operations for reminders
 type ReminderRepository struct {
-	db     *sql.DB
-	lock   sync.Mutex
-	logger *log.Logger
+	db              *sql.DB
+	lock            sync.Mutex
+	logger          logging.Logger
+	defaultTimezone string // Used for users who haven't set a timezone
+	maxReminders    int    // Active one-time reminders per user, 0 for no limit
+	maxRecurring    int    // Active recurring reminders per user, 0 for no limit
 }
 
+// ErrLimitReached is returned when adding a reminder would exceed the user's limit
+var ErrLimitReached = errors.New("reminder limit reached")
+
+// ErrDatabaseNotWritable is returned when the database can't be created or written to,
+// e.g. because of the file's or its directory's permissions
+var ErrDatabaseNotWritable = errors.New("database is not writable")
+
+// ErrInvalidTime is returned when a recurring reminder's time isn't a valid time of day
+var ErrInvalidTime = errors.New("invalid time of day, expected HH:MM")
+
+// Errors returned when a one-time reminder can't be read or changed
+var (
+	ErrReminderNotFound  = errors.New("reminder not found")
+	ErrNotOwned          = errors.New("reminder belongs to another user")
+	ErrReminderDelivered = errors.New("reminder was already delivered")
+)
+
 // ReminderItem represents a reminder in the database
 type ReminderItem struct {
 	ID           int64
@@ -27,10 +52,69 @@ type ReminderItem struct {
 	Label        string
 	Notified     bool
 	IsTodo       bool
+	PhotoFileID  string
+	Note         string    // Text of the forwarded message the reminder was set on
+	EndTime      time.Time // End of the event window, zero if the reminder has no duration
+	CreatedAt    time.Time // Zero for reminders created before it was recorded
+	SourceText   string    // The user's message the reminder was created from, empty if unknown
+	Silent       bool      // Delivered without a notification sound
+	Category     string    // Category of the reminder, e.g. "work", shown as an icon in lists; empty if none
+}
+
+// reminderColumns is the column list matching scanReminder
+const reminderColumns = "id, chat_id, user_id, reminder_time, label, notified, is_todo, photo_file_id, note, end_time, created_at, source_text, silent, category"
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// NewReminderRepository creates a new ReminderRepository
-func NewReminderRepository(dbPath string, logger *log.Logger) (*ReminderRepository, error) {
+// scanReminder scans a row selected with reminderColumns
+func scanReminder(row rowScanner) (ReminderItem, error) {
+	var reminder ReminderItem
+	var notified, isTodo, silent int
+	var endTime, createdAt sql.NullTime
+	err := row.Scan(&reminder.ID, &reminder.ChatID, &reminder.UserID, &reminder.ReminderTime,
+		&reminder.Label, &notified, &isTodo, &reminder.PhotoFileID, &reminder.Note, &endTime,
+		&createdAt, &reminder.SourceText, &silent, &reminder.Category)
+	reminder.Notified = notified > 0
+	reminder.IsTodo = isTodo > 0
+	reminder.Silent = silent > 0
+	if endTime.Valid {
+		reminder.EndTime = endTime.Time
+	}
+	if createdAt.Valid {
+		reminder.CreatedAt = createdAt.Time
+	}
+	return reminder, err
+}
+
+// scanReminders scans all rows selected with reminderColumns
+func (r *ReminderRepository) scanReminders(rows *sql.Rows) ([]ReminderItem, error) {
+	var reminders []ReminderItem
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			r.logger.Printf("Error scanning reminder row: %v", err)
+			continue
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// NewReminderRepository creates a new ReminderRepository.
+// defaultTimezone is used for users who haven't set a timezone.
+// The database's directory is created if it doesn't exist; ErrDatabaseNotWritable is
+// returned if the database can't be written to.
+func NewReminderRepository(dbPath, defaultTimezone string, logger logging.Logger) (*ReminderRepository, error) {
+	if dir := filepath.Dir(dbPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("%w: creating directory %s: %v", ErrDatabaseNotWritable, dir, err)
+		}
+	}
+
 	connStr := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", dbPath)
 	db, err := sql.Open("sqlite3", connStr)
 	if err != nil {
@@ -40,8 +124,14 @@ func NewReminderRepository(dbPath string, logger *log.Logger) (*ReminderReposito
 	db.SetMaxOpenConns(1)
 
 	repo := &ReminderRepository{
-		db:     db,
-		logger: logger,
+		db:              db,
+		logger:          logger,
+		defaultTimezone: defaultTimezone,
+	}
+
+	if err := repo.checkWritable(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %s: %v", ErrDatabaseNotWritable, dbPath, err)
 	}
 
 	if err := repo.initSchema(); err != nil {
@@ -53,12 +143,46 @@ func NewReminderRepository(dbPath string, logger *log.Logger) (*ReminderReposito
 	return repo, nil
 }
 
+// checkWritable makes sure the database can be opened and written to by creating a table
+// in a transaction that's rolled back, so a read-only file or directory is reported at
+// startup rather than on the first write
+func (r *ReminderRepository) checkWritable() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS write_check (id INTEGER)")
+	return err
+}
+
+// SetLimits sets how many active one-time and recurring reminders each user may have.
+// Zero means no limit.
+func (r *ReminderRepository) SetLimits(maxReminders, maxRecurring int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.maxReminders = maxReminders
+	r.maxRecurring = maxRecurring
+}
+
+// BackupContext writes a consistent copy of the database to path, which must not exist.
+// VACUUM INTO reads a snapshot, so it is safe in WAL mode while the bot is running.
+func (r *ReminderRepository) BackupContext(ctx context.Context, path string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
 // Close closes the database connection
 func (r *ReminderRepository) Close() error {
 	return r.db.Close()
 }
 
-// initSchema creates tables if they don't exist and migrates existing tables
+// initSchema creates tables if they don't exist and applies pending migrations
 func (r *ReminderRepository) initSchema() error {
 	// First, create tables if they don't exist
 	createTableSQL := `
@@ -91,62 +215,129 @@ func (r *ReminderRepository) initSchema() error {
     CREATE INDEX IF NOT EXISTS idx_recurring_user_id ON recurring_reminders(user_id);
     CREATE INDEX IF NOT EXISTS idx_recurring_active ON recurring_reminders(active);
     
+    CREATE TABLE IF NOT EXISTS recurring_occurrences (
+        reminder_id INTEGER NOT NULL,
+        occurrence_date TEXT NOT NULL,
+        claimed_at TIMESTAMP NOT NULL,
+        UNIQUE(reminder_id, occurrence_date)
+    );
+    
+    CREATE TABLE IF NOT EXISTS recurring_skips (
+        reminder_id INTEGER NOT NULL,
+        skip_date TEXT NOT NULL,
+        UNIQUE(reminder_id, skip_date)
+    );
+    
+    CREATE TABLE IF NOT EXISTS reminder_history (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        chat_id INTEGER NOT NULL,
+        message_id INTEGER NOT NULL,
+        reminder_id INTEGER DEFAULT NULL,
+        recurring_id INTEGER DEFAULT NULL,
+        label TEXT NOT NULL,
+        sent_at TIMESTAMP NOT NULL,
+        acknowledged_at TIMESTAMP DEFAULT NULL,
+        resurfaced_at TIMESTAMP DEFAULT NULL,
+        resurfaced_message_id INTEGER DEFAULT NULL
+    );
+    
+    CREATE INDEX IF NOT EXISTS idx_history_message ON reminder_history(chat_id, message_id);
+    CREATE INDEX IF NOT EXISTS idx_history_unacknowledged ON reminder_history(sent_at) WHERE acknowledged_at IS NULL;
+    
+    CREATE TABLE IF NOT EXISTS outbox (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        chat_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        reminder_id INTEGER DEFAULT NULL,
+        recurring_id INTEGER DEFAULT NULL,
+        label TEXT NOT NULL,
+        text TEXT NOT NULL,
+        photo_file_id TEXT NOT NULL DEFAULT '',
+        reply_markup TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP NOT NULL
+    );
+    
+    CREATE TABLE IF NOT EXISTS feedback (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        chat_id INTEGER NOT NULL,
+        username TEXT NOT NULL DEFAULT '',
+        text TEXT NOT NULL,
+        created_at TIMESTAMP NOT NULL,
+        replied_at TIMESTAMP DEFAULT NULL
+    );
+    
+    CREATE TABLE IF NOT EXISTS chat_members (
+        chat_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        name TEXT NOT NULL,
+        username TEXT NOT NULL DEFAULT '',
+        updated_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (chat_id, user_id)
+    );
+    
+    CREATE TABLE IF NOT EXISTS chat_preferences (
+        chat_id INTEGER PRIMARY KEY,
+        timezone TEXT NOT NULL DEFAULT '',
+        delete_policy TEXT NOT NULL DEFAULT 'creator',
+        agenda_time TEXT DEFAULT NULL,
+        agenda_skip_empty INTEGER NOT NULL DEFAULT 1,
+        agenda_sent_on TEXT DEFAULT NULL,
+        created_at TIMESTAMP NOT NULL,
+        updated_at TIMESTAMP NOT NULL
+    );
+    
+    CREATE TABLE IF NOT EXISTS templates (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        name TEXT NOT NULL,
+        label TEXT NOT NULL,
+        is_todo INTEGER NOT NULL DEFAULT 0,
+        recurring_type TEXT NOT NULL DEFAULT '',
+        time TEXT NOT NULL DEFAULT '',
+        day_of_week INTEGER DEFAULT NULL,
+        day_of_month INTEGER DEFAULT NULL,
+        week_of_month INTEGER DEFAULT NULL,
+        created_at TIMESTAMP NOT NULL,
+        UNIQUE(user_id, name)
+    );
+    
+    CREATE TABLE IF NOT EXISTS transfer_offers (
+        token TEXT PRIMARY KEY,
+        from_user_id INTEGER NOT NULL,
+        to_user_id INTEGER NOT NULL,
+        expires_at TIMESTAMP NOT NULL
+    );
+    
+    CREATE TABLE IF NOT EXISTS user_activity (
+        user_id INTEGER PRIMARY KEY,
+        last_active TIMESTAMP NOT NULL
+    );
+    
     CREATE TABLE IF NOT EXISTS user_preferences (
         user_id INTEGER PRIMARY KEY,
-        timezone TEXT NOT NULL DEFAULT 'Europe/Moscow',
+        timezone TEXT NOT NULL DEFAULT '%s',
         created_at TIMESTAMP NOT NULL,
         updated_at TIMESTAMP NOT NULL
     );
     `
+	createTableSQL = fmt.Sprintf(createTableSQL, strings.ReplaceAll(r.defaultTimezone, "'", "''"))
 	_, err := r.db.Exec(createTableSQL)
 	if err != nil {
 		return err
 	}
 
-	// Now handle migrations for existing tables
-	// Add is_todo column to reminders table if it doesn't exist
-	err = r.addColumnIfNotExists("reminders", "is_todo", "INTEGER DEFAULT 0")
-	if err != nil {
-		return err
-	}
-
-	// Add is_todo column to recurring_reminders table if it doesn't exist
-	err = r.addColumnIfNotExists("recurring_reminders", "is_todo", "INTEGER DEFAULT 0")
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// addColumnIfNotExists adds a column to a table if it doesn't already exist
-func (r *ReminderRepository) addColumnIfNotExists(table, column, definition string) error {
-	// Check if the column exists
-	var dummy string
-	query := fmt.Sprintf("SELECT %s FROM %s LIMIT 1", column, table)
-
-	err := r.db.QueryRow(query).Scan(&dummy)
-	if err != nil {
-		// Column doesn't exist, add it
-		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no such column") {
-			r.logger.Printf("Adding column %s to table %s", column, table)
-			alterQuery := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
-			_, err := r.db.Exec(alterQuery)
-			return err
-		}
-		return err
-	}
-
-	// Column already exists
-	return nil
+	return r.migrate()
 }
 
-// AddReminder adds a new reminder
-func (r *ReminderRepository) AddReminder(chatID, userID int64, reminderTime time.Time, label string, isTodo bool) (int64, error) {
+// AddReminderContext adds a new reminder. The ID, Notified and CreatedAt fields of the item are ignored.
+// It returns ErrLimitReached if the user already has the maximum number of active reminders.
+func (r *ReminderRepository) AddReminderContext(ctx context.Context, item ReminderItem) (int64, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -156,9 +347,24 @@ func (r *ReminderRepository) AddReminder(chatID, userID int64, reminderTime time
 		}
 	}()
 
-	result, err := tx.Exec(
-		"INSERT INTO reminders (chat_id, user_id, reminder_time, label, is_todo) VALUES (?, ?, ?, ?, ?)",
-		chatID, userID, reminderTime, label, boolToInt(isTodo),
+	if r.maxReminders > 0 {
+		var count int
+		err = tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM reminders WHERE user_id = ? AND notified = 0", item.UserID,
+		).Scan(&count)
+		if err != nil {
+			return 0, err
+		}
+		if count >= r.maxReminders {
+			err = ErrLimitReached
+			return 0, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO reminders (chat_id, user_id, reminder_time, label, is_todo, photo_file_id, note, end_time, created_at, source_text, silent, category) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		item.ChatID, item.UserID, item.ReminderTime, item.Label, boolToInt(item.IsTodo), item.PhotoFileID, item.Note,
+		nullTime(item.EndTime), time.Now().UTC(), item.SourceText, boolToInt(item.Silent), item.Category,
 	)
 	if err != nil {
 		return 0, err
@@ -176,6 +382,105 @@ func (r *ReminderRepository) AddReminder(chatID, userID int64, reminderTime time
 	return id, nil
 }
 
+// AddReminderSeriesContext adds reminders created together, e.g. "5 раз каждые 3 минуты", in
+// one transaction. They share a series ID, the ID of the first one, so the series can be
+// deleted at once with DeleteReminderSeriesContext. It returns the IDs in the order of items,
+// or ErrLimitReached if the series doesn't fit the user's limit of active reminders.
+func (r *ReminderRepository) AddReminderSeriesContext(ctx context.Context, items []ReminderItem) ([]int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if r.maxReminders > 0 {
+		var count int
+		err = tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM reminders WHERE user_id = ? AND notified = 0", items[0].UserID,
+		).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count+len(items) > r.maxReminders {
+			err = ErrLimitReached
+			return nil, err
+		}
+	}
+
+	ids := make([]int64, 0, len(items))
+	now := time.Now().UTC()
+	for _, item := range items {
+		var result sql.Result
+		result, err = tx.ExecContext(ctx,
+			"INSERT INTO reminders (chat_id, user_id, reminder_time, label, is_todo, photo_file_id, note, end_time, created_at, source_text, silent, series_id, category) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			item.ChatID, item.UserID, item.ReminderTime, item.Label, boolToInt(item.IsTodo), item.PhotoFileID, item.Note,
+			nullTime(item.EndTime), now, item.SourceText, boolToInt(item.Silent), sql.NullInt64{Int64: firstID(ids), Valid: len(ids) > 0},
+			item.Category,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var id int64
+		if id, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	// The first reminder gets its own ID as the series ID once it's known
+	if _, err = tx.ExecContext(ctx, "UPDATE reminders SET series_id = id WHERE id = ?", ids[0]); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// firstID returns the first of ids, or 0 if there are none
+func firstID(ids []int64) int64 {
+	if len(ids) == 0 {
+		return 0
+	}
+	return ids[0]
+}
+
+// DeleteReminderSeriesContext deletes the pending reminders of a series owned by the user
+// and returns how many were deleted
+func (r *ReminderRepository) DeleteReminderSeriesContext(ctx context.Context, seriesID, userID int64) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM reminders WHERE series_id = ? AND user_id = ? AND notified = 0",
+		seriesID, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// nullTime converts a zero time to NULL for SQLite
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
 // Helper function to convert bool to int for SQLite
 func boolToInt(b bool) int {
 	if b {
@@ -184,12 +489,12 @@ func boolToInt(b bool) int {
 	return 0
 }
 
-// UpdateReminderTime updates the time of a reminder
-func (r *ReminderRepository) UpdateReminderTime(id, userID int64, reminderTime time.Time) (bool, error) {
+// UpdateReminderTimeContext updates the time of a reminder. See reminderChanged for the errors returned.
+func (r *ReminderRepository) UpdateReminderTimeContext(ctx context.Context, id, userID int64, reminderTime time.Time) (bool, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		"UPDATE reminders SET reminder_time = ? WHERE id = ? AND user_id = ? AND notified = 0",
 		reminderTime, id, userID,
 	)
@@ -197,16 +502,15 @@ func (r *ReminderRepository) UpdateReminderTime(id, userID int64, reminderTime t
 		return false, err
 	}
 
-	rows, err := result.RowsAffected()
-	return rows > 0, err
+	return r.reminderChanged(ctx, result, id, userID)
 }
 
-// UpdateReminderLabel updates the label of a reminder
-func (r *ReminderRepository) UpdateReminderLabel(id, userID int64, label string) (bool, error) {
+// UpdateReminderLabelContext updates the label of a reminder. See reminderChanged for the errors returned.
+func (r *ReminderRepository) UpdateReminderLabelContext(ctx context.Context, id, userID int64, label string) (bool, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		"UPDATE reminders SET label = ? WHERE id = ? AND user_id = ? AND notified = 0",
 		label, id, userID,
 	)
@@ -214,16 +518,15 @@ func (r *ReminderRepository) UpdateReminderLabel(id, userID int64, label string)
 		return false, err
 	}
 
-	rows, err := result.RowsAffected()
-	return rows > 0, err
+	return r.reminderChanged(ctx, result, id, userID)
 }
 
-// UpdateReminder updates both time and label of a reminder
-func (r *ReminderRepository) UpdateReminder(id, userID int64, reminderTime time.Time, label string) (bool, error) {
+// UpdateReminderContext updates both time and label of a reminder. See reminderChanged for the errors returned.
+func (r *ReminderRepository) UpdateReminderContext(ctx context.Context, id, userID int64, reminderTime time.Time, label string) (bool, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		"UPDATE reminders SET reminder_time = ?, label = ? WHERE id = ? AND user_id = ? AND notified = 0",
 		reminderTime, label, id, userID,
 	)
@@ -231,16 +534,103 @@ func (r *ReminderRepository) UpdateReminder(id, userID int64, reminderTime time.
 		return false, err
 	}
 
+	return r.reminderChanged(ctx, result, id, userID)
+}
+
+// UpdateReminderChatContext moves a pending reminder to another chat, where it will be delivered
+func (r *ReminderRepository) UpdateReminderChatContext(ctx context.Context, id, userID, chatID int64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE reminders SET chat_id = ? WHERE id = ? AND user_id = ? AND notified = 0",
+		chatID, id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return r.reminderChanged(ctx, result, id, userID)
+}
+
+// reminderChanged reports whether an update or delete of a pending reminder owned by the
+// user affected a row. If it didn't, the error tells why: ErrReminderNotFound, ErrNotOwned
+// or ErrReminderDelivered. The caller must hold r.lock.
+func (r *ReminderRepository) reminderChanged(ctx context.Context, result sql.Result, id, userID int64) (bool, error) {
 	rows, err := result.RowsAffected()
-	return rows > 0, err
+	if err != nil {
+		return false, err
+	}
+	if rows > 0 {
+		return true, nil
+	}
+
+	var ownerID int64
+	var notified int
+	err = r.db.QueryRowContext(ctx, "SELECT user_id, notified FROM reminders WHERE id = ?", id).Scan(&ownerID, &notified)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, ErrReminderNotFound
+	case err != nil:
+		return false, err
+	case ownerID != userID:
+		return false, ErrNotOwned
+	case notified > 0:
+		return false, ErrReminderDelivered
+	}
+	return false, ErrReminderNotFound
 }
 
-// DeleteReminder deletes a reminder
-func (r *ReminderRepository) DeleteReminder(id, userID int64) (bool, error) {
+// GetUserChatIDsContext returns the chats in which the user has created reminders
+func (r *ReminderRepository) GetUserChatIDsContext(ctx context.Context, userID int64) ([]int64, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	result, err := r.db.Exec(
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT chat_id FROM reminders WHERE user_id = ?
+		UNION
+		SELECT chat_id FROM recurring_reminders WHERE user_id = ?`,
+		userID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			return nil, err
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+
+	return chatIDs, rows.Err()
+}
+
+// UpdateReminderEndTimeContext updates the end of a reminder's event window; a zero time clears it
+func (r *ReminderRepository) UpdateReminderEndTimeContext(ctx context.Context, id, userID int64, endTime time.Time) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE reminders SET end_time = ? WHERE id = ? AND user_id = ? AND notified = 0",
+		nullTime(endTime), id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return r.reminderChanged(ctx, result, id, userID)
+}
+
+// DeleteReminderContext deletes a pending reminder. See reminderChanged for the errors returned.
+func (r *ReminderRepository) DeleteReminderContext(ctx context.Context, id, userID int64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
 		"DELETE FROM reminders WHERE id = ? AND user_id = ? AND notified = 0",
 		id, userID,
 	)
@@ -248,17 +638,16 @@ func (r *ReminderRepository) DeleteReminder(id, userID int64) (bool, error) {
 		return false, err
 	}
 
-	rows, err := result.RowsAffected()
-	return rows > 0, err
+	return r.reminderChanged(ctx, result, id, userID)
 }
 
-// GetUserReminders gets all active reminders for a user
-func (r *ReminderRepository) GetUserReminders(userID int64) ([]ReminderItem, error) {
+// GetUserRemindersContext gets all active reminders for a user
+func (r *ReminderRepository) GetUserRemindersContext(ctx context.Context, userID int64) ([]ReminderItem, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	rows, err := r.db.Query(`
-        SELECT id, chat_id, user_id, reminder_time, label, notified, is_todo 
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+reminderColumns+`
         FROM reminders 
         WHERE user_id = ? AND notified = 0 
         ORDER BY reminder_time`, userID)
@@ -267,29 +656,16 @@ func (r *ReminderRepository) GetUserReminders(userID int64) ([]ReminderItem, err
 	}
 	defer rows.Close()
 
-	var reminders []ReminderItem
-	for rows.Next() {
-		var reminder ReminderItem
-		var notified, isTodo int
-		if err := rows.Scan(&reminder.ID, &reminder.ChatID, &reminder.UserID, &reminder.ReminderTime, &reminder.Label, &notified, &isTodo); err != nil {
-			r.logger.Printf("Error scanning reminder row: %v", err)
-			continue
-		}
-		reminder.Notified = notified > 0
-		reminder.IsTodo = isTodo > 0
-		reminders = append(reminders, reminder)
-	}
-
-	return reminders, rows.Err()
+	return r.scanReminders(rows)
 }
 
-// GetUserRemindersByPeriod gets reminders for a user within a time period
-func (r *ReminderRepository) GetUserRemindersByPeriod(userID int64, start, end time.Time) ([]ReminderItem, error) {
+// GetUserRemindersByPeriodContext gets reminders for a user within a time period
+func (r *ReminderRepository) GetUserRemindersByPeriodContext(ctx context.Context, userID int64, start, end time.Time) ([]ReminderItem, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	rows, err := r.db.Query(`
-        SELECT id, chat_id, user_id, reminder_time, label, notified, is_todo 
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+reminderColumns+`
         FROM reminders 
         WHERE user_id = ? AND notified = 0 AND reminder_time >= ? AND reminder_time < ? 
         ORDER BY reminder_time`, userID, start, end)
@@ -298,64 +674,65 @@ func (r *ReminderRepository) GetUserRemindersByPeriod(userID int64, start, end t
 	}
 	defer rows.Close()
 
-	var reminders []ReminderItem
-	for rows.Next() {
-		var reminder ReminderItem
-		var notified, isTodo int
-		if err := rows.Scan(&reminder.ID, &reminder.ChatID, &reminder.UserID, &reminder.ReminderTime, &reminder.Label, &notified, &isTodo); err != nil {
-			r.logger.Printf("Error scanning reminder row: %v", err)
-			continue
-		}
-		reminder.Notified = notified > 0
-		reminder.IsTodo = isTodo > 0
-		reminders = append(reminders, reminder)
+	return r.scanReminders(rows)
+}
+
+// GetNotifiedRemindersContext gets the user's last limit delivered reminders and completed
+// todos, most recently delivered first
+func (r *ReminderRepository) GetNotifiedRemindersContext(ctx context.Context, userID int64, limit int) ([]ReminderItem, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+reminderColumns+`
+        FROM reminders 
+        WHERE user_id = ? AND notified = 1 
+        ORDER BY COALESCE(notified_at, reminder_time) DESC, id DESC
+        LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return reminders, rows.Err()
+	return r.scanReminders(rows)
 }
 
-// GetDueReminders gets all past-due, unnotified reminders (excluding todos)
-func (r *ReminderRepository) GetDueReminders(before time.Time) ([]ReminderItem, error) {
+// GetDueRemindersContext gets up to limit past-due, unnotified reminders (excluding todos),
+// oldest first. A limit <= 0 returns all of them.
+func (r *ReminderRepository) GetDueRemindersContext(ctx context.Context, before time.Time, limit int) ([]ReminderItem, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	rows, err := r.db.Query(`
-        SELECT id, chat_id, user_id, reminder_time, label, notified, is_todo 
+	if limit <= 0 {
+		limit = -1 // SQLite treats a negative LIMIT as no limit
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+reminderColumns+`
         FROM reminders 
         WHERE reminder_time <= ? AND notified = 0 AND is_todo = 0
-        ORDER BY reminder_time`, before)
+          AND user_id NOT IN (SELECT user_id FROM user_preferences WHERE paused_until > ?)
+        ORDER BY reminder_time, id
+        LIMIT ?`, before, before.UTC(), limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reminders []ReminderItem
-	for rows.Next() {
-		var reminder ReminderItem
-		var notified, isTodo int
-		if err := rows.Scan(&reminder.ID, &reminder.ChatID, &reminder.UserID, &reminder.ReminderTime, &reminder.Label, &notified, &isTodo); err != nil {
-			r.logger.Printf("Error scanning reminder row: %v", err)
-			continue
-		}
-		reminder.Notified = notified > 0
-		reminder.IsTodo = isTodo > 0
-		reminders = append(reminders, reminder)
-	}
-
-	return reminders, rows.Err()
+	return r.scanReminders(rows)
 }
 
-// MarkAsNotified marks a reminder as notified
-func (r *ReminderRepository) MarkAsNotified(id int64) error {
+// MarkAsNotifiedContext marks a reminder as notified
+func (r *ReminderRepository) MarkAsNotifiedContext(ctx context.Context, id int64) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	_, err := r.db.Exec("UPDATE reminders SET notified = 1 WHERE id = ?", id)
+	_, err := r.db.ExecContext(ctx, "UPDATE reminders SET notified = 1, notified_at = ? WHERE id = ?", time.Now().UTC(), id)
 	return err
 }
 
-// MarkMultipleAsNotified marks multiple reminders as notified in one transaction
-func (r *ReminderRepository) MarkMultipleAsNotified(ids []int64) error {
+// MarkMultipleAsNotifiedContext marks multiple reminders as notified in one transaction
+func (r *ReminderRepository) MarkMultipleAsNotifiedContext(ctx context.Context, ids []int64) error {
 	if len(ids) == 0 {
 		return nil
 	}
@@ -363,7 +740,7 @@ func (r *ReminderRepository) MarkMultipleAsNotified(ids []int64) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -373,14 +750,15 @@ func (r *ReminderRepository) MarkMultipleAsNotified(ids []int64) error {
 		}
 	}()
 
-	stmt, err := tx.Prepare("UPDATE reminders SET notified = 1 WHERE id = ?")
+	stmt, err := tx.PrepareContext(ctx, "UPDATE reminders SET notified = 1, notified_at = ? WHERE id = ?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
+	now := time.Now().UTC()
 	for _, id := range ids {
-		if _, err := stmt.Exec(id); err != nil {
+		if _, err = stmt.ExecContext(ctx, now, id); err != nil {
 			return err
 		}
 	}
@@ -388,6 +766,59 @@ func (r *ReminderRepository) MarkMultipleAsNotified(ids []int64) error {
 	return tx.Commit()
 }
 
+// CompleteUserTodosContext marks all of a user's active one-time todos as done
+// and returns how many were completed
+func (r *ReminderRepository) CompleteUserTodosContext(ctx context.Context, userID int64) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE reminders SET notified = 1, notified_at = ? WHERE user_id = ? AND is_todo = 1 AND notified = 0",
+		time.Now().UTC(), userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetUserTodosContext gets a user's active one-time todos, earliest first
+func (r *ReminderRepository) GetUserTodosContext(ctx context.Context, userID int64) ([]ReminderItem, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+reminderColumns+`
+        FROM reminders 
+        WHERE user_id = ? AND is_todo = 1 AND notified = 0 
+        ORDER BY reminder_time, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanReminders(rows)
+}
+
+// CompleteTodoContext marks one of the user's todos as done. It returns false if the user
+// has no such active todo.
+func (r *ReminderRepository) CompleteTodoContext(ctx context.Context, id, userID int64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE reminders SET notified = 1, notified_at = ? WHERE id = ? AND user_id = ? AND is_todo = 1 AND notified = 0",
+		time.Now().UTC(), id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
 // UserPreferences represents user preferences in the database
 type UserPreferences struct {
 	UserID    int64
@@ -396,51 +827,71 @@ type UserPreferences struct {
 	UpdatedAt time.Time
 }
 
-// GetUserTimezone gets a user's timezone
-func (r *ReminderRepository) GetUserTimezone(userID int64) (string, error) {
+// GetUserTimezoneContext gets a user's timezone
+func (r *ReminderRepository) GetUserTimezoneContext(ctx context.Context, userID int64) (string, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	var timezone string
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		"SELECT timezone FROM user_preferences WHERE user_id = ?",
 		userID,
 	).Scan(&timezone)
 
 	if err == sql.ErrNoRows {
-		// Default to Moscow time if no preference is set
-		timezone = "Europe/Moscow"
-		// Create a default preference
-		_, err = r.db.Exec(
-			"INSERT INTO user_preferences (user_id, timezone, created_at, updated_at) VALUES (?, ?, ?, ?)",
-			userID, timezone, time.Now(), time.Now(),
-		)
-		if err != nil {
-			return timezone, nil // Return default even if insert fails
-		}
-		return timezone, nil
+		// Use the default timezone if no preference is set. No row is created, so new
+		// users can still be asked for their timezone (see HasUserPreferencesContext).
+		return r.defaultTimezone, nil
 	}
 
 	if err != nil {
-		return "Europe/Moscow", err // Return default timezone on error
+		return r.defaultTimezone, err // Return default timezone on error
 	}
 
 	return timezone, nil
 }
 
-// SetUserTimezone sets a user's timezone
-func (r *ReminderRepository) SetUserTimezone(userID int64, timezone string) error {
+// UpdateLastActiveContext records when the user last sent the bot a message. It's kept
+// apart from user_preferences, where a row means the user has chosen their settings.
+func (r *ReminderRepository) UpdateLastActiveContext(ctx context.Context, userID int64, at time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_activity (user_id, last_active) VALUES (?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET last_active = excluded.last_active`,
+		userID, at.UTC(),
+	)
+	return err
+}
+
+// HasUserPreferencesContext reports whether the user has a preferences row,
+// i.e. has chosen a timezone or changed another setting
+func (r *ReminderRepository) HasUserPreferencesContext(ctx context.Context, userID int64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM user_preferences WHERE user_id = ?)",
+		userID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// SetUserTimezoneContext sets a user's timezone
+func (r *ReminderRepository) SetUserTimezoneContext(ctx context.Context, userID int64, timezone string) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	// Validate timezone string
-	_, err := time.LoadLocation(timezone)
+	_, err := utils.LoadLocation(timezone)
 	if err != nil {
 		return fmt.Errorf("invalid timezone: %s", timezone)
 	}
 
 	now := time.Now()
-	_, err = r.db.Exec(
+	_, err = r.db.ExecContext(ctx,
 		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at) 
          VALUES (?, ?, ?, ?)
          ON CONFLICT(user_id) DO UPDATE SET
@@ -451,36 +902,254 @@ func (r *ReminderRepository) SetUserTimezone(userID int64, timezone string) erro
 	return err
 }
 
-// GetReminderByID gets a specific reminder by ID
-func (r *ReminderRepository) GetReminderByID(id int64) (*ReminderItem, error) {
+// PauseUserContext pauses delivery of all reminders for a user until the given time
+func (r *ReminderRepository) PauseUserContext(ctx context.Context, userID int64, until time.Time) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	var reminder ReminderItem
-	var notified int
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, paused_until) 
+         VALUES (?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         paused_until = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, until.UTC(),
+		until.UTC(), now,
+	)
+	return err
+}
 
-	err := r.db.QueryRow(`
-        SELECT id, chat_id, user_id, reminder_time, label, notified 
-        FROM reminders 
-        WHERE id = ?`, id).Scan(
-		&reminder.ID, &reminder.ChatID, &reminder.UserID,
-		&reminder.ReminderTime, &reminder.Label, &notified)
+// ResumeUserContext clears a user's pause. It reports whether the user was paused.
+func (r *ReminderRepository) ResumeUserContext(ctx context.Context, userID int64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
 
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE user_preferences SET paused_until = NULL, updated_at = ? WHERE user_id = ? AND paused_until > ?",
+		time.Now(), userID, time.Now().UTC(),
+	)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	reminder.Notified = notified > 0
-	return &reminder, nil
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
-// GetAllActiveChatIDs returns a list of unique chat IDs from all active users
-func (r *ReminderRepository) GetAllActiveChatIDs() ([]int64, error) {
+// GetUserPausedUntilContext returns the time until which a user's reminders are paused.
+// The zero time is returned if the user is not paused.
+func (r *ReminderRepository) GetUserPausedUntilContext(ctx context.Context, userID int64) (time.Time, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	// Get all unique chat IDs from all tables
-	query := `
+	var pausedUntil sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		"SELECT paused_until FROM user_preferences WHERE user_id = ? AND paused_until > ?",
+		userID, time.Now().UTC(),
+	).Scan(&pausedUntil)
+
+	if err == sql.ErrNoRows || (err == nil && !pausedUntil.Valid) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return pausedUntil.Time, nil
+}
+
+// SetUserDefaultSnoozeContext sets a user's default snooze interval in minutes
+func (r *ReminderRepository) SetUserDefaultSnoozeContext(ctx context.Context, userID int64, minutes int) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, default_snooze_minutes) 
+         VALUES (?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         default_snooze_minutes = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, minutes,
+		minutes, now,
+	)
+	return err
+}
+
+// SetUserShowOriginContext sets whether a user's delivered reminders mention when and
+// how they were created
+func (r *ReminderRepository) SetUserShowOriginContext(ctx context.Context, userID int64, show bool) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, show_origin) 
+         VALUES (?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         show_origin = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, show,
+		show, now,
+	)
+	return err
+}
+
+// GetUserShowOriginContext reports whether a user's delivered reminders mention when and
+// how they were created
+func (r *ReminderRepository) GetUserShowOriginContext(ctx context.Context, userID int64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var show bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT show_origin FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&show)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return show, err
+}
+
+// SetUserDeliveryPrefixContext sets the text put before the label of a user's delivered
+// reminders, "" for the label alone
+func (r *ReminderRepository) SetUserDeliveryPrefixContext(ctx context.Context, userID int64, prefix string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, delivery_prefix) 
+         VALUES (?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         delivery_prefix = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, prefix,
+		prefix, now,
+	)
+	return err
+}
+
+// GetUserDeliveryPrefixContext gets the text put before the label of a user's delivered
+// reminders, "" if none is set
+func (r *ReminderRepository) GetUserDeliveryPrefixContext(ctx context.Context, userID int64) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var prefix string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT delivery_prefix FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&prefix)
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return prefix, err
+}
+
+// SetUserLocationContext stores the coordinates a user shared, used for sunrise and sunset times
+func (r *ReminderRepository) SetUserLocationContext(ctx context.Context, userID int64, lat, lon float64) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, latitude, longitude) 
+         VALUES (?, ?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         latitude = ?, longitude = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, lat, lon,
+		lat, lon, now,
+	)
+	return err
+}
+
+// GetUserLocationContext returns the coordinates a user shared.
+// It reports false if the user hasn't shared a location.
+func (r *ReminderRepository) GetUserLocationContext(ctx context.Context, userID int64) (lat, lon float64, ok bool, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var latitude, longitude sql.NullFloat64
+	err = r.db.QueryRowContext(ctx,
+		"SELECT latitude, longitude FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&latitude, &longitude)
+
+	if err == sql.ErrNoRows || (err == nil && (!latitude.Valid || !longitude.Valid)) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return latitude.Float64, longitude.Float64, true, nil
+}
+
+// GetUserDefaultSnoozeContext returns a user's default snooze interval in minutes,
+// or 0 if the user hasn't set one
+func (r *ReminderRepository) GetUserDefaultSnoozeContext(ctx context.Context, userID int64) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var minutes sql.NullInt64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT default_snooze_minutes FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&minutes)
+
+	if err == sql.ErrNoRows || (err == nil && !minutes.Valid) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return int(minutes.Int64), nil
+}
+
+// SnoozeReminderContext moves a delivered or pending reminder to a new time and
+// marks it as not notified so it is delivered again
+func (r *ReminderRepository) SnoozeReminderContext(ctx context.Context, id, userID int64, until time.Time) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE reminders SET reminder_time = ?, notified = 0, notified_at = NULL WHERE id = ? AND user_id = ?",
+		until.UTC(), id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// GetReminderByIDContext gets a specific reminder by ID, or returns ErrReminderNotFound
+func (r *ReminderRepository) GetReminderByIDContext(ctx context.Context, id int64) (*ReminderItem, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	reminder, err := scanReminder(r.db.QueryRowContext(ctx, `
+        SELECT `+reminderColumns+`
+        FROM reminders 
+        WHERE id = ?`, id))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrReminderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &reminder, nil
+}
+
+// activeChatsQuery selects the unique chat IDs from all active users. user_activity isn't
+// a source of recipients: it also records users who only wrote in a group and may never
+// have opened a private chat with the bot. It only filters by activity.
+const activeChatsQuery = `
 	SELECT DISTINCT chat_id FROM (
 		SELECT chat_id FROM reminders WHERE notified = 0
 		UNION
@@ -490,12 +1159,66 @@ func (r *ReminderRepository) GetAllActiveChatIDs() ([]int64, error) {
 	) AS active_chats
 	`
 
-	rows, err := r.db.Query(query)
+// GetAllActiveChatIDsContext returns a list of unique chat IDs from all active users
+func (r *ReminderRepository) GetAllActiveChatIDsContext(ctx context.Context) ([]int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx, activeChatsQuery)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return r.scanChatIDs(rows)
+}
+
+// BroadcastFilter narrows down the chats a broadcast is sent to. Zero fields don't filter.
+type BroadcastFilter struct {
+	Timezones   []string  // The chat's timezone, else its user's, else the default, is one of these
+	ActiveSince time.Time // The chat's user sent a message, or reminders were created or acknowledged in the chat, since
+}
+
+// GetBroadcastChatIDsContext returns the chat IDs of GetAllActiveChatIDsContext matching the filter
+func (r *ReminderRepository) GetBroadcastChatIDsContext(ctx context.Context, filter BroadcastFilter) ([]int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	query := "SELECT chat_id FROM (" + activeChatsQuery + ") AS c WHERE 1 = 1"
+	var args []interface{}
+
+	if len(filter.Timezones) > 0 {
+		query += ` AND COALESCE(
+		    NULLIF((SELECT timezone FROM chat_preferences p WHERE p.chat_id = c.chat_id), ''),
+		    (SELECT timezone FROM user_preferences p WHERE p.user_id = c.chat_id), ?) IN (?` +
+			strings.Repeat(", ?", len(filter.Timezones)-1) + ")"
+		args = append(args, r.defaultTimezone)
+		for _, timezone := range filter.Timezones {
+			args = append(args, timezone)
+		}
+	}
+
+	if !filter.ActiveSince.IsZero() {
+		query += ` AND (EXISTS(SELECT 1 FROM reminders WHERE chat_id = c.chat_id AND created_at >= ?)
+		    OR EXISTS(SELECT 1 FROM recurring_reminders WHERE chat_id = c.chat_id AND created_at >= ?)
+		    OR EXISTS(SELECT 1 FROM reminder_history WHERE chat_id = c.chat_id AND acknowledged_at >= ?)
+		    OR EXISTS(SELECT 1 FROM user_preferences WHERE user_id = c.chat_id AND updated_at >= ?)
+		    OR EXISTS(SELECT 1 FROM user_activity WHERE user_id = c.chat_id AND last_active >= ?))`
+		since := filter.ActiveSince.UTC()
+		args = append(args, since, since, since, since, since)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanChatIDs(rows)
+}
+
+// scanChatIDs scans rows of chat IDs, skipping invalid ones
+func (r *ReminderRepository) scanChatIDs(rows *sql.Rows) ([]int64, error) {
 	var chatIDs []int64
 	for rows.Next() {
 		var chatID int64
@@ -508,3 +1231,115 @@ func (r *ReminderRepository) GetAllActiveChatIDs() ([]int64, error) {
 
 	return chatIDs, rows.Err()
 }
+
+// UserSummary describes a user for the admin /users command
+type UserSummary struct {
+	UserID             int64
+	Timezone           string
+	ActiveReminders    int
+	RecurringReminders int
+	PausedUntil        time.Time
+	LastActive         time.Time // Zero if the user hasn't sent a message since it was recorded
+}
+
+// GetUserSummariesContext returns a summary of every known user,
+// ordered by the number of active reminders (busiest first)
+func (r *ReminderRepository) GetUserSummariesContext(ctx context.Context) ([]UserSummary, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	query := `
+	SELECT u.user_id,
+	       IFNULL(p.timezone, ?),
+	       (SELECT COUNT(*) FROM reminders WHERE user_id = u.user_id AND notified = 0),
+	       (SELECT COUNT(*) FROM recurring_reminders WHERE user_id = u.user_id AND active = 1),
+	       p.paused_until,
+	       a.last_active
+	FROM (
+		SELECT user_id FROM reminders
+		UNION
+		SELECT user_id FROM recurring_reminders
+		UNION
+		SELECT user_id FROM user_preferences
+		UNION
+		SELECT user_id FROM user_activity
+	) AS u
+	LEFT JOIN user_preferences p ON p.user_id = u.user_id
+	LEFT JOIN user_activity a ON a.user_id = u.user_id
+	ORDER BY 3 DESC, 4 DESC, u.user_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, r.defaultTimezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []UserSummary
+	for rows.Next() {
+		var user UserSummary
+		var pausedUntil, lastActive sql.NullTime
+		if err := rows.Scan(&user.UserID, &user.Timezone, &user.ActiveReminders,
+			&user.RecurringReminders, &pausedUntil, &lastActive); err != nil {
+			r.logger.Printf("Error scanning user summary: %v", err)
+			continue
+		}
+		if pausedUntil.Valid && pausedUntil.Time.After(time.Now()) {
+			user.PausedUntil = pausedUntil.Time
+		}
+		if lastActive.Valid {
+			user.LastActive = lastActive.Time
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// GlobalStats holds aggregate statistics across all users
+type GlobalStats struct {
+	TotalUsers         int
+	PausedUsers        int
+	ActiveLast24h      int // Users who sent a message in the last 24 hours
+	ActiveReminders    int
+	RecurringReminders int
+	SentLast24h        int // One-off reminders delivered in the last 24 hours
+	RecurringLast24h   int // Recurring reminders delivered in the last 24 hours
+	Overdue            int // Due more than a few minutes ago but still not delivered
+}
+
+// GetGlobalStatsContext returns aggregate statistics across all users.
+// Reminders due before overdueBefore that are still not delivered are counted as overdue.
+func (r *ReminderRepository) GetGlobalStatsContext(ctx context.Context, now, overdueBefore time.Time) (GlobalStats, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now = now.UTC()
+	dayAgo := now.Add(-24 * time.Hour)
+
+	var stats GlobalStats
+	err := r.db.QueryRowContext(ctx, `
+	SELECT
+		(SELECT COUNT(*) FROM (
+			SELECT user_id FROM reminders
+			UNION
+			SELECT user_id FROM recurring_reminders
+			UNION
+			SELECT user_id FROM user_preferences
+			UNION
+			SELECT user_id FROM user_activity
+		)),
+		(SELECT COUNT(*) FROM user_preferences WHERE paused_until > ?),
+		(SELECT COUNT(*) FROM user_activity WHERE last_active >= ?),
+		(SELECT COUNT(*) FROM reminders WHERE notified = 0),
+		(SELECT COUNT(*) FROM recurring_reminders WHERE active = 1),
+		(SELECT COUNT(*) FROM reminders WHERE notified = 1 AND notified_at >= ?),
+		(SELECT COUNT(*) FROM recurring_occurrences WHERE claimed_at >= ?),
+		(SELECT COUNT(*) FROM reminders WHERE notified = 0 AND is_todo = 0 AND reminder_time < ?
+			AND user_id NOT IN (SELECT user_id FROM user_preferences WHERE paused_until > ?))`,
+		now, dayAgo, dayAgo, dayAgo, overdueBefore.UTC(), now,
+	).Scan(&stats.TotalUsers, &stats.PausedUsers, &stats.ActiveLast24h, &stats.ActiveReminders, &stats.RecurringReminders,
+		&stats.SentLast24h, &stats.RecurringLast24h, &stats.Overdue)
+
+	return stats, err
+}
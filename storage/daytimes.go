@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DayTimes are the times of day ("15:04") a user means by "утром", "днём" and "вечером".
+// Fields the user hasn't set are empty.
+type DayTimes struct {
+	Morning   string
+	Afternoon string
+	Evening   string
+}
+
+// SetUserDayTimesContext stores the times of day a user means by "утром", "днём" and "вечером".
+// Empty fields reset those times to the default.
+func (r *ReminderRepository) SetUserDayTimesContext(ctx context.Context, userID int64, times DayTimes) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	morning := sql.NullString{String: times.Morning, Valid: times.Morning != ""}
+	afternoon := sql.NullString{String: times.Afternoon, Valid: times.Afternoon != ""}
+	evening := sql.NullString{String: times.Evening, Valid: times.Evening != ""}
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, morning_time, afternoon_time, evening_time) 
+         VALUES (?, ?, ?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         morning_time = ?, afternoon_time = ?, evening_time = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, morning, afternoon, evening,
+		morning, afternoon, evening, now,
+	)
+	return err
+}
+
+// GetUserDayTimesContext returns the times of day a user means by "утром", "днём" and "вечером"
+func (r *ReminderRepository) GetUserDayTimesContext(ctx context.Context, userID int64) (DayTimes, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var morning, afternoon, evening sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		"SELECT morning_time, afternoon_time, evening_time FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&morning, &afternoon, &evening)
+
+	if err == sql.ErrNoRows {
+		return DayTimes{}, nil
+	}
+	return DayTimes{Morning: morning.String, Afternoon: afternoon.String, Evening: evening.String}, err
+}
+
+// SetUserDefaultReminderHourContext sets the hour used for reminders given only a date
+func (r *ReminderRepository) SetUserDefaultReminderHourContext(ctx context.Context, userID int64, hour int) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_preferences (user_id, timezone, created_at, updated_at, default_reminder_hour) 
+         VALUES (?, ?, ?, ?, ?)
+         ON CONFLICT(user_id) DO UPDATE SET
+         default_reminder_hour = ?, updated_at = ?`,
+		userID, r.defaultTimezone, now, now, hour,
+		hour, now,
+	)
+	return err
+}
+
+// GetUserDefaultReminderHourContext returns the hour used for a user's reminders given only a date.
+// It reports false if the user hasn't set one.
+func (r *ReminderRepository) GetUserDefaultReminderHourContext(ctx context.Context, userID int64) (int, bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var hour sql.NullInt64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT default_reminder_hour FROM user_preferences WHERE user_id = ?",
+		userID,
+	).Scan(&hour)
+
+	if err == sql.ErrNoRows || (err == nil && !hour.Valid) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return int(hour.Int64), true, nil
+}
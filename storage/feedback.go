@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Feedback is a message a user sent to the bot's operators with /feedback
+type Feedback struct {
+	ID        int64
+	UserID    int64
+	ChatID    int64
+	Username  string
+	Text      string
+	CreatedAt time.Time
+}
+
+// AddFeedbackContext stores a user's feedback for later review
+func (r *ReminderRepository) AddFeedbackContext(ctx context.Context, feedback Feedback) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO feedback (user_id, chat_id, username, text, created_at) VALUES (?, ?, ?, ?, ?)",
+		feedback.UserID, feedback.ChatID, feedback.Username, feedback.Text, feedback.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// MarkFeedbackRepliedContext records that an admin replied to the user's unanswered feedback
+// and returns how many messages it covered
+func (r *ReminderRepository) MarkFeedbackRepliedContext(ctx context.Context, userID int64, at time.Time) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE feedback SET replied_at = ? WHERE user_id = ? AND replied_at IS NULL",
+		at.UTC(), userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
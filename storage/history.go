@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// HistoryEntry records a delivered reminder message and whether the user acknowledged it
+type HistoryEntry struct {
+	ID             int64
+	UserID         int64
+	ChatID         int64
+	MessageID      int
+	ReminderID     int64 // One-time reminder, 0 for recurring ones
+	RecurringID    int64 // Recurring reminder, 0 for one-time ones
+	Label          string
+	SentAt         time.Time
+	AcknowledgedAt time.Time
+}
+
+// AddHistoryContext records a delivered reminder message
+func (r *ReminderRepository) AddHistoryContext(ctx context.Context, entry HistoryEntry) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx, `
+        INSERT INTO reminder_history (user_id, chat_id, message_id, reminder_id, recurring_id, label, sent_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.UserID, entry.ChatID, entry.MessageID,
+		sql.NullInt64{Int64: entry.ReminderID, Valid: entry.ReminderID > 0},
+		sql.NullInt64{Int64: entry.RecurringID, Valid: entry.RecurringID > 0},
+		entry.Label, entry.SentAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// AcknowledgeHistoryContext records that the user saw the reminder delivered (or re-surfaced)
+// in the given message. It returns false if there is no such unacknowledged message of the user.
+func (r *ReminderRepository) AcknowledgeHistoryContext(ctx context.Context, chatID int64, messageID int, userID int64, at time.Time) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE reminder_history SET acknowledged_at = ?
+        WHERE chat_id = ? AND (message_id = ? OR resurfaced_message_id = ?)
+          AND user_id = ? AND acknowledged_at IS NULL`,
+		at.UTC(), chatID, messageID, messageID, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// GetUnacknowledgedHistoryContext gets reminders sent before the given time that were
+// neither acknowledged nor re-surfaced yet, oldest first
+func (r *ReminderRepository) GetUnacknowledgedHistoryContext(ctx context.Context, before time.Time, limit int) ([]HistoryEntry, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, user_id, chat_id, message_id, IFNULL(reminder_id, 0), IFNULL(recurring_id, 0), label, sent_at
+        FROM reminder_history
+        WHERE acknowledged_at IS NULL AND resurfaced_at IS NULL AND sent_at <= ?
+        ORDER BY sent_at
+        LIMIT ?`,
+		before.UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.ChatID, &entry.MessageID,
+			&entry.ReminderID, &entry.RecurringID, &entry.Label, &entry.SentAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// MarkHistoryResurfacedContext records that an unacknowledged reminder was sent again
+// in the given message, so it is re-surfaced only once
+func (r *ReminderRepository) MarkHistoryResurfacedContext(ctx context.Context, id int64, messageID int, at time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE reminder_history SET resurfaced_at = ?, resurfaced_message_id = ? WHERE id = ?",
+		at.UTC(), sql.NullInt64{Int64: int64(messageID), Valid: messageID > 0}, id,
+	)
+	return err
+}
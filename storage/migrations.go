@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is a versioned schema change applied once, in order, at startup. Migrations
+// must be idempotent: databases created before versioning was introduced run them all
+// again, so e.g. columns that already exist are left alone.
+type migration struct {
+	version int
+	name    string
+	up      func(r *ReminderRepository, tx *sql.Tx) error
+}
+
+// migrations are the schema changes on top of the tables created by initSchema. Add new
+// ones at the end with the next version; never reorder or edit ones already released.
+var migrations = []migration{
+	{1, "add reminders.is_todo", addColumns("reminders", "is_todo", "INTEGER DEFAULT 0")},
+	{2, "add recurring_reminders.is_todo", addColumns("recurring_reminders", "is_todo", "INTEGER DEFAULT 0")},
+	{3, "add reminders.photo_file_id", addColumns("reminders", "photo_file_id", "TEXT NOT NULL DEFAULT ''")},
+	{4, "add reminders.note", addColumns("reminders", "note", "TEXT NOT NULL DEFAULT ''")},
+	{5, "add reminders.end_time", addColumns("reminders", "end_time", "DATETIME DEFAULT NULL")},
+	{6, "add reminders.notified_at", addColumns("reminders", "notified_at", "TIMESTAMP DEFAULT NULL")},
+	{7, "add recurring_reminders.skip_until", addColumns("recurring_reminders", "skip_until", "TEXT DEFAULT NULL")},
+	{8, "add recurring_reminders.week_of_month", addColumns("recurring_reminders", "week_of_month", "INTEGER DEFAULT NULL")},
+	{9, "add solar schedule to recurring_reminders", addColumns("recurring_reminders",
+		"solar_event", "TEXT NOT NULL DEFAULT ''",
+		"solar_offset", "INTEGER NOT NULL DEFAULT 0",
+		"latitude", "REAL DEFAULT NULL",
+		"longitude", "REAL DEFAULT NULL")},
+	{10, "add location to user_preferences", addColumns("user_preferences",
+		"latitude", "REAL DEFAULT NULL",
+		"longitude", "REAL DEFAULT NULL")},
+	{11, "add daily agenda to user_preferences", addColumns("user_preferences",
+		"agenda_time", "TEXT DEFAULT NULL",
+		"agenda_skip_empty", "INTEGER NOT NULL DEFAULT 1",
+		"agenda_sent_on", "TEXT DEFAULT NULL")},
+	{12, "add creation context to reminders", addColumns("reminders",
+		"created_at", "TIMESTAMP DEFAULT NULL",
+		"source_text", "TEXT NOT NULL DEFAULT ''")},
+	{13, "add user_preferences.show_origin", addColumns("user_preferences", "show_origin", "INTEGER NOT NULL DEFAULT 0")},
+	{14, "add user_preferences.default_reminder_hour", addColumns("user_preferences", "default_reminder_hour", "INTEGER DEFAULT NULL")},
+	{15, "add named times of day to user_preferences", addColumns("user_preferences",
+		"morning_time", "TEXT DEFAULT NULL",
+		"afternoon_time", "TEXT DEFAULT NULL",
+		"evening_time", "TEXT DEFAULT NULL")},
+	{16, "add recurring_reminders.end_time", addColumns("recurring_reminders", "end_time", "TEXT DEFAULT NULL")},
+	{17, "add recurring_reminders.enabled", addColumns("recurring_reminders", "enabled", "INTEGER NOT NULL DEFAULT 1")},
+	{18, "add user_preferences.paused_until", addColumns("user_preferences", "paused_until", "TIMESTAMP DEFAULT NULL")},
+	{19, "add user_preferences.default_snooze_minutes", addColumns("user_preferences", "default_snooze_minutes", "INTEGER DEFAULT NULL")},
+	{20, "add silent to reminders, recurring_reminders and outbox", func(r *ReminderRepository, tx *sql.Tx) error {
+		for _, table := range []string{"reminders", "recurring_reminders", "outbox"} {
+			if err := r.addColumnIfNotExists(tx, table, "silent", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{21, "add user_preferences.delivery_prefix", addColumns("user_preferences", "delivery_prefix", "TEXT NOT NULL DEFAULT ''")},
+	{22, "add reminders.series_id", addColumns("reminders", "series_id", "INTEGER DEFAULT NULL")},
+	{23, "add recurring_reminders.interval_hours", addColumns("recurring_reminders", "interval_hours", "INTEGER NOT NULL DEFAULT 1")},
+	{24, "move skip_until to recurring_skips", func(r *ReminderRepository, tx *sql.Tx) error {
+		_, err := tx.Exec(`
+        INSERT OR IGNORE INTO recurring_skips (reminder_id, skip_date)
+        SELECT id, skip_until FROM recurring_reminders WHERE IFNULL(skip_until, '') <> '';
+        UPDATE recurring_reminders SET skip_until = NULL WHERE skip_until IS NOT NULL;`)
+		return err
+	}},
+	{25, "add category to reminders and recurring_reminders", func(r *ReminderRepository, tx *sql.Tx) error {
+		for _, table := range []string{"reminders", "recurring_reminders"} {
+			if err := r.addColumnIfNotExists(tx, table, "category", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{26, "add send retries to outbox", addColumns("outbox",
+		"attempts", "INTEGER NOT NULL DEFAULT 0",
+		"next_attempt_at", "TIMESTAMP DEFAULT NULL")},
+}
+
+// addColumns returns a migration step adding columns to table, given as pairs of column
+// names and definitions
+func addColumns(table string, columns ...string) func(r *ReminderRepository, tx *sql.Tx) error {
+	return func(r *ReminderRepository, tx *sql.Tx) error {
+		for i := 0; i+1 < len(columns); i += 2 {
+			if err := r.addColumnIfNotExists(tx, table, columns[i], columns[i+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migrate applies the migrations newer than the database's schema version, each in its
+// own transaction together with recording its version in schema_migrations
+func (r *ReminderRepository) migrate() error {
+	_, err := r.db.Exec(`
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INTEGER PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at TIMESTAMP NOT NULL
+    )`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := r.db.QueryRow("SELECT IFNULL(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := r.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		r.logger.Printf("Applied migration %d: %s", m.version, m.name)
+	}
+
+	return nil
+}
+
+// applyMigration runs a migration and records its version in one transaction
+func (r *ReminderRepository) applyMigration(m migration) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(r, tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addColumnIfNotExists adds a column to a table if it doesn't already exist
+func (r *ReminderRepository) addColumnIfNotExists(tx *sql.Tx, table, column, definition string) error {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM pragma_table_info(?) WHERE name = ?)", table, column).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	r.logger.Printf("Adding column %s to table %s", column, table)
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
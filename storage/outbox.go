@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// OutboxMessage is a reminder message queued for sending. Messages are written to the
+// outbox before they are sent and deleted once Telegram accepts them, so a restart
+// mid-batch delivers them late (or twice) rather than never.
+type OutboxMessage struct {
+	ID          int64
+	ChatID      int64
+	UserID      int64
+	ReminderID  int64 // One-time reminder, 0 for recurring ones
+	RecurringID int64 // Recurring reminder, 0 for one-time ones
+	Label       string
+	Text        string
+	PhotoFileID string
+	ReplyMarkup string // JSON-encoded inline keyboard, empty if none
+	Silent      bool   // Sent without a notification sound
+	Attempts    int    // Failed sends so far
+	CreatedAt   time.Time
+}
+
+// EnqueueOutboxContext queues messages for sending and returns them with their IDs set.
+// One-time reminders are marked as notified in the same transaction, so a reminder is
+// either still due or queued.
+func (r *ReminderRepository) EnqueueOutboxContext(ctx context.Context, messages []OutboxMessage) ([]OutboxMessage, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now().UTC()
+	queued := make([]OutboxMessage, 0, len(messages))
+	for _, m := range messages {
+		var result sql.Result
+		result, err = tx.ExecContext(ctx, `
+            INSERT INTO outbox (chat_id, user_id, reminder_id, recurring_id, label, text, photo_file_id, reply_markup, silent, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			m.ChatID, m.UserID,
+			sql.NullInt64{Int64: m.ReminderID, Valid: m.ReminderID > 0},
+			sql.NullInt64{Int64: m.RecurringID, Valid: m.RecurringID > 0},
+			m.Label, m.Text, m.PhotoFileID, m.ReplyMarkup, boolToInt(m.Silent), now,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if m.ID, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+		m.CreatedAt = now
+
+		if m.ReminderID > 0 {
+			_, err = tx.ExecContext(ctx, "UPDATE reminders SET notified = 1, notified_at = ? WHERE id = ?", now, m.ReminderID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		queued = append(queued, m)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return queued, nil
+}
+
+// GetOutboxContext gets up to limit queued messages, oldest first, leaving out those whose
+// retry is scheduled later. A limit of 0 or less gets all of them.
+func (r *ReminderRepository) GetOutboxContext(ctx context.Context, limit int) ([]OutboxMessage, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, chat_id, user_id, IFNULL(reminder_id, 0), IFNULL(recurring_id, 0),
+               label, text, photo_file_id, reply_markup, silent, attempts, created_at
+        FROM outbox
+        WHERE next_attempt_at IS NULL OR next_attempt_at <= ?
+        ORDER BY id
+        LIMIT ?`,
+		time.Now().UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		var m OutboxMessage
+		var silent int
+		if err := rows.Scan(&m.ID, &m.ChatID, &m.UserID, &m.ReminderID, &m.RecurringID,
+			&m.Label, &m.Text, &m.PhotoFileID, &m.ReplyMarkup, &silent, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		m.Silent = silent > 0
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// DeferOutboxContext records a failed send of a queued message and schedules its next
+// attempt, so it doesn't hold up the messages behind it
+func (r *ReminderRepository) DeferOutboxContext(ctx context.Context, id int64, nextAttempt time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE outbox SET attempts = attempts + 1, next_attempt_at = ? WHERE id = ?",
+		nextAttempt.UTC(), id,
+	)
+	return err
+}
+
+// DeleteOutboxContext removes a message from the outbox once it is sent
+func (r *ReminderRepository) DeleteOutboxContext(ctx context.Context, id int64) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM outbox WHERE id = ?", id)
+	return err
+}
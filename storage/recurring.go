@@ -1,8 +1,16 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"reminders21/utils"
 )
 
 // RecurringType defines the type of recurrence
@@ -12,6 +20,21 @@ const (
 	RecurringDaily   RecurringType = "daily"
 	RecurringWeekly  RecurringType = "weekly"
 	RecurringMonthly RecurringType = "monthly"
+	// RecurringMonthlyWeekday repeats on an ordinal weekday of the month, e.g. the first Monday
+	RecurringMonthlyWeekday RecurringType = "monthly_weekday"
+	// RecurringHourly repeats every IntervalHours hours each day, from Time until EndTime
+	// or the end of the day
+	RecurringHourly RecurringType = "hourly"
+)
+
+const (
+	// LastWeekOfMonth is the WeekOfMonth of reminders on the last given weekday of the month
+	LastWeekOfMonth = -1
+	// Workday is a DayOfWeek matching Monday to Friday: every workday for weekly reminders,
+	// e.g. "по будням", or e.g. "the last workday of the month" for monthly_weekday ones
+	Workday = 7
+	// Weekend is a DayOfWeek of weekly reminders matching Saturday and Sunday
+	Weekend = 8
 )
 
 // RecurringReminder represents a recurring reminder
@@ -23,26 +46,107 @@ type RecurringReminder struct {
 	CreatedAt     time.Time
 	RecurringType RecurringType
 	Time          string // Time of day in format "15:04"
-	DayOfWeek     int    // 0-6 for weekly reminders (0 = Sunday)
+	EndTime       string // End of the event window in format "15:04", empty if none; delivery is at Time. For hourly reminders, the last time of day to repeat at
+	DayOfWeek     int    // 0-6 for weekly and monthly_weekday reminders (0 = Sunday), Workday, or Weekend for weekly ones
 	DayOfMonth    int    // 1-31 for monthly reminders
+	WeekOfMonth   int    // 1-5 or LastWeekOfMonth for monthly_weekday reminders
+	IntervalHours int    // Hours between occurrences of hourly reminders
 	LastTriggered time.Time
 	Active        bool
+	Enabled       bool // False while the user has disabled the reminder without deleting it
 	IsTodo        bool
+	Silent        bool     // Delivered without a notification sound
+	SkipDates     []string // Local dates ("2006-01-02") of skipped occurrences
+	Category      string   // Category of the reminder, e.g. "work", shown as an icon in lists; empty if none
+
+	// Reminders following the sun occur SolarOffset minutes from SolarEvent (SunriseEvent
+	// or SunsetEvent) at the given coordinates instead of at Time
+	SolarEvent  string
+	SolarOffset int
+	Latitude    float64
+	Longitude   float64
+}
+
+// Solar events of recurring reminders following the sun
+const (
+	SunriseEvent = "sunrise"
+	SunsetEvent  = "sunset"
+)
+
+// clockTimePattern matches times of day like "9", "9:00", "09.30", "21:00:00" or "9 AM"
+var clockTimePattern = regexp.MustCompile(`(?i)^(\d{1,2})(?:[:.](\d{2}))?(?::\d{2})?\s*(am|pm|a\.m\.|p\.m\.)?$`)
+
+// NormalizeClockTime converts a time of day to the zero-padded "15:04" format that
+// due reminders are matched against. Anything that isn't a valid time of day, including
+// an empty string, returns ErrInvalidTime.
+func NormalizeClockTime(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	m := clockTimePattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTime, s)
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+
+	if suffix := strings.ToLower(m[3]); suffix != "" {
+		if hour < 1 || hour > 12 {
+			return "", fmt.Errorf("%w: %q", ErrInvalidTime, s)
+		}
+		hour %= 12
+		if strings.HasPrefix(suffix, "p") {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTime, s)
+	}
+	return fmt.Sprintf("%02d:%02d", hour, minute), nil
+}
+
+// normalizeRecurringTimes normalizes the start and end time of a recurring reminder.
+// Either may be empty: reminders following the sun have no start time and most
+// reminders have no end time.
+func normalizeRecurringTimes(timeStr, endTime string) (string, string, error) {
+	var err error
+	if timeStr != "" {
+		if timeStr, err = NormalizeClockTime(timeStr); err != nil {
+			return "", "", err
+		}
+	}
+	if endTime != "" {
+		if endTime, err = NormalizeClockTime(endTime); err != nil {
+			return "", "", err
+		}
+	}
+	return timeStr, endTime, nil
 }
 
-// AddRecurringReminder adds a new recurring reminder
-func (r *ReminderRepository) AddRecurringReminder(
+// AddRecurringReminderContext adds a new recurring reminder.
+// It returns ErrLimitReached if the user already has the maximum number of active recurring reminders,
+// or ErrInvalidTime if timeStr or endTime isn't a valid time of day.
+func (r *ReminderRepository) AddRecurringReminderContext(
+	ctx context.Context,
 	chatID, userID int64,
 	label string,
 	recurringType RecurringType,
-	timeStr string,
-	dayOfWeek, dayOfMonth int,
-	isTodo bool) (int64, error) {
+	timeStr, endTime string,
+	dayOfWeek, dayOfMonth, weekOfMonth int,
+	isTodo, silent bool) (int64, error) {
+
+	timeStr, endTime, err := normalizeRecurringTimes(timeStr, endTime)
+	if err != nil {
+		return 0, err
+	}
 
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -52,20 +156,37 @@ func (r *ReminderRepository) AddRecurringReminder(
 		}
 	}()
 
-	result, err := tx.Exec(
+	if r.maxRecurring > 0 {
+		var count int
+		err = tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM recurring_reminders WHERE user_id = ? AND active = 1", userID,
+		).Scan(&count)
+		if err != nil {
+			return 0, err
+		}
+		if count >= r.maxRecurring {
+			err = ErrLimitReached
+			return 0, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
 		`INSERT INTO recurring_reminders (
             chat_id, user_id, label, created_at, 
-            recurring_type, time, day_of_week, day_of_month, active, is_todo
-        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?)`,
+            recurring_type, time, end_time, day_of_week, day_of_month, week_of_month, active, is_todo, silent
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?)`,
 		chatID,
 		userID,
 		label,
 		time.Now(),
 		string(recurringType),
 		timeStr,
+		sql.NullString{String: endTime, Valid: endTime != ""},
 		sql.NullInt64{Int64: int64(dayOfWeek), Valid: dayOfWeek >= 0},
 		sql.NullInt64{Int64: int64(dayOfMonth), Valid: dayOfMonth > 0},
+		sql.NullInt64{Int64: int64(weekOfMonth), Valid: weekOfMonth != 0},
 		boolToInt(isTodo),
+		boolToInt(silent),
 	)
 
 	if err != nil {
@@ -84,21 +205,199 @@ func (r *ReminderRepository) AddRecurringReminder(
 	return id, nil
 }
 
-// GetUserRecurringReminders gets all active recurring reminders for a user
-func (r *ReminderRepository) GetUserRecurringReminders(userID int64) ([]RecurringReminder, error) {
+// recurringColumns is the column list matching scanRecurringReminder
+const recurringColumns = `id, chat_id, user_id, label, created_at, recurring_type,
+           time, IFNULL(day_of_week, -1), IFNULL(day_of_month, -1),
+           last_triggered, active, is_todo, IFNULL(week_of_month, 0),
+           enabled, solar_event, solar_offset, IFNULL(latitude, 0), IFNULL(longitude, 0),
+           IFNULL(end_time, ''), silent, interval_hours, category,
+           IFNULL((SELECT group_concat(skip_date) FROM recurring_skips s WHERE s.reminder_id = recurring_reminders.id), '')`
+
+// scanRecurringReminder scans a row selected with recurringColumns
+func scanRecurringReminder(row rowScanner, extra ...interface{}) (RecurringReminder, error) {
+	var reminder RecurringReminder
+	var recurringTypeStr string
+	var lastTriggered sql.NullTime
+	var isTodo, silent int
+	var skipDates string
+
+	dest := []interface{}{
+		&reminder.ID, &reminder.ChatID, &reminder.UserID, &reminder.Label, &reminder.CreatedAt,
+		&recurringTypeStr, &reminder.Time, &reminder.DayOfWeek, &reminder.DayOfMonth,
+		&lastTriggered, &reminder.Active, &isTodo, &reminder.WeekOfMonth,
+		&reminder.Enabled, &reminder.SolarEvent, &reminder.SolarOffset, &reminder.Latitude, &reminder.Longitude,
+		&reminder.EndTime, &silent, &reminder.IntervalHours, &reminder.Category, &skipDates,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return reminder, err
+	}
+
+	reminder.RecurringType = RecurringType(recurringTypeStr)
+	reminder.IsTodo = isTodo > 0
+	reminder.Silent = silent > 0
+	if skipDates != "" {
+		reminder.SkipDates = strings.Split(skipDates, ",")
+	}
+	if lastTriggered.Valid {
+		reminder.LastTriggered = lastTriggered.Time
+	}
+
+	return reminder, nil
+}
+
+// OccursOn reports whether the reminder is scheduled on the given date.
+// The weekday and day of month are taken in date's location.
+func (rr RecurringReminder) OccursOn(date time.Time) bool {
+	switch rr.RecurringType {
+	case RecurringDaily, RecurringHourly:
+		return true
+	case RecurringWeekly:
+		return rr.matchesWeekday(date)
+	case RecurringMonthly:
+		return rr.DayOfMonth == date.Day()
+	case RecurringMonthlyWeekday:
+		return rr.occursOnWeekOfMonth(date)
+	default:
+		return false
+	}
+}
+
+// matchesWeekday reports whether date falls on the reminder's DayOfWeek
+func (rr RecurringReminder) matchesWeekday(date time.Time) bool {
+	weekend := date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+	switch rr.DayOfWeek {
+	case Workday:
+		return !weekend
+	case Weekend:
+		return weekend
+	}
+	return int(date.Weekday()) == rr.DayOfWeek
+}
+
+// occursOnWeekOfMonth reports whether date is the WeekOfMonth-th matching weekday of its
+// month. Months without a fifth such weekday have no occurrence.
+func (rr RecurringReminder) occursOnWeekOfMonth(date time.Time) bool {
+	if !rr.matchesWeekday(date) {
+		return false
+	}
+
+	if rr.WeekOfMonth == LastWeekOfMonth {
+		for d := date.AddDate(0, 0, 1); d.Month() == date.Month(); d = d.AddDate(0, 0, 1) {
+			if rr.matchesWeekday(d) {
+				return false
+			}
+		}
+		return true
+	}
+
+	n := 0
+	for d := date.AddDate(0, 0, 1-date.Day()); !d.After(date); d = d.AddDate(0, 0, 1) {
+		if rr.matchesWeekday(d) {
+			n++
+		}
+	}
+	return n == rr.WeekOfMonth
+}
+
+// TimeOn returns the time of the reminder's occurrence on date's calendar day, in date's
+// location. It reports false if there is none, e.g. no sunset during a polar day.
+// It doesn't check whether the reminder occurs on that day at all; see OccursOn.
+func (rr RecurringReminder) TimeOn(date time.Time) (time.Time, bool) {
+	if rr.SolarEvent != "" {
+		at, ok := utils.SunEvent(date, rr.Latitude, rr.Longitude, rr.SolarEvent == SunriseEvent)
+		return at.Add(time.Duration(rr.SolarOffset) * time.Minute), ok
+	}
+
+	t, err := time.Parse("15:04", rr.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, date.Location()), true
+}
+
+// TimesOn returns the times of the reminder's occurrences on date's calendar day, in date's
+// location: every IntervalHours hours from Time until EndTime or the end of the day for
+// hourly reminders, or the single time from TimeOn for the others. Like TimeOn, it doesn't
+// check whether the reminder occurs on that day at all.
+func (rr RecurringReminder) TimesOn(date time.Time) []time.Time {
+	at, ok := rr.TimeOn(date)
+	if !ok {
+		return nil
+	}
+	if rr.RecurringType != RecurringHourly {
+		return []time.Time{at}
+	}
+
+	until := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 0, 0, date.Location())
+	if end, err := time.Parse("15:04", rr.EndTime); err == nil {
+		until = time.Date(date.Year(), date.Month(), date.Day(), end.Hour(), end.Minute(), 0, 0, date.Location())
+	}
+	interval := time.Duration(max(rr.IntervalHours, 1)) * time.Hour
+
+	var times []time.Time
+	for ; !at.After(until) && at.Day() == date.Day(); at = at.Add(interval) {
+		times = append(times, at)
+	}
+	return times
+}
+
+// Skips reports whether the occurrence on the given date has been skipped
+func (rr RecurringReminder) Skips(date time.Time) bool {
+	return slices.Contains(rr.SkipDates, date.Format("2006-01-02"))
+}
+
+// NextOccurrence returns the first date on or after from on which the reminder occurs,
+// or the zero time if there is none within a year
+func (rr RecurringReminder) NextOccurrence(from time.Time) time.Time {
+	for date := from; date.Before(from.AddDate(1, 0, 1)); date = date.AddDate(0, 0, 1) {
+		if rr.OccursOn(date) {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// NextOccurrences returns up to n occurrence times at or after from, leaving out skipped
+// dates and looking at most a year ahead. Times are in from's location; todos have no
+// time and occur at midnight.
+func (rr RecurringReminder) NextOccurrences(from time.Time, n int) []time.Time {
+	var occurrences []time.Time
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for end := day.AddDate(1, 0, 1); len(occurrences) < n && day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !rr.OccursOn(day) || rr.Skips(day) {
+			continue
+		}
+
+		if rr.IsTodo {
+			// A todo counts all day
+			occurrences = append(occurrences, day)
+			continue
+		}
+		for _, at := range rr.TimesOn(day) {
+			// Today's occurrences count until their time has passed
+			if at.Before(from) || len(occurrences) == n {
+				continue
+			}
+			occurrences = append(occurrences, at)
+		}
+	}
+
+	return occurrences
+}
+
+// GetUserRecurringRemindersContext gets all active recurring reminders for a user
+func (r *ReminderRepository) GetUserRecurringRemindersContext(ctx context.Context, userID int64) ([]RecurringReminder, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	query := `
-    SELECT id, chat_id, user_id, label, created_at, recurring_type, 
-           time, IFNULL(day_of_week, -1), IFNULL(day_of_month, -1), 
-           last_triggered, active, is_todo
+    SELECT ` + recurringColumns + `
     FROM recurring_reminders
     WHERE user_id = ? AND active = 1
     ORDER BY created_at DESC
     `
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,149 +405,237 @@ func (r *ReminderRepository) GetUserRecurringReminders(userID int64) ([]Recurrin
 
 	var reminders []RecurringReminder
 	for rows.Next() {
-		var r RecurringReminder
-		var recurringTypeStr string
-		var lastTriggered sql.NullTime
-		var isTodo int
-
-		err := rows.Scan(
-			&r.ID, &r.ChatID, &r.UserID, &r.Label, &r.CreatedAt,
-			&recurringTypeStr, &r.Time, &r.DayOfWeek, &r.DayOfMonth,
-			&lastTriggered, &r.Active, &isTodo,
-		)
-
+		reminder, err := scanRecurringReminder(rows)
 		if err != nil {
 			return nil, err
 		}
+		reminders = append(reminders, reminder)
+	}
 
-		r.RecurringType = RecurringType(recurringTypeStr)
-		r.IsTodo = isTodo > 0
+	return reminders, rows.Err()
+}
 
-		// Handle last_triggered time
-		if lastTriggered.Valid {
-			r.LastTriggered = lastTriggered.Time
-		} else {
-			r.LastTriggered = time.Time{}
-		}
+// RecurringFilter narrows down a user's recurring reminders
+type RecurringFilter struct {
+	Type      RecurringType // Only reminders of this type, empty for any
+	DayOfWeek int           // Only reminders occurring on this weekday (0 = Sunday), -1 for any
+}
 
-		reminders = append(reminders, r)
+// GetUserRecurringRemindersFilteredContext gets a user's active recurring reminders matching the filter.
+// A weekday filter matches daily and hourly reminders and weekly and monthly_weekday reminders on that day.
+func (r *ReminderRepository) GetUserRecurringRemindersFilteredContext(ctx context.Context, userID int64, filter RecurringFilter) ([]RecurringReminder, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	query := `
+    SELECT ` + recurringColumns + `
+    FROM recurring_reminders
+    WHERE user_id = ? AND active = 1`
+	args := []interface{}{userID}
+
+	if filter.Type != "" {
+		query += " AND recurring_type = ?"
+		args = append(args, string(filter.Type))
+	}
+	if filter.DayOfWeek >= 0 {
+		query += ` AND (recurring_type IN ('daily', 'hourly')
+		    OR (recurring_type = 'weekly' AND (day_of_week = ? OR (day_of_week = ? AND ? BETWEEN 1 AND 5) OR (day_of_week = ? AND ? IN (0, 6))))
+		    OR (recurring_type = 'monthly_weekday' AND (day_of_week = ? OR (day_of_week = ? AND ? BETWEEN 1 AND 5))))`
+		args = append(args, filter.DayOfWeek, Workday, filter.DayOfWeek, Weekend, filter.DayOfWeek,
+			filter.DayOfWeek, Workday, filter.DayOfWeek)
 	}
+	query += " ORDER BY created_at DESC"
 
-	return reminders, nil
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []RecurringReminder
+	for rows.Next() {
+		reminder, err := scanRecurringReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
 }
 
-// GetDueRecurringReminders gets recurring reminders that are due (excluding todos)
-func (r *ReminderRepository) GetDueRecurringReminders(now time.Time) ([]RecurringReminder, error) {
+// GetRecurringReminderByIDContext gets an active recurring reminder owned by the user.
+// It returns nil if no such reminder exists.
+func (r *ReminderRepository) GetRecurringReminderByIDContext(ctx context.Context, id, userID int64) (*RecurringReminder, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	// Current time information
-	currentTime := now.Format("15:04")
-	currentDayOfWeek := int(now.Weekday())
-	currentDayOfMonth := now.Day()
+	reminder, err := scanRecurringReminder(r.db.QueryRowContext(ctx, `
+    SELECT `+recurringColumns+`
+    FROM recurring_reminders
+    WHERE id = ? AND user_id = ? AND active = 1`, id, userID))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &reminder, nil
+}
 
-	// Calculate the start of today
-	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// GetDueRecurringRemindersContext gets recurring reminders that are due (excluding todos).
+// A reminder is due when one of its times and its day match the current time in its owner's
+// timezone and it hasn't been triggered yet on that local day, or, for hourly reminders,
+// at that time.
+func (r *ReminderRepository) GetDueRecurringRemindersContext(ctx context.Context, now time.Time) ([]RecurringReminder, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
 
 	query := `
-    SELECT 
-        id, 
-        chat_id, 
-        user_id, 
-        label, 
-        created_at, 
-        recurring_type, 
-        time, 
-        IFNULL(day_of_week, -1) as day_of_week, 
-        IFNULL(day_of_month, -1) as day_of_month, 
-        last_triggered, 
-        active,
-        is_todo
+    SELECT ` + recurringColumns + `,
+           IFNULL((SELECT timezone FROM user_preferences p WHERE p.user_id = recurring_reminders.user_id), ?)
     FROM recurring_reminders
     WHERE active = 1 
+      AND enabled = 1
       AND is_todo = 0
-      AND time = ? 
-      AND (
-          (recurring_type = 'daily') OR
-          (recurring_type = 'weekly' AND day_of_week = ?) OR
-          (recurring_type = 'monthly' AND day_of_month = ?)
-      )
-      AND (last_triggered IS NULL OR last_triggered < ?)
+      AND user_id NOT IN (SELECT user_id FROM user_preferences WHERE paused_until > ?)
 `
 
-	rows, err := r.db.Query(
-		query,
-		currentTime,
-		currentDayOfWeek,
-		currentDayOfMonth,
-		startOfToday,
-	)
-
+	rows, err := r.db.QueryContext(ctx, query, r.defaultTimezone, now.UTC())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	locations := make(map[string]*time.Location)
+
 	var reminders []RecurringReminder
 	for rows.Next() {
-		var r RecurringReminder
-		var recurringTypeStr string
-		var isTodo int
-
-		// During scanning:
-		var lastTriggered sql.NullTime
-		err := rows.Scan(
-			&r.ID, &r.ChatID, &r.UserID, &r.Label, &r.CreatedAt,
-			&recurringTypeStr, &r.Time, &r.DayOfWeek, &r.DayOfMonth,
-			&lastTriggered, &r.Active, &isTodo,
-		)
-
+		var timezone string
+		reminder, err := scanRecurringReminder(rows, &timezone)
 		if err != nil {
 			return nil, err
 		}
 
-		if lastTriggered.Valid {
-			r.LastTriggered = lastTriggered.Time
-		} else {
-			r.LastTriggered = time.Time{}
+		location, ok := locations[timezone]
+		if !ok {
+			location, err = utils.LoadLocation(timezone)
+			if err != nil {
+				r.logger.Printf("Invalid timezone %s for user %d: %v", timezone, reminder.UserID, err)
+				location = time.UTC
+			}
+			locations[timezone] = location
+		}
+
+		localNow := now.In(location)
+		startOfToday := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, location)
+
+		if !reminder.OccursOn(localNow) {
+			continue
+		}
+		if !slices.ContainsFunc(reminder.TimesOn(localNow), func(at time.Time) bool {
+			return at.Format("15:04") == localNow.Format("15:04")
+		}) {
+			continue
+		}
+
+		// Hourly reminders are due once per slot, the others once per day
+		triggeredSince := startOfToday
+		if reminder.RecurringType == RecurringHourly {
+			triggeredSince = localNow.Truncate(time.Minute)
+		}
+		if !reminder.LastTriggered.IsZero() && !reminder.LastTriggered.Before(triggeredSince) {
+			continue
+		}
+		if reminder.Skips(localNow) {
+			continue
 		}
 
-		r.RecurringType = RecurringType(recurringTypeStr)
-		r.IsTodo = isTodo > 0
-		reminders = append(reminders, r)
+		reminders = append(reminders, reminder)
 	}
 
-	return reminders, nil
+	return reminders, rows.Err()
 }
 
 ///////
 
-// UpdateRecurringReminderLastTriggered updates the last_triggered timestamp
-func (r *ReminderRepository) UpdateRecurringReminderLastTriggered(id int64, lastTriggered time.Time) error {
+// ClaimRecurringOccurrenceContext claims the occurrence of a recurring reminder on the given
+// local date ("2006-01-02", or "2006-01-02 15:04" for hourly reminders) and updates its last_triggered timestamp in the same transaction.
+// It returns false if the occurrence was already claimed, so each occurrence is delivered
+// at most once even if the bot restarts between sending and recording it.
+func (r *ReminderRepository) ClaimRecurringOccurrenceContext(ctx context.Context, id int64, occurrenceDate string, claimedAt time.Time) (bool, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	_, err := r.db.Exec("UPDATE recurring_reminders SET last_triggered = ? WHERE id = ?", lastTriggered, id)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT OR IGNORE INTO recurring_occurrences (reminder_id, occurrence_date, claimed_at) VALUES (?, ?, ?)",
+		id, occurrenceDate, claimedAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE recurring_reminders SET last_triggered = ? WHERE id = ?", claimedAt, id); err != nil {
+		return false, err
+	}
+
+	// Older occurrences can't be claimed again; the last day's ones are kept for the
+	// delivery count in GetGlobalStatsContext, as an hourly reminder has many per day
+	if _, err = tx.ExecContext(ctx,
+		"DELETE FROM recurring_occurrences WHERE reminder_id = ? AND claimed_at < ?",
+		id, claimedAt.UTC().Add(-24*time.Hour),
+	); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
-// UpdateRecurringReminder updates a recurring reminder
-func (r *ReminderRepository) UpdateRecurringReminder(id, userID int64, label string,
-	recurringType RecurringType, timeStr string, dayOfWeek, dayOfMonth int) (bool, error) {
+// UpdateRecurringReminderContext updates a recurring reminder.
+// It returns ErrInvalidTime if timeStr or endTime isn't a valid time of day.
+func (r *ReminderRepository) UpdateRecurringReminderContext(ctx context.Context, id, userID int64, label string,
+	recurringType RecurringType, timeStr, endTime string, dayOfWeek, dayOfMonth, weekOfMonth int) (bool, error) {
+
+	timeStr, endTime, err := normalizeRecurringTimes(timeStr, endTime)
+	if err != nil {
+		return false, err
+	}
 
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		`UPDATE recurring_reminders 
-		SET label = ?, recurring_type = ?, time = ?, 
-		    day_of_week = ?, day_of_month = ?
+		SET label = ?, recurring_type = ?, time = ?, end_time = ?,
+		    day_of_week = ?, day_of_month = ?, week_of_month = ?
 		WHERE id = ? AND user_id = ? AND active = 1`,
 		label,
 		string(recurringType),
 		timeStr,
+		sql.NullString{String: endTime, Valid: endTime != ""},
 		sql.NullInt64{Int64: int64(dayOfWeek), Valid: dayOfWeek >= 0},
 		sql.NullInt64{Int64: int64(dayOfMonth), Valid: dayOfMonth > 0},
+		sql.NullInt64{Int64: int64(weekOfMonth), Valid: weekOfMonth != 0},
 		id,
 		userID,
 	)
@@ -261,12 +648,111 @@ func (r *ReminderRepository) UpdateRecurringReminder(id, userID int64, label str
 	return rowsAffected > 0, err
 }
 
-// DeleteRecurringReminder deletes a recurring reminder (sets active to false)
-func (r *ReminderRepository) DeleteRecurringReminder(id, userID int64) (bool, error) {
+// SetRecurringSolarContext makes a recurring reminder follow the sun: it occurs offset
+// minutes from event (SunriseEvent or SunsetEvent) at the given coordinates.
+// An empty event makes it occur at its fixed time again.
+func (r *ReminderRepository) SetRecurringSolarContext(ctx context.Context, id, userID int64, event string, offset int, lat, lon float64) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE recurring_reminders
+		SET solar_event = ?, solar_offset = ?, latitude = ?, longitude = ?
+		WHERE id = ? AND user_id = ? AND active = 1`,
+		event, offset,
+		sql.NullFloat64{Float64: lat, Valid: event != ""},
+		sql.NullFloat64{Float64: lon, Valid: event != ""},
+		id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// SetRecurringIntervalContext sets the hours between occurrences of an hourly reminder
+func (r *ReminderRepository) SetRecurringIntervalContext(ctx context.Context, id, userID int64, hours int) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE recurring_reminders SET interval_hours = ? WHERE id = ? AND user_id = ? AND active = 1",
+		hours, id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// SetRecurringCategoryContext sets the category of a recurring reminder, "" for none
+func (r *ReminderRepository) SetRecurringCategoryContext(ctx context.Context, id, userID int64, category string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE recurring_reminders SET category = ? WHERE id = ? AND user_id = ? AND active = 1",
+		category, id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// SkipRecurringOccurrenceContext skips the occurrence of a recurring reminder on the
+// given local date ("2006-01-02"); skipping it again has no effect. It returns false if
+// the user has no such active reminder.
+func (r *ReminderRepository) SkipRecurringOccurrenceContext(ctx context.Context, id, userID int64, date string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM recurring_reminders WHERE id = ? AND user_id = ? AND active = 1)",
+		id, userID,
+	).Scan(&exists)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO recurring_skips (reminder_id, skip_date) VALUES (?, ?)",
+		id, date,
+	)
+	return err == nil, err
+}
+
+// SetRecurringActiveContext disables or re-enables a recurring reminder without deleting it.
+// Disabled reminders are kept in the user's list but are never due.
+func (r *ReminderRepository) SetRecurringActiveContext(ctx context.Context, id, userID int64, active bool) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE recurring_reminders SET enabled = ? WHERE id = ? AND user_id = ? AND active = 1",
+		active, id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// DeleteRecurringReminderContext deletes a recurring reminder (sets active to false)
+func (r *ReminderRepository) DeleteRecurringReminderContext(ctx context.Context, id, userID int64) (bool, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		"UPDATE recurring_reminders SET active = 0 WHERE id = ? AND user_id = ? AND active = 1",
 		id, userID,
 	)
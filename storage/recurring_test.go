@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeClockTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"9:00", "09:00"},
+		{"09:00", "09:00"},
+		{"9", "09:00"},
+		{"9.30", "09:30"},
+		{" 21:05:00 ", "21:05"},
+		{"0:00", "00:00"},
+		{"23:59", "23:59"},
+		{"9 AM", "09:00"},
+		{"9 pm", "21:00"},
+		{"12 AM", "00:00"},
+		{"12:30 p.m.", "12:30"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeClockTime(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeClockTime(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"25:00", "12:60", "", "утром", "9:5", "13 PM", "0 AM"} {
+		if got, err := NormalizeClockTime(in); !errors.Is(err, ErrInvalidTime) {
+			t.Errorf("NormalizeClockTime(%q) = %q, %v; want ErrInvalidTime", in, got, err)
+		}
+	}
+}
+
+func TestNormalizeRecurringTimes(t *testing.T) {
+	start, end, err := normalizeRecurringTimes("9:00", "6:30 PM")
+	if err != nil || start != "09:00" || end != "18:30" {
+		t.Errorf("normalizeRecurringTimes(9:00, 6:30 PM) = %q, %q, %v", start, end, err)
+	}
+
+	// Reminders following the sun have no start time and most have no end time
+	start, end, err = normalizeRecurringTimes("", "")
+	if err != nil || start != "" || end != "" {
+		t.Errorf("normalizeRecurringTimes(\"\", \"\") = %q, %q, %v", start, end, err)
+	}
+
+	for _, times := range [][2]string{{"9:5", ""}, {"25:00", ""}, {"09:00", "13 PM"}} {
+		if _, _, err := normalizeRecurringTimes(times[0], times[1]); !errors.Is(err, ErrInvalidTime) {
+			t.Errorf("normalizeRecurringTimes(%q, %q) error = %v, want ErrInvalidTime", times[0], times[1], err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrTemplateNotFound is returned when the user has no template with the given name
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Template is a reminder a user saved under a name with /template save to create it again
+// without the LLM. Only the time is given when it's used.
+type Template struct {
+	ID            int64
+	UserID        int64
+	Name          string
+	Label         string
+	IsTodo        bool
+	RecurringType RecurringType // Empty for one-time reminders
+	Time          string        // Time of day ("15:04") of recurring reminders, used if no time is given
+	DayOfWeek     int           // As in RecurringReminder, -1 if unset
+	DayOfMonth    int           // As in RecurringReminder, -1 if unset
+	WeekOfMonth   int           // As in RecurringReminder, 0 if unset
+	CreatedAt     time.Time
+}
+
+// templateColumns is the column list matching scanTemplate
+const templateColumns = "id, user_id, name, label, is_todo, recurring_type, time, IFNULL(day_of_week, -1), IFNULL(day_of_month, -1), IFNULL(week_of_month, 0), created_at"
+
+// scanTemplate scans a row selected with templateColumns
+func scanTemplate(row rowScanner) (Template, error) {
+	var template Template
+	var isTodo int
+	var recurringType string
+	err := row.Scan(&template.ID, &template.UserID, &template.Name, &template.Label, &isTodo, &recurringType,
+		&template.Time, &template.DayOfWeek, &template.DayOfMonth, &template.WeekOfMonth, &template.CreatedAt)
+	template.IsTodo = isTodo > 0
+	template.RecurringType = RecurringType(recurringType)
+	return template, err
+}
+
+// SaveTemplateContext stores a template, replacing the user's template with the same name
+func (r *ReminderRepository) SaveTemplateContext(ctx context.Context, template Template) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO templates (user_id, name, label, is_todo, recurring_type, time, day_of_week, day_of_month, week_of_month, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(user_id, name) DO UPDATE SET
+            label = excluded.label, is_todo = excluded.is_todo, recurring_type = excluded.recurring_type,
+            time = excluded.time, day_of_week = excluded.day_of_week, day_of_month = excluded.day_of_month,
+            week_of_month = excluded.week_of_month, created_at = excluded.created_at`,
+		template.UserID, template.Name, template.Label, boolToInt(template.IsTodo), string(template.RecurringType),
+		template.Time,
+		sql.NullInt64{Int64: int64(template.DayOfWeek), Valid: template.DayOfWeek >= 0},
+		sql.NullInt64{Int64: int64(template.DayOfMonth), Valid: template.DayOfMonth > 0},
+		sql.NullInt64{Int64: int64(template.WeekOfMonth), Valid: template.WeekOfMonth != 0},
+		time.Now().UTC(),
+	)
+	return err
+}
+
+// GetTemplateContext gets the user's template by name. It returns ErrTemplateNotFound
+// if there is none.
+func (r *ReminderRepository) GetTemplateContext(ctx context.Context, userID int64, name string) (*Template, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	template, err := scanTemplate(r.db.QueryRowContext(ctx,
+		"SELECT "+templateColumns+" FROM templates WHERE user_id = ? AND name = ?", userID, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// GetTemplatesContext gets the user's templates sorted by name
+func (r *ReminderRepository) GetTemplatesContext(ctx context.Context, userID int64) ([]Template, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+templateColumns+" FROM templates WHERE user_id = ? ORDER BY name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []Template
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+// DeleteTemplateContext deletes the user's template by name
+func (r *ReminderRepository) DeleteTemplateContext(ctx context.Context, userID int64, name string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM templates WHERE user_id = ? AND name = ?", userID, name)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// GetLastCreatedTemplateContext builds an unnamed template from the one-time or recurring
+// reminder the user created last. It returns ErrReminderNotFound if the user has none.
+func (r *ReminderRepository) GetLastCreatedTemplateContext(ctx context.Context, userID int64) (*Template, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	reminder, reminderErr := scanReminder(r.db.QueryRowContext(ctx,
+		"SELECT "+reminderColumns+" FROM reminders WHERE user_id = ? ORDER BY id DESC LIMIT 1", userID))
+	if reminderErr != nil && !errors.Is(reminderErr, sql.ErrNoRows) {
+		return nil, reminderErr
+	}
+	recurring, recurringErr := scanRecurringReminder(r.db.QueryRowContext(ctx,
+		"SELECT "+recurringColumns+" FROM recurring_reminders WHERE user_id = ? ORDER BY id DESC LIMIT 1", userID))
+	if recurringErr != nil && !errors.Is(recurringErr, sql.ErrNoRows) {
+		return nil, recurringErr
+	}
+
+	// Reminders created before created_at was recorded are older than any recurring one
+	useRecurring := recurringErr == nil &&
+		(reminderErr != nil || !recurring.CreatedAt.Before(reminder.CreatedAt))
+	switch {
+	case useRecurring:
+		return &Template{
+			UserID:        userID,
+			Label:         recurring.Label,
+			IsTodo:        recurring.IsTodo,
+			RecurringType: recurring.RecurringType,
+			Time:          recurring.Time,
+			DayOfWeek:     recurring.DayOfWeek,
+			DayOfMonth:    recurring.DayOfMonth,
+			WeekOfMonth:   recurring.WeekOfMonth,
+		}, nil
+	case reminderErr == nil:
+		return &Template{
+			UserID:     userID,
+			Label:      reminder.Label,
+			IsTodo:     reminder.IsTodo,
+			DayOfWeek:  -1,
+			DayOfMonth: -1,
+		}, nil
+	}
+	return nil, ErrReminderNotFound
+}
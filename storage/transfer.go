@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// CreateTransferOfferContext stores an offer to transfer a user's reminders to another
+// user and returns the random token the recipient accepts it with. Expired offers are
+// dropped.
+func (r *ReminderRepository) CreateTransferOfferContext(ctx context.Context, fromUserID, toUserID int64, expiresAt time.Time) (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM transfer_offers WHERE expires_at < ?", time.Now().UTC()); err != nil {
+		return "", err
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO transfer_offers (token, from_user_id, to_user_id, expires_at) VALUES (?, ?, ?, ?)",
+		token, fromUserID, toUserID, expiresAt.UTC(),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// TakeTransferOfferContext removes the offer with the token if it was made to toUserID and
+// returns the user who made it. It reports false if there is no such offer or it expired,
+// so an offer can only be answered once and only by its recipient.
+func (r *ReminderRepository) TakeTransferOfferContext(ctx context.Context, token string, toUserID int64) (fromUserID int64, ok bool, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var expiresAt time.Time
+	err = r.db.QueryRowContext(ctx,
+		"SELECT from_user_id, expires_at FROM transfer_offers WHERE token = ? AND to_user_id = ?",
+		token, toUserID,
+	).Scan(&fromUserID, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM transfer_offers WHERE token = ?", token); err != nil {
+		return 0, false, err
+	}
+
+	return fromUserID, time.Now().Before(expiresAt), nil
+}
+
+// TransferRemindersContext hands all of a user's pending one-time reminders and active
+// recurring reminders over to another user in one transaction and returns how many of
+// each were transferred. Reminders in the sender's private chat move to the recipient's
+// private chat; those in group chats stay in their chat.
+// It returns ErrLimitReached if the recipient would exceed their limits.
+func (r *ReminderRepository) TransferRemindersContext(ctx context.Context, fromUserID, toUserID int64) (reminders, recurring int64, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, limit := range []struct {
+		max   int
+		query string
+	}{
+		{r.maxReminders, "SELECT COUNT(*) FROM reminders WHERE user_id IN (?, ?) AND notified = 0"},
+		{r.maxRecurring, "SELECT COUNT(*) FROM recurring_reminders WHERE user_id IN (?, ?) AND active = 1"},
+	} {
+		if limit.max <= 0 {
+			continue
+		}
+		var count int
+		if err = tx.QueryRowContext(ctx, limit.query, fromUserID, toUserID).Scan(&count); err != nil {
+			return 0, 0, err
+		}
+		if count > limit.max {
+			err = ErrLimitReached
+			return 0, 0, err
+		}
+	}
+
+	// In private chats the chat ID equals the user ID
+	result, err := tx.ExecContext(ctx, `
+        UPDATE reminders
+        SET user_id = ?, chat_id = CASE WHEN chat_id = ? THEN ? ELSE chat_id END
+        WHERE user_id = ? AND notified = 0`,
+		toUserID, fromUserID, toUserID, fromUserID,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	if reminders, err = result.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `
+        UPDATE recurring_reminders
+        SET user_id = ?, chat_id = CASE WHEN chat_id = ? THEN ? ELSE chat_id END
+        WHERE user_id = ? AND active = 1`,
+		toUserID, fromUserID, toUserID, fromUserID,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	if recurring, err = result.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	return reminders, recurring, nil
+}
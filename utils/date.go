@@ -23,3 +23,61 @@ func WeekdayToRussian(w time.Weekday) string {
 		return w.String()
 	}
 }
+
+// AddBusinessDays returns the day n working days after from, keeping its time of day.
+// Saturdays, Sundays and holidays are skipped; holidays are dates "2006-01-02", or "01-02"
+// for every year.
+func AddBusinessDays(from time.Time, n int, holidays []string) time.Time {
+	skip := make(map[string]bool, len(holidays))
+	for _, holiday := range holidays {
+		skip[holiday] = true
+	}
+
+	day := from
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday ||
+			skip[day.Format("2006-01-02")] || skip[day.Format("01-02")] {
+			continue
+		}
+		n--
+	}
+	return day
+}
+
+// AddMonths returns t moved by n calendar months, keeping its time of day. A day the
+// target month doesn't have becomes its last day, so a month after January 31 is the
+// end of February rather than early March as with AddDate.
+func AddMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// CalendarDaysBetween returns the number of calendar days from the date of from to the date
+// of to, both taken in from's location: 0 for the same day, 1 for the next one, -1 for the
+// previous one. Unlike dividing the difference by 24 hours, it isn't thrown off by times of
+// day or DST changes.
+func CalendarDaysBetween(from, to time.Time) int {
+	to = to.In(from.Location())
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+// PluralRussian picks the Russian noun form for n, e.g.
+// PluralRussian(5, "напоминание", "напоминания", "напоминаний") returns "напоминаний"
+func PluralRussian(n int, one, few, many string) string {
+	n = n % 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	default:
+		return many
+	}
+}
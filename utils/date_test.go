@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestCalendarDaysBetween(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(loc *time.Location, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"same day", at(newYork, 5, 10, 8, 0), at(newYork, 5, 10, 22, 0), 0},
+		{"next day", at(newYork, 5, 10, 8, 0), at(newYork, 5, 11, 8, 0), 1},
+		{"previous day", at(newYork, 5, 10, 8, 0), at(newYork, 5, 9, 23, 0), -1},
+		{"a minute after midnight", at(newYork, 5, 10, 23, 59), at(newYork, 5, 11, 0, 1), 1},
+		{"a minute before midnight", at(newYork, 5, 10, 0, 1), at(newYork, 5, 10, 23, 59), 0},
+		// 02:00 UTC is still the evening before in New York
+		{"other location", at(newYork, 5, 10, 20, 0), at(time.UTC, 5, 11, 2, 0), 0},
+		{"other location next day", at(newYork, 5, 10, 20, 0), at(time.UTC, 5, 11, 5, 0), 1},
+		// The day clocks go forward is 23 hours long, the day they go back 25 hours
+		{"spring forward", at(newYork, 3, 8, 23, 30), at(newYork, 3, 9, 23, 30), 1},
+		{"fall back", at(newYork, 11, 1, 0, 0), at(newYork, 11, 2, 23, 59), 1},
+		{"week", at(newYork, 3, 5, 12, 0), at(newYork, 3, 12, 12, 0), 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalendarDaysBetween(tt.from, tt.to); got != tt.want {
+				t.Errorf("CalendarDaysBetween(%v, %v) = %d, want %d", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import "regexp"
+
+var (
+	// explicitTimePattern matches an explicit or relative time, e.g. "в 8", "10:30", "через 2 часа"
+	explicitTimePattern = regexp.MustCompile(`(?i)\d{1,2}[:.]\d{2}|(^|[^\p{L}])(в|к|до|с|через)\s+\d{1,2}([^\d]|$)|(^|[^\p{L}])через([^\p{L}]|$)`)
+
+	morningPattern   = regexp.MustCompile(`(?i)(^|[^\p{L}])(утром|с утра|поутру)([^\p{L}]|$)`)
+	afternoonPattern = regexp.MustCompile(`(?i)(^|[^\p{L}])(днём|днем|в обед)([^\p{L}]|$)`)
+	eveningPattern   = regexp.MustCompile(`(?i)(^|[^\p{L}])(вечером)([^\p{L}]|$)`)
+)
+
+// ApplyDayTimes replaces vague times of day ("утром", "днём", "вечером") in text with
+// the given times ("15:04"), e.g. "завтра утром" becomes "завтра в 09:00".
+// Text that already has an explicit time, like "вечером в 8", is left as is.
+func ApplyDayTimes(text, morning, afternoon, evening string) string {
+	if explicitTimePattern.MatchString(text) {
+		return text
+	}
+
+	for pattern, at := range map[*regexp.Regexp]string{
+		morningPattern:   morning,
+		afternoonPattern: afternoon,
+		eveningPattern:   evening,
+	} {
+		if at != "" {
+			text = pattern.ReplaceAllString(text, "${1}в "+at+"${3}")
+		}
+	}
+	return text
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Fuzzy and fractional quantities are replaced with concrete ones before a message
+// reaches the LLM, which often gets them wrong. The assumed values are:
+//
+//	полчаса, полдня, ...     0.5
+//	четверть (часа)          0.25
+//	полтора, полторы         1.5
+//	N с половиной            N + 0.5
+//	1.5, 2,5                 as written
+//	пару, пара, парочку      2
+//	несколько                3
+//
+// Fractions are expressed in a smaller unit: "через полтора часа" becomes
+// "через 90 минут", "через 2,5 дня" becomes "через 60 часов". Decimals with two digits
+// after the point look like clock times ("в 9.30 часов"), so they are only replaced
+// after "через", "на" or "спустя".
+
+// quantityUnit is a unit of time with its Russian forms for PluralRussian
+type quantityUnit struct {
+	one, few, many string
+	smaller        string  // Unit used for fractions, empty for minutes
+	factor         float64 // Number of smaller units in this one
+}
+
+var quantityUnits = map[string]quantityUnit{
+	"minute": {"минуту", "минуты", "минут", "", 0},
+	"hour":   {"час", "часа", "часов", "minute", 60},
+	"day":    {"день", "дня", "дней", "hour", 24},
+	"week":   {"неделю", "недели", "недель", "day", 7},
+	"month":  {"месяц", "месяца", "месяцев", "day", 30},
+	"year":   {"год", "года", "лет", "month", 12},
+}
+
+// quantityUnitStems map the beginning of a Russian unit word to its unit
+var quantityUnitStems = []struct {
+	stem, unit string
+}{
+	{"мин", "minute"},
+	{"час", "hour"},
+	{"ден", "day"},
+	{"дн", "day"},
+	{"сут", "day"},
+	{"недел", "week"},
+	{"месяц", "month"},
+	{"год", "year"},
+	{"лет", "year"},
+}
+
+// quantityNumbers are number words that may precede "с половиной"
+var quantityNumbers = map[string]float64{
+	"один": 1, "одну": 1, "одна": 1, "два": 2, "две": 2, "три": 3, "четыре": 4, "пять": 5,
+}
+
+const unitPattern = `(минут\p{L}*|час\p{L}*|день|дн\p{L}*|сут\p{L}*|недел\p{L}*|месяц\p{L}*|год\p{L}*|лет)`
+
+var (
+	// quantityPattern matches a fuzzy or fractional quantity followed by a unit, and the
+	// relative marker before it if there is one
+	quantityPattern = regexp.MustCompile(`(?i)(^|[^\p{L}\d])((?:через|на|спустя)\s+)?` +
+		`(полтора|полторы|пару|пара|парочку|несколько|четверть|(?:\d+|один|одну|одна|два|две|три|четыре|пять)\s+с\s+половиной|\d+[.,]\d+)` +
+		`\s+` + unitPattern + `([^\p{L}]|$)`)
+	// halfPattern matches "полчаса", "полдня" and the like
+	halfPattern = regexp.MustCompile(`(?i)(^|[^\p{L}\d])пол(часа|дня|суток|недели|месяца|года)([^\p{L}]|$)`)
+	// clockTimeQuantityPattern matches decimals that may be a time of day, like "9.30" or "15,45"
+	clockTimeQuantityPattern = regexp.MustCompile(`^\d{1,2}[.,]\d{2}$`)
+)
+
+// NormalizeQuantities replaces fuzzy and fractional time quantities in text with concrete ones
+func NormalizeQuantities(text string) string {
+	text = halfPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := halfPattern.FindStringSubmatch(match)
+		unit, ok := quantityUnitFor(m[2])
+		if !ok {
+			return match
+		}
+		return m[1] + formatQuantity(0.5, unit) + m[3]
+	})
+
+	return quantityPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := quantityPattern.FindStringSubmatch(match)
+		if m[2] == "" && clockTimeQuantityPattern.MatchString(m[3]) {
+			return match
+		}
+		value, ok := quantityValue(strings.ToLower(m[3]))
+		unit, unitOK := quantityUnitFor(m[4])
+		if !ok || !unitOK {
+			return match
+		}
+		return m[1] + m[2] + formatQuantity(value, unit) + m[5]
+	})
+}
+
+// quantityValue returns the number a fuzzy or fractional quantity stands for
+func quantityValue(quantity string) (float64, bool) {
+	switch quantity {
+	case "полтора", "полторы":
+		return 1.5, true
+	case "пару", "пара", "парочку":
+		return 2, true
+	case "несколько":
+		return 3, true
+	case "четверть":
+		return 0.25, true
+	}
+
+	if number, ok := strings.CutSuffix(quantity, "с половиной"); ok {
+		number = strings.TrimSpace(number)
+		if n, ok := quantityNumbers[number]; ok {
+			return n + 0.5, true
+		}
+		n, err := strconv.Atoi(number)
+		return float64(n) + 0.5, err == nil
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(quantity, ",", ".", 1), 64)
+	return value, err == nil
+}
+
+// quantityUnitFor returns the unit of a Russian unit word in any form
+func quantityUnitFor(word string) (string, bool) {
+	word = strings.ToLower(word)
+	for _, s := range quantityUnitStems {
+		if strings.HasPrefix(word, s.stem) {
+			return s.unit, true
+		}
+	}
+	return "", false
+}
+
+// formatQuantity formats value units, moving to smaller units until the value is whole,
+// e.g. 1.5 hours as "90 минут"
+func formatQuantity(value float64, unit string) string {
+	u := quantityUnits[unit]
+	for value != math.Trunc(value) && u.smaller != "" {
+		value *= u.factor
+		unit = u.smaller
+		u = quantityUnits[unit]
+	}
+
+	n := int(math.Round(value))
+	return fmt.Sprintf("%d %s", n, PluralRussian(n, u.one, u.few, u.many))
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestNormalizeQuantities(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"через полчаса позвонить", "через 30 минут позвонить"},
+		{"через полтора часа", "через 90 минут"},
+		{"через 2 с половиной часа", "через 150 минут"},
+		{"через два с половиной дня", "через 60 часов"},
+		{"через 2,5 дня", "через 60 часов"},
+		{"через 1.5 часа", "через 90 минут"},
+		{"через пару дней", "через 2 дня"},
+		{"через несколько недель", "через 3 недели"},
+		{"через четверть часа", "через 15 минут"},
+		// Two digits after the point count as a quantity only after a relative marker
+		{"через 1.25 часа", "через 75 минут"},
+		{"на 1,25 часа", "на 75 минут"},
+		{"спустя 0.75 часа", "спустя 45 минут"},
+		// Otherwise they are clock times and left alone
+		{"встреча в 9.30 часов утра", "встреча в 9.30 часов утра"},
+		{"в 15,45 часов", "в 15,45 часов"},
+		{"созвон 10.00 часов", "созвон 10.00 часов"},
+		{"позвонить маме", "позвонить маме"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeQuantities(tt.in); got != tt.want {
+			t.Errorf("NormalizeQuantities(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
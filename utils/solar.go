@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// sunZenith is the zenith angle (degrees) of the sun's upper limb at sunrise and sunset,
+// accounting for atmospheric refraction
+const sunZenith = 90.833
+
+// SunEvent returns the time of sunrise (or sunset) at the given coordinates on date's
+// calendar day, in date's location. It reports false if the sun doesn't rise or set that
+// day (polar day or night). The approximation is accurate to a couple of minutes.
+func SunEvent(date time.Time, lat, lon float64, sunrise bool) (time.Time, bool) {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	toDeg := func(rad float64) float64 { return rad * 180 / math.Pi }
+	normalize := func(v, max float64) float64 {
+		v = math.Mod(v, max)
+		if v < 0 {
+			v += max
+		}
+		return v
+	}
+
+	// Approximate time of the event as a fraction of the year
+	lngHour := lon / 15
+	approxHour := 18.0
+	if sunrise {
+		approxHour = 6
+	}
+	t := float64(date.YearDay()) + (approxHour-lngHour)/24
+
+	// Sun's mean anomaly, true longitude and right ascension
+	m := 0.9856*t - 3.289
+	l := normalize(m+1.916*math.Sin(toRad(m))+0.020*math.Sin(toRad(2*m))+282.634, 360)
+	ra := normalize(toDeg(math.Atan(0.91764*math.Tan(toRad(l)))), 360)
+	// Right ascension is in the same quadrant as the true longitude
+	ra = (ra + math.Floor(l/90)*90 - math.Floor(ra/90)*90) / 15
+
+	// Sun's declination and local hour angle
+	sinDec := 0.39782 * math.Sin(toRad(l))
+	cosDec := math.Cos(math.Asin(sinDec))
+	cosH := (math.Cos(toRad(sunZenith)) - sinDec*math.Sin(toRad(lat))) / (cosDec * math.Cos(toRad(lat)))
+	if cosH > 1 || cosH < -1 {
+		return time.Time{}, false
+	}
+
+	h := toDeg(math.Acos(cosH))
+	if sunrise {
+		h = 360 - h
+	}
+
+	localMeanTime := h/15 + ra - 0.06571*t - 6.622
+	utcHours := normalize(localMeanTime-lngHour, 24)
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	at := day.Add(time.Duration(utcHours * float64(time.Hour))).In(date.Location())
+
+	// The UTC day may differ from the local one far from Greenwich
+	if at.YearDay() != date.YearDay() {
+		if at.Before(date) {
+			at = at.Add(24 * time.Hour)
+		} else {
+			at = at.Add(-24 * time.Hour)
+		}
+	}
+
+	return at.Truncate(time.Minute), true
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SanitizeText removes invalid UTF-8 and control characters other than newlines and tabs
+// from user input. Telegram rejects messages with invalid UTF-8, so labels are cleaned
+// before they are stored and later sent.
+func SanitizeText(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
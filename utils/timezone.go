@@ -1 +1,355 @@
 package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// timezoneAliases maps lower-case city names and common aliases to IANA timezones
+var timezoneAliases = map[string]string{
+	// Russia
+	"москва":           "Europe/Moscow",
+	"мск":              "Europe/Moscow",
+	"moscow":           "Europe/Moscow",
+	"санкт-петербург":  "Europe/Moscow",
+	"петербург":        "Europe/Moscow",
+	"питер":            "Europe/Moscow",
+	"спб":              "Europe/Moscow",
+	"saint petersburg": "Europe/Moscow",
+	"st petersburg":    "Europe/Moscow",
+	"казань":           "Europe/Moscow",
+	"нижний новгород":  "Europe/Moscow",
+	"ростов-на-дону":   "Europe/Moscow",
+	"краснодар":        "Europe/Moscow",
+	"сочи":             "Europe/Moscow",
+	"воронеж":          "Europe/Moscow",
+	"калининград":      "Europe/Kaliningrad",
+	"самара":           "Europe/Samara",
+	"ижевск":           "Europe/Samara",
+	"волгоград":        "Europe/Volgograd",
+	"саратов":          "Europe/Saratov",
+	"ульяновск":        "Europe/Ulyanovsk",
+	"астрахань":        "Europe/Astrakhan",
+	"екатеринбург":     "Asia/Yekaterinburg",
+	"екб":              "Asia/Yekaterinburg",
+	"yekaterinburg":    "Asia/Yekaterinburg",
+	"челябинск":        "Asia/Yekaterinburg",
+	"пермь":            "Asia/Yekaterinburg",
+	"уфа":              "Asia/Yekaterinburg",
+	"тюмень":           "Asia/Yekaterinburg",
+	"омск":             "Asia/Omsk",
+	"новосибирск":      "Asia/Novosibirsk",
+	"новосиб":          "Asia/Novosibirsk",
+	"novosibirsk":      "Asia/Novosibirsk",
+	"барнаул":          "Asia/Barnaul",
+	"томск":            "Asia/Tomsk",
+	"кемерово":         "Asia/Novokuznetsk",
+	"новокузнецк":      "Asia/Novokuznetsk",
+	"красноярск":       "Asia/Krasnoyarsk",
+	"иркутск":          "Asia/Irkutsk",
+	"чита":             "Asia/Chita",
+	"якутск":           "Asia/Yakutsk",
+	"владивосток":      "Asia/Vladivostok",
+	"хабаровск":        "Asia/Vladivostok",
+	"магадан":          "Asia/Magadan",
+	"сахалин":          "Asia/Sakhalin",
+	"южно-сахалинск":   "Asia/Sakhalin",
+	"камчатка":         "Asia/Kamchatka",
+	"петропавловск-камчатский": "Asia/Kamchatka",
+
+	// Neighbouring countries
+	"минск":     "Europe/Minsk",
+	"киев":      "Europe/Kiev",
+	"алматы":    "Asia/Almaty",
+	"астана":    "Asia/Almaty",
+	"ташкент":   "Asia/Tashkent",
+	"бишкек":    "Asia/Bishkek",
+	"душанбе":   "Asia/Dushanbe",
+	"тбилиси":   "Asia/Tbilisi",
+	"ереван":    "Asia/Yerevan",
+	"баку":      "Asia/Baku",
+	"кишинев":   "Europe/Chisinau",
+	"рига":      "Europe/Riga",
+	"вильнюс":   "Europe/Vilnius",
+	"таллин":    "Europe/Tallinn",
+	"таллинн":   "Europe/Tallinn",
+	"стамбул":   "Europe/Istanbul",
+	"тель-авив": "Asia/Jerusalem",
+	"израиль":   "Asia/Jerusalem",
+
+	// Elsewhere
+	"лондон":       "Europe/London",
+	"берлин":       "Europe/Berlin",
+	"париж":        "Europe/Paris",
+	"прага":        "Europe/Prague",
+	"варшава":      "Europe/Warsaw",
+	"белград":      "Europe/Belgrade",
+	"дубай":        "Asia/Dubai",
+	"бангкок":      "Asia/Bangkok",
+	"пхукет":       "Asia/Bangkok",
+	"бали":         "Asia/Makassar",
+	"токио":        "Asia/Tokyo",
+	"нью-йорк":     "America/New_York",
+	"лос-анджелес": "America/Los_Angeles",
+	"utc":          "UTC",
+	"gmt":          "UTC",
+}
+
+// utcOffsetPattern matches offsets like "+3", "-05:30", "UTC+5" and "GMT +0530"
+var utcOffsetPattern = regexp.MustCompile(`^(?:utc|gmt)?\s*([+-])\s*(\d{1,2})(?::?(\d{2}))?$`)
+
+// ParseUTCOffset parses a UTC offset and returns its canonical name ("UTC+05:30")
+// and the offset in seconds
+func ParseUTCOffset(input string) (string, int, bool) {
+	m := utcOffsetPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(input)))
+	if m == nil {
+		return "", 0, false
+	}
+
+	hours, _ := strconv.Atoi(m[2])
+	minutes := 0
+	if m[3] != "" {
+		minutes, _ = strconv.Atoi(m[3])
+	}
+	if hours > 14 || minutes >= 60 {
+		return "", 0, false
+	}
+
+	offset := hours*3600 + minutes*60
+	if offset == 0 {
+		return "UTC", 0, true
+	}
+	if m[1] == "-" {
+		offset = -offset
+	}
+
+	return fmt.Sprintf("UTC%s%02d:%02d", m[1], hours, minutes), offset, true
+}
+
+// LoadLocation is like time.LoadLocation but also accepts UTC offsets
+// (as produced by ParseUTCOffset), which become fixed zones without DST
+func LoadLocation(name string) (*time.Location, error) {
+	if canonical, offset, ok := ParseUTCOffset(name); ok {
+		if offset == 0 {
+			return time.UTC, nil
+		}
+		return time.FixedZone(canonical, offset), nil
+	}
+	return time.LoadLocation(name)
+}
+
+// normalizeTimezoneInput lower-cases the input and strips decorations like "г."
+func normalizeTimezoneInput(input string) string {
+	s := strings.ToLower(strings.TrimSpace(input))
+	s = strings.ReplaceAll(s, "ё", "е")
+	s = strings.TrimPrefix(s, "г.")
+	s = strings.TrimPrefix(s, "город ")
+	s = strings.ReplaceAll(s, "_", " ")
+	s = strings.ReplaceAll(s, ".", "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ResolveTimezone maps user input to a timezone name that LoadLocation accepts.
+// It accepts exact IANA names (in any case), Russian and English city names, common aliases
+// and, if none of those match, UTC offsets like "+3" or "UTC+5:30".
+func ResolveTimezone(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", false
+	}
+
+	// Exact IANA name
+	if strings.Contains(input, "/") {
+		if _, err := time.LoadLocation(input); err == nil {
+			return input, true
+		}
+	}
+
+	name := normalizeTimezoneInput(input)
+	if zone, ok := timezoneAliases[name]; ok {
+		return zone, true
+	}
+
+	// IANA name in the wrong case, or just its city part ("berlin", "europe/berlin")
+	for _, zone := range timezoneAliases {
+		lower := normalizeTimezoneInput(zone)
+		city := lower[strings.LastIndex(lower, "/")+1:]
+		if name == lower || name == city {
+			return zone, true
+		}
+	}
+
+	if canonical, _, ok := ParseUTCOffset(input); ok {
+		return canonical, true
+	}
+
+	return "", false
+}
+
+// SuggestTimezones returns up to limit city names closest to the input,
+// to offer when ResolveTimezone finds no match
+func SuggestTimezones(input string, limit int) []string {
+	name := normalizeTimezoneInput(input)
+	// Compare only the city part of IANA-like input ("europe/mosco")
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	var candidates []candidate
+	for alias := range timezoneAliases {
+		candidates = append(candidates, candidate{alias, levenshtein(name, alias)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var suggestions []string
+	for _, c := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		// Skip candidates that share almost nothing with the input
+		if c.distance > max(1, utf8.RuneCountInString(c.name)/3) {
+			continue
+		}
+		suggestions = append(suggestions, Capitalize(c.name))
+	}
+
+	return suggestions
+}
+
+// Capitalize upper-cases the first letter of s
+func Capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// timezoneLandmark is a city used to guess a timezone from coordinates
+type timezoneLandmark struct {
+	lat, lon float64
+	zone     string
+}
+
+// timezoneLandmarks cover the zones of Russia and nearby countries
+var timezoneLandmarks = []timezoneLandmark{
+	{54.71, 20.51, "Europe/Kaliningrad"},
+	{55.76, 37.62, "Europe/Moscow"},
+	{59.94, 30.31, "Europe/Moscow"},
+	{55.79, 49.12, "Europe/Moscow"},
+	{47.24, 39.71, "Europe/Moscow"},
+	{53.20, 50.15, "Europe/Samara"},
+	{48.71, 44.51, "Europe/Volgograd"},
+	{51.53, 46.03, "Europe/Saratov"},
+	{56.84, 60.60, "Asia/Yekaterinburg"},
+	{54.74, 55.97, "Asia/Yekaterinburg"},
+	{57.15, 65.53, "Asia/Yekaterinburg"},
+	{54.99, 73.37, "Asia/Omsk"},
+	{55.03, 82.92, "Asia/Novosibirsk"},
+	{53.35, 83.78, "Asia/Barnaul"},
+	{56.01, 92.87, "Asia/Krasnoyarsk"},
+	{52.29, 104.28, "Asia/Irkutsk"},
+	{52.03, 113.50, "Asia/Chita"},
+	{62.03, 129.73, "Asia/Yakutsk"},
+	{43.12, 131.89, "Asia/Vladivostok"},
+	{48.48, 135.08, "Asia/Vladivostok"},
+	{59.56, 150.80, "Asia/Magadan"},
+	{46.96, 142.73, "Asia/Sakhalin"},
+	{53.02, 158.65, "Asia/Kamchatka"},
+	{53.90, 27.56, "Europe/Minsk"},
+	{50.45, 30.52, "Europe/Kiev"},
+	{43.24, 76.89, "Asia/Almaty"},
+	{41.30, 69.24, "Asia/Tashkent"},
+	{42.87, 74.59, "Asia/Bishkek"},
+	{41.72, 44.79, "Asia/Tbilisi"},
+	{40.18, 44.51, "Asia/Yerevan"},
+	{40.41, 49.87, "Asia/Baku"},
+	{41.01, 28.98, "Europe/Istanbul"},
+	{32.09, 34.78, "Asia/Jerusalem"},
+	{25.20, 55.27, "Asia/Dubai"},
+}
+
+// maxLandmarkDistance is how far (in km) a location may be from the nearest landmark
+// for its timezone to be used
+const maxLandmarkDistance = 500
+
+// TimezoneByLocation guesses the timezone at the given coordinates: the zone of the
+// nearest known city, or a UTC offset derived from the longitude if none is close
+func TimezoneByLocation(lat, lon float64) string {
+	best, bestDistance := "", math.Inf(1)
+	for _, l := range timezoneLandmarks {
+		if d := distanceKm(lat, lon, l.lat, l.lon); d < bestDistance {
+			best, bestDistance = l.zone, d
+		}
+	}
+	if bestDistance <= maxLandmarkDistance {
+		return best
+	}
+
+	// Solar time: 15 degrees of longitude per hour
+	canonical, _, _ := ParseUTCOffset(fmt.Sprintf("%+d", int(math.Round(lon/15))))
+	return canonical
+}
+
+// TimezoneCoordinates returns the coordinates of a known city in the timezone.
+// It reports false for zones without one, including UTC offsets.
+func TimezoneCoordinates(zone string) (lat, lon float64, ok bool) {
+	for _, l := range timezoneLandmarks {
+		if l.zone == zone {
+			return l.lat, l.lon, true
+		}
+	}
+	return 0, 0, false
+}
+
+// distanceKm returns the great-circle distance between two points
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}